@@ -58,7 +58,7 @@ func (m *Module) getJWTForCache(schema *model.Schema, token, jwtKey string) (str
 
 		salt := info.Info[:saltLen]
 		uid := info.Info[saltLen:]
-		f, err := model.FindCols[string](schema.Model(), "salt", model.ID(uid))
+		f, err := model.FindCols[string](systemStore(schema), "salt", model.ID(uid))
 		if err != nil || len(f) == 0 || f[0] != salt {
 			return [2]interface{}{}, false
 		}
@@ -90,7 +90,7 @@ func (h *Index) refreshToken(c *znet.Context) (interface{}, error) {
 
 	salt := info.Info[:saltLen]
 	uid := info.Info[saltLen:]
-	f, err := model.FindCols[string](h.accoutModel.Model(), "salt", model.ID(uid))
+	f, err := model.FindCols[string](systemStore(h.accoutModel), "salt", model.ID(uid))
 	if err != nil || len(f) == 0 || f[0] != salt {
 		return nil, zerror.InvalidInput.Text("refresh_token 已失效")
 	}
@@ -201,7 +201,7 @@ func (h *Index) login(c *znet.Context) (result interface{}, err error) {
 		return
 	}
 
-	user, err := model.FindOne[ztype.Map](h.accoutModel.Model(), model.Filter{
+	user, err := model.FindOne[ztype.Map](systemStore(h.accoutModel), model.Filter{
 		"account": account,
 	})
 	if err != nil {
@@ -329,7 +329,7 @@ func (h *Index) AnyPassword(c *znet.Context) (data any, err error) {
 	}
 
 	uid := h.module.Request.UID(c)
-	user, err := model.FindOne[ztype.Map](h.accoutModel.Model(), model.ID(uid), func(so *model.CondOptions) {
+	user, err := model.FindOne[ztype.Map](systemStore(h.accoutModel), model.ID(uid), func(so *model.CondOptions) {
 		so.Fields = []string{model.IDKey(), "password", "salt"}
 	})
 	if err != nil {
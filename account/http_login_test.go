@@ -0,0 +1,42 @@
+package account
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/zjson"
+)
+
+func TestHTTPLoginAndRefreshToken(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	env := newHTTPTestEnv(t)
+	defer env.cleanup()
+
+	// 密码错误不能登录
+	payload, _ := json.Marshal(map[string]string{"account": "manage", "password": "wrong-pass"})
+	w := env.request("POST", "/api/base/login", payload, "")
+	tt.EqualTrue(zjson.ParseBytes(w.Body.Bytes()).Get("code").Int() != 0)
+
+	// 密码、盐为隐藏字段，登录仍需正常校验
+	payload, _ = json.Marshal(map[string]string{"account": "manage", "password": "Aa123456"})
+	w = env.request("POST", "/api/base/login", payload, "")
+	tt.Equal(200, w.Code)
+	data := zjson.ParseBytes(w.Body.Bytes()).Get("data")
+	tt.EqualTrue(data.Get("token").String() != "")
+	refreshToken := data.Get("refresh_token").String()
+	tt.EqualTrue(refreshToken != "")
+
+	// 刷新 token 需要读取盐
+	payload, _ = json.Marshal(map[string]string{"refresh_token": refreshToken})
+	w = env.request("POST", "/api/base/refresh-token", payload, "")
+	tt.Equal(200, w.Code)
+	data = zjson.ParseBytes(w.Body.Bytes()).Get("data")
+	token := data.Get("token").String()
+	tt.EqualTrue(token != "")
+
+	// 新 token 可通过缓存的盐校验
+	w = env.request("GET", "/api/base/info", nil, token)
+	tt.Equal(200, w.Code)
+	tt.Equal("manage", zjson.ParseBytes(w.Body.Bytes()).Get("data.account").String())
+}
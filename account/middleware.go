@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/zlsgo/app_module/account/jwt"
+	"github.com/zlsgo/app_module/model"
 
 	"github.com/sohaha/zlsgo/zarray"
 	"github.com/sohaha/zlsgo/zcli"
@@ -60,6 +61,10 @@ func (m *Module) initMiddleware() error {
 
 	m.verifyPermissions = []znet.Handler{}
 
+	if m.Options.TenantResolver != nil && m.Options.TenantAccess == nil {
+		return errors.New("TenantAccess is required when TenantResolver is set")
+	}
+
 	if m.Options.Session != nil {
 		expireDuration := time.Duration(m.Options.Expire) * time.Second
 		if expireDuration <= 0 {
@@ -142,6 +147,11 @@ func (m *Module) initMiddleware() error {
 			return permissionDenied(errors.New("用户已被禁用"))
 		}
 
+		// 租户需在认证后解析，并校验当前用户是否可访问
+		if err = model.BindAuthorizedTenant(c, m.Options.TenantResolver, m.Options.TenantAccess); err != nil {
+			return err
+		}
+
 		isInlayAdmin := u.Get("administrator").Bool()
 		c.WithValue(ctxWithIsInlayAdmin, isInlayAdmin)
 
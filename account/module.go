@@ -57,23 +57,25 @@ func (m *Module) Name() string {
 }
 
 type Options struct {
-	InitDB               func() (*zdb.DB, error)  `z:"-"`
-	SSEReconnect         func(uid, lastID string) `z:"-"`
-	InlayRBAC            *rbac.RBAC               `z:"-"`
-	key                  string                   `z:"key"`
-	ApiPrefix            string                   `z:"prefix"`
-	RBACFile             string                   `z:"rbac_file"`
-	AdminDefaultPassword string                   `z:"admin_default_password"`
-	ModelPrefix          string                   `z:"model_prefix"`
-	InlayUser            ztype.Maps               `z:"inlay_user"`
-	Models               []schema.Schema          `z:"-"`
-	SSE                  znet.SSEOption           `z:"-"`
-	Expire               int64                    `z:"expire"`
-	RefreshExpire        int64                    `z:"refresh_expire"`
-	Only                 bool                     `z:"only"`
-	DisabledLogIP        bool                     `z:"disabled_ip"`
-	EnableRegister       bool                     `z:"register"`
-	Session              zsession.Store           `z:"-"`
+	InitDB               func() (*zdb.DB, error)                    `z:"-"`
+	SSEReconnect         func(uid, lastID string)                   `z:"-"`
+	TenantResolver       func(c *znet.Context) (string, error)      `z:"-"`
+	TenantAccess         func(c *znet.Context, tenant string) error `z:"-"`
+	InlayRBAC            *rbac.RBAC                                 `z:"-"`
+	key                  string                                     `z:"key"`
+	ApiPrefix            string                                     `z:"prefix"`
+	RBACFile             string                                     `z:"rbac_file"`
+	AdminDefaultPassword string                                     `z:"admin_default_password"`
+	ModelPrefix          string                                     `z:"model_prefix"`
+	InlayUser            ztype.Maps                                 `z:"inlay_user"`
+	Models               []schema.Schema                            `z:"-"`
+	SSE                  znet.SSEOption                             `z:"-"`
+	Expire               int64                                      `z:"expire"`
+	RefreshExpire        int64                                      `z:"refresh_expire"`
+	Only                 bool                                       `z:"only"`
+	DisabledLogIP        bool                                       `z:"disabled_ip"`
+	EnableRegister       bool                                       `z:"register"`
+	Session              zsession.Store                             `z:"-"`
 }
 
 func (o Options) ConfKey() string {
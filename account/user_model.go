@@ -84,6 +84,9 @@ func accountModelDefine(p *Module) error {
 				Size:     4,
 				Nullable: true,
 				Label:    "盐",
+				Options: mSchema.FieldOption{
+					Visibility: mSchema.VisibilityHidden,
+				},
 			},
 			"login_at": {
 				Type:     schema.Time,
@@ -153,7 +156,8 @@ func accountModelDefine(p *Module) error {
 				Label: "密码",
 				Type:  schema.String,
 				Options: mSchema.FieldOption{
-					Crypt: "PASSWORD",
+					Crypt:      "PASSWORD",
+					Visibility: mSchema.VisibilityHidden,
 				},
 				Validations: []mSchema.Validations{
 					{
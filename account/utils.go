@@ -1,12 +1,19 @@
 package account
 
 import (
+	"context"
 	"errors"
 
 	"github.com/sohaha/zlsgo/znet"
 	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model"
 )
 
+// systemStore 用于读取密码、盐等隐藏字段的内部查询，不应用字段可见性策略
+func systemStore(schema *model.Schema) *model.Store {
+	return schema.Model().WithContext(model.WithSystem(context.Background()))
+}
+
 type requestWith struct {
 	module *Module
 }
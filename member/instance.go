@@ -82,6 +82,11 @@ func initInstance(m *Module) error {
 				_ = member.Info.Delete("password")
 				_ = member.Info.Delete("salt")
 
+				// 租户需在认证后解析，并校验当前用户是否可访问
+				if err = model.BindAuthorizedTenant(c, m.Options.TenantResolver, m.Options.TenantAccess); err != nil {
+					return err
+				}
+
 				c.Next()
 
 				return nil
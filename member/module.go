@@ -38,15 +38,17 @@ func (m *Module) Name() string {
 }
 
 type Options struct {
-	InitDB           func() (*zdb.DB, error) `z:"-"`
-	ApiPrefix        string                  `z:"prefix"`
-	key              string                  `z:"-"`
-	Providers        []auth.AuthProvider     `z:"-"`
-	EnabledProviders []string                `z:"enabled_providers"`
-	Expire           int                     `z:"expire"`
-	ModelPrefix      string                  `z:"model_prefix"`
-	EnableRegister   bool                    `z:"enable_register"`
-	Only             bool                    `z:"only"`
+	InitDB           func() (*zdb.DB, error)                    `z:"-"`
+	TenantResolver   func(c *znet.Context) (string, error)      `z:"-"`
+	TenantAccess     func(c *znet.Context, tenant string) error `z:"-"`
+	ApiPrefix        string                                     `z:"prefix"`
+	key              string                                     `z:"-"`
+	Providers        []auth.AuthProvider                        `z:"-"`
+	EnabledProviders []string                                   `z:"enabled_providers"`
+	Expire           int                                        `z:"expire"`
+	ModelPrefix      string                                     `z:"model_prefix"`
+	EnableRegister   bool                                       `z:"enable_register"`
+	Only             bool                                       `z:"only"`
 }
 
 func (o Options) ConfKey() string {
@@ -80,6 +82,10 @@ func (m *Module) Load(di zdi.Invoker) (any, error) {
 
 		m.Options.key = zstring.Pad(m.Options.key, 32, "0", zstring.PadRight)
 
+		if m.Options.TenantResolver != nil && m.Options.TenantAccess == nil {
+			return errors.New("TenantAccess is required when TenantResolver is set")
+		}
+
 		injector := di.(zdi.Injector)
 
 		if err := initInstance(m); err != nil {
@@ -25,7 +25,7 @@ func cascadeFields(m *Schema) []string {
 		case schema.RelationManyToMany:
 			keys := rel.ForeignKey
 			if len(keys) == 0 {
-				keys = []string{idKey}
+				keys = m.PrimaryKeys()
 			}
 			fields = append(fields, keys...)
 		default:
@@ -88,6 +88,9 @@ func cascadeDeleteRelation(m *Schema, rel schema.Relation, cType schema.CascadeT
 	if len(filter) == 0 {
 		return nil
 	}
+	if err := childSchema.tenantFilter(filter); err != nil {
+		return err
+	}
 
 	switch cType {
 	case schema.CascadeTypeRestrict:
@@ -127,7 +130,7 @@ func cascadeDeletePivot(m *Schema, rel schema.Relation, cType schema.CascadeType
 
 	parentKeys := rel.ForeignKey
 	if len(parentKeys) == 0 {
-		parentKeys = []string{idKey}
+		parentKeys = m.PrimaryKeys()
 	}
 	if len(rel.PivotKeys.Foreign) == 0 {
 		return nil
@@ -159,7 +162,7 @@ func cascadeDeletePivot(m *Schema, rel schema.Relation, cType schema.CascadeType
 func deleteByFilter(m *Schema, filter ztype.Map) error {
 	if *m.define.Options.SoftDeletes {
 		data := make(ztype.Map, 1)
-		now := ztime.Time()
+		now := storageTime(ztime.Time())
 		if *m.define.Options.SoftDeleteIsTime {
 			data[DeletedAtKey] = now
 		} else {
@@ -174,7 +177,7 @@ func deleteByFilter(m *Schema, filter ztype.Map) error {
 
 func hasRows(m *Schema, filter ztype.Map) (bool, error) {
 	rows, err := m.Storage.Find(m.GetTableName(), filter, func(co *CondOptions) {
-		co.Fields = append(co.Fields[:0], idKey)
+		co.Fields = append(co.Fields[:0], m.PrimaryKeys()...)
 		co.Limit = 1
 	})
 	if err != nil {
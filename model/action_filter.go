@@ -10,15 +10,29 @@ import (
 )
 
 // getFilter 将各种类型的过滤器转换为统一的 ztype.Map 格式
-// 并自动处理软删除字段过滤
-func getFilter(m *Schema, filter QueryFilter) (filterMap ztype.Map) {
+// 并自动应用作用域（含内置软删除作用域）
+func getFilter(m *Schema, filter QueryFilter) (filterMap ztype.Map, err error) {
 	if filter == nil {
 		filterMap = ztype.Map{}
 	} else {
 		filterMap = filter.ToMap()
 	}
 
-	filterMap = cloneFilterMap(filterMap)
+	filterMap = resolveExprFilters(m, cloneFilterMap(filterMap))
+
+	names := popScopeNames(filterMap, scopeKey)
+	nested, err := popNestedScopes(m, filterMap)
+	if err != nil {
+		return nil, err
+	}
+	without := append(popScopeNames(filterMap, withoutScopeKey), nested...)
+	if err = applyScopes(m, filterMap, names, without); err != nil {
+		return nil, err
+	}
+	if err = m.blindIndexFilter(filterMap); err != nil {
+		return nil, err
+	}
+	m.timeFilter(filterMap)
 
 	// 过滤无效字段：排除不在模型定义中的字段
 	for key := range filterMap {
@@ -39,24 +53,12 @@ func getFilter(m *Schema, filter QueryFilter) (filterMap ztype.Map) {
 			}
 			continue
 		}
-		if m.fullFieldsMap != nil {
-			if _, ok := m.fullFieldsMap[fieldName]; !ok {
-				delete(filterMap, key)
-			}
-			continue
-		}
-		if len(m.fullFields) > 0 {
-			if !zarray.Contains(m.fullFields, fieldName) {
-				delete(filterMap, key)
-			}
-			continue
-		}
-		if !zarray.Contains(m.GetFields(), fieldName) {
+		if !m.hasFilterField(fieldName) {
 			delete(filterMap, key)
 		}
 	}
 
-	if *m.define.Options.SoftDeletes {
+	if *m.define.Options.SoftDeletes && !scopeExcluded(without, SoftDeleteScope) {
 		if !hasFieldInFilter(filterMap, DeletedAtKey) {
 			if *m.define.Options.SoftDeleteIsTime {
 				filterMap[DeletedAtKey] = nil
@@ -69,6 +71,18 @@ func getFilter(m *Schema, filter QueryFilter) (filterMap ztype.Map) {
 	return
 }
 
+// hasFilterField 字段是否可用于过滤条件
+func (m *Schema) hasFilterField(name string) bool {
+	if m.fullFieldsMap != nil {
+		_, ok := m.fullFieldsMap[name]
+		return ok
+	}
+	if len(m.fullFields) > 0 {
+		return zarray.Contains(m.fullFields, name)
+	}
+	return zarray.Contains(m.GetFields(), name)
+}
+
 func cloneFilterMap(src ztype.Map) ztype.Map {
 	if src == nil {
 		return ztype.Map{}
@@ -6,26 +6,33 @@ import (
 	"github.com/sohaha/zlsgo/ztime"
 	"github.com/sohaha/zlsgo/ztype"
 	"github.com/zlsgo/app_module/model/hook"
+	"github.com/zlsgo/app_module/model/schema"
 )
 
 // insertData 插入数据预处理
 // 执行字段验证、默认值填充、时间戳设置等
 func insertData(m *Schema, data ztype.Map) (ztype.Map, error) {
-	data, err := m.valuesBeforeProcess(data)
+	tenant, err := m.requireTenant()
+	if err != nil {
+		return nil, err
+	}
+	id, hasID := m.primaryKeyValue(data[idKey])
+
+	data, err = m.valuesBeforeProcess(data)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(m.GetDefineFields()) > 0 {
-		data, err = VerifiData(data, m.GetDefineFields(), activeCreate)
+		data, err = verifiData(data, m.GetDefineFields(), activeCreate, m.displayZone())
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	if *m.define.Options.Timestamps {
-		data[CreatedAtKey] = ztime.Now()
-		data[UpdatedAtKey] = ztime.Now()
+		data[CreatedAtKey] = storageNow()
+		data[UpdatedAtKey] = storageNow()
 	}
 
 	if *m.define.Options.SoftDeletes {
@@ -35,6 +42,15 @@ func insertData(m *Schema, data ztype.Map) (ztype.Map, error) {
 			data[DeletedAtKey] = 0
 		}
 	}
+
+	if m.Tenancy() == schema.TenancyColumn {
+		data[TenantIDKey] = tenant
+	}
+
+	if hasID {
+		data[idKey] = id
+	}
+
 	data, err = m.valuesCryptProcess(data)
 	if err != nil {
 		return nil, err
@@ -48,6 +64,13 @@ func Insert[D any](m *Schema, data D, fn ...func(*InsertOptions)) (lastId interf
 	if err != nil {
 		return 0, err
 	}
+
+	return withChangeTx(m, func(m *Schema) (any, error) {
+		return insert(m, dataMap, fn...)
+	})
+}
+
+func insert(m *Schema, dataMap ztype.Map, fn ...func(*InsertOptions)) (lastId interface{}, err error) {
 	dataMap, err = insertData(m, dataMap)
 	if err != nil {
 		return 0, err
@@ -62,6 +85,15 @@ func Insert[D any](m *Schema, data D, fn ...func(*InsertOptions)) (lastId interf
 	if err != nil {
 		return 0, err
 	}
+	if m.IsCompositeKey() {
+		id = m.rowKey(dataMap)
+	} else if pk, ok := dataMap[idKey]; ok && m.PrimaryKeyType() != schema.PrimaryKeyAutoInc {
+		id = pk
+	}
+
+	if err = m.recordChange(AuditInsert, id, nil, dataMap); err != nil {
+		return 0, err
+	}
 
 	if *m.define.Options.CryptID {
 		id, err = m.EnCryptID(ztype.ToString(id))
@@ -71,7 +103,7 @@ func Insert[D any](m *Schema, data D, fn ...func(*InsertOptions)) (lastId interf
 	}
 
 	// AfterInsert hook (不返回错误，避免数据不一致)
-	_ = m.hook(hook.EventAfterInsert, id, dataMap)
+	m.afterHook(hook.EventAfterInsert, id, dataMap)
 
 	return id, nil
 }
@@ -83,6 +115,12 @@ func InsertMany[D any](m *Schema, datas D, fn ...func(*InsertOptions)) (lastIds
 		return []interface{}{}, err
 	}
 
+	return withChangeTx(m, func(m *Schema) ([]interface{}, error) {
+		return insertMany(m, dataMaps, fn...)
+	})
+}
+
+func insertMany(m *Schema, dataMaps ztype.Maps, fn ...func(*InsertOptions)) (lastIds []interface{}, err error) {
 	d := make(ztype.Maps, 0, len(dataMaps))
 	for i := range dataMaps {
 		data, err := insertData(m, dataMaps[i])
@@ -102,9 +140,38 @@ func InsertMany[D any](m *Schema, datas D, fn ...func(*InsertOptions)) (lastIds
 		return []interface{}{}, err
 	}
 
-	lastIds, err = m.Storage.InsertMany(m.GetTableName(), d, fn...)
-	if err != nil {
-		return []interface{}{}, err
+	naturalKey := m.IsCompositeKey() || m.PrimaryKeyType() != schema.PrimaryKeyAutoInc
+	if m.recordsChange() && !naturalKey {
+		// 批量插入的驱动未必返回每行自增 ID，需要记录变更时逐行插入以取得准确 ID
+		lastIds = make([]interface{}, len(d))
+		for i := range d {
+			lastIds[i], err = m.Storage.Insert(m.GetTableName(), d[i], fn...)
+			if err != nil {
+				return []interface{}{}, err
+			}
+		}
+	} else {
+		lastIds, err = m.Storage.InsertMany(m.GetTableName(), d, fn...)
+		if err != nil {
+			return []interface{}{}, err
+		}
+	}
+	if naturalKey {
+		lastIds = make([]interface{}, len(d))
+		for i := range d {
+			lastIds[i] = m.rowKey(d[i])
+		}
+	}
+
+	if m.recordsChange() {
+		if len(lastIds) != len(d) {
+			return []interface{}{}, errors.New("insert ids do not match inserted rows, change records cannot be written")
+		}
+		for i := range d {
+			if err = m.recordChange(AuditInsert, lastIds[i], nil, d[i]); err != nil {
+				return []interface{}{}, err
+			}
+		}
 	}
 
 	if *m.define.Options.CryptID {
@@ -117,7 +184,7 @@ func InsertMany[D any](m *Schema, datas D, fn ...func(*InsertOptions)) (lastIds
 	}
 
 	// AfterInsert hook (批量数据，不返回错误，避免数据不一致)
-	_ = m.hook(hook.EventAfterInsert, lastIds, d)
+	m.afterHook(hook.EventAfterInsert, lastIds, d)
 
 	return lastIds, nil
 }
@@ -136,8 +203,20 @@ func Delete(m *Schema, filter QueryFilter, fn ...func(*CondOptions)) (int64, err
 
 // DeleteMany 删除多条记录（支持软删除）
 func DeleteMany(m *Schema, filter QueryFilter, fn ...func(*CondOptions)) (int64, error) {
-	f := getFilter(m, filter)
+	return withChangeTx(m, func(m *Schema) (int64, error) {
+		return deleteMany(m, filter, fn...)
+	})
+}
+
+func deleteMany(m *Schema, filter QueryFilter, fn ...func(*CondOptions)) (int64, error) {
+	f, err := getFilter(m, filter)
+	if err != nil {
+		return 0, err
+	}
 	m.DeCrypt(f)
+	if err := m.tenantFilter(f); err != nil {
+		return 0, err
+	}
 
 	// BeforeDelete hook
 	if err := m.hook(hook.EventBeforeDelete, f); err != nil {
@@ -161,12 +240,16 @@ func DeleteMany(m *Schema, filter QueryFilter, fn ...func(*CondOptions)) (int64,
 		}
 	}
 
+	auditRows, err := m.auditRows(f, fn...)
+	if err != nil {
+		return 0, err
+	}
+
 	var total int64
-	var err error
 
 	if *m.define.Options.SoftDeletes {
 		data := make(ztype.Map, 1)
-		now := ztime.Time()
+		now := storageTime(ztime.Time())
 		if *m.define.Options.SoftDeleteIsTime {
 			data[DeletedAtKey] = now
 		} else {
@@ -181,8 +264,89 @@ func DeleteMany(m *Schema, filter QueryFilter, fn ...func(*CondOptions)) (int64,
 		return 0, err
 	}
 
+	for _, row := range auditRows {
+		if err = m.recordChange(AuditDelete, row[idKey], row, nil); err != nil {
+			return 0, err
+		}
+	}
+
 	// AfterDelete hook (不返回错误，避免数据不一致)
-	_ = m.hook(hook.EventAfterDelete, f, total)
+	m.afterHook(hook.EventAfterDelete, f, total)
+
+	return total, nil
+}
+
+// Restore 恢复软删除的记录
+func Restore(m *Schema, filter QueryFilter, fn ...func(*CondOptions)) (int64, error) {
+	if !*m.define.Options.SoftDeletes {
+		return 0, ErrSoftDeleteNotSupported
+	}
+
+	return withChangeTx(m, func(m *Schema) (int64, error) {
+		return restore(m, filter, fn...)
+	})
+}
+
+func restore(m *Schema, filter QueryFilter, fn ...func(*CondOptions)) (int64, error) {
+	var explicit bool
+	if filter != nil {
+		explicit = hasFieldInFilter(filter.ToMap(), DeletedAtKey)
+	}
+
+	f, err := getFilter(m, filter)
+	if err != nil {
+		return 0, err
+	}
+	data := make(ztype.Map, 2)
+	if !explicit {
+		delete(f, DeletedAtKey)
+		if *m.define.Options.SoftDeleteIsTime {
+			f[DeletedAtKey+" IS NOT NULL"] = true
+		} else {
+			f[DeletedAtKey+" >"] = 0
+		}
+	}
+	if *m.define.Options.SoftDeleteIsTime {
+		data[DeletedAtKey] = nil
+	} else {
+		data[DeletedAtKey] = 0
+	}
+	if *m.define.Options.Timestamps {
+		data[UpdatedAtKey] = storageNow()
+	}
+
+	if ok := m.DeCrypt(f); !ok {
+		return 0, errDecryptionFailed(errors.New("data decryption failed"))
+	}
+	if err := m.tenantFilter(f); err != nil {
+		return 0, err
+	}
+
+	// BeforeRestore hook
+	if err := m.hook(hook.EventBeforeRestore, f); err != nil {
+		return 0, err
+	}
+
+	auditRows, err := m.auditRows(f, fn...)
+	if err != nil {
+		return 0, err
+	}
+
+	total, err := m.Storage.Update(m.GetTableName(), data, f, fn...)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, row := range auditRows {
+		before := ztype.Map{DeletedAtKey: row[DeletedAtKey]}
+		after := ztype.Map{DeletedAtKey: data[DeletedAtKey]}
+		if err = m.recordChange(AuditRestore, row[idKey], before, after); err != nil {
+			return 0, err
+		}
+	}
+
+	// AfterRestore hook (不返回错误，避免数据不一致)
+	m.afterHook(hook.EventAfterRestore, f, total)
 
 	return total, nil
 }
@@ -215,44 +379,72 @@ func UpdateMany[D any](
 	if err != nil {
 		return 0, err
 	}
+
+	return withChangeTx(m, func(m *Schema) (int64, error) {
+		return updateMany(m, filter, dataMap, fn...)
+	})
+}
+
+// updateData 更新数据预处理
+// 过滤只读字段、执行字段验证、更新时间戳与加密处理
+func updateData(m *Schema, dataMap ztype.Map) (ztype.Map, error) {
 	dataMap = filterDate(dataMap, m.readOnlyKeys)
-	dataMap, err = m.valuesBeforeProcess(dataMap)
+	dataMap, err := m.valuesBeforeProcess(dataMap)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	if len(m.GetDefineFields()) > 0 {
-		dataMap, err = VerifiData(dataMap, m.GetDefineFields(), activeUpdate)
+		dataMap, err = verifiData(dataMap, m.GetDefineFields(), activeUpdate, m.displayZone())
 		if err != nil {
-			return 0, errDataValidation(err)
+			return nil, errDataValidation(err)
 		}
 	}
 	if *m.define.Options.Timestamps {
-		dataMap[UpdatedAtKey] = ztime.Now()
+		dataMap[UpdatedAtKey] = storageNow()
 	}
-	dataMap, err = m.valuesCryptProcess(dataMap)
+	return m.valuesCryptProcess(dataMap)
+}
+
+func updateMany(m *Schema, filter QueryFilter, dataMap ztype.Map, fn ...func(*CondOptions)) (total int64, err error) {
+	dataMap, err = updateData(m, dataMap)
 	if err != nil {
 		return 0, err
 	}
 
-	f := getFilter(m, filter)
+	f, err := getFilter(m, filter)
+	if err != nil {
+		return 0, err
+	}
 
 	if ok := m.DeCrypt(f); !ok {
 		return 0, errDecryptionFailed(errors.New("data decryption failed"))
 	}
+	if err = m.tenantFilter(f); err != nil {
+		return 0, err
+	}
 
 	// BeforeUpdate hook
 	if err = m.hook(hook.EventBeforeUpdate, f, dataMap); err != nil {
 		return 0, err
 	}
 
+	auditRows, err := m.auditRows(f, fn...)
+	if err != nil {
+		return 0, err
+	}
+
 	total, err = m.Storage.Update(m.GetTableName(), dataMap, f, fn...)
 	if err != nil {
 		return 0, err
 	}
 
+	if err = m.auditUpdated(auditRows, dataMap); err != nil {
+		return 0, err
+	}
+
 	// AfterUpdate hook (不返回错误，避免数据不一致)
-	_ = m.hook(hook.EventAfterUpdate, f, dataMap, total)
+	m.afterHook(hook.EventAfterUpdate, f, dataMap, total)
 
 	return total, nil
 }
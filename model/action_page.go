@@ -5,6 +5,7 @@ import (
 	"github.com/sohaha/zlsgo/zjson"
 	"github.com/sohaha/zlsgo/zstring"
 	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/hook"
 )
 
 // PageData 分页数据结构
@@ -41,7 +42,11 @@ func Pages[R any](
 	filter QueryFilter,
 	fn ...func(*CondOptions),
 ) (*RepositoryPageData[R], error) {
-	pageData, err := pages(m, page, pagesize, getFilter(m, filter), true, fn...)
+	f, err := getFilter(m, filter)
+	if err != nil {
+		return nil, err
+	}
+	pageData, err := pages(m, page, pagesize, f, true, fn...)
 	if err != nil {
 		return nil, err
 	}
@@ -70,6 +75,12 @@ func pages(
 	if cryptId {
 		_ = m.DeCrypt(filter)
 	}
+	if err = m.hook(hook.EventBeforeFind, filter); err != nil {
+		return
+	}
+	if err = m.tenantFilter(filter); err != nil {
+		return
+	}
 
 	var (
 		childRelationson nestedRelationMap
@@ -109,8 +120,10 @@ func pages(
 	}
 
 	afterProcess := m.afterProcess
-	if len(afterProcess) == 0 {
-		return data, nil
+	if len(afterProcess) == 0 && len(m.timeFields) == 0 {
+		err = m.hook(hook.EventAfterFind, &data.Items)
+		m.applyFieldPolicies(data.Items)
+		return data, err
 	}
 
 	for i := range data.Items {
@@ -128,11 +141,19 @@ func pages(
 			}
 			(*row)[k] = val
 		}
+		if err = m.formatTimeFields(*row); err != nil {
+			return data, err
+		}
 
 		if cryptId && *m.define.Options.CryptID {
 			_ = m.EnCrypt(row)
 		}
 	}
 
+	if err = m.hook(hook.EventAfterFind, &data.Items); err != nil {
+		return data, err
+	}
+	m.applyFieldPolicies(data.Items)
+
 	return data, nil
 }
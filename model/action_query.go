@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/hook"
 )
 
 // findMaps 内部查询函数（返回 ztype.Maps）
@@ -12,6 +13,12 @@ func findMaps(m *Store, filter ztype.Map, cryptId bool, fn ...func(*CondOptions)
 	if cryptId {
 		_ = m.schema.DeCrypt(filter)
 	}
+	if err = m.schema.hook(hook.EventBeforeFind, filter); err != nil {
+		return
+	}
+	if err = m.schema.tenantFilter(filter); err != nil {
+		return
+	}
 
 	var (
 		childRelationson nestedRelationMap
@@ -42,7 +49,7 @@ func findMaps(m *Store, filter ztype.Map, cryptId bool, fn ...func(*CondOptions)
 		return
 	}
 
-	if len(m.schema.afterProcess) > 0 {
+	if len(m.schema.afterProcess) > 0 || len(m.schema.timeFields) > 0 {
 		for i := range resp {
 			row := &resp[i]
 			for k, v := range m.schema.afterProcess {
@@ -58,12 +65,20 @@ func findMaps(m *Store, filter ztype.Map, cryptId bool, fn ...func(*CondOptions)
 				}
 				(*row)[k] = val
 			}
+			if err = m.schema.formatTimeFields(*row); err != nil {
+				return
+			}
 			if cryptId && *m.schema.define.Options.CryptID {
 				m.schema.EnCrypt(row)
 			}
 		}
 	}
 
+	if err = m.schema.hook(hook.EventAfterFind, &resp); err != nil {
+		return nil, err
+	}
+	m.schema.applyFieldPolicies(resp)
+
 	return resp, nil
 }
 
@@ -79,7 +94,11 @@ func find[R any](m *Store, filter ztype.Map, cryptId bool, fn ...func(*CondOptio
 
 // Find 查询多条记录（公开 API）
 func Find[R any](m *Store, filter QueryFilter, fn ...func(*CondOptions)) ([]R, error) {
-	return find[R](m, getFilter(m.schema, filter), true, fn...)
+	f, err := getFilter(m.schema, filter)
+	if err != nil {
+		return nil, err
+	}
+	return find[R](m, f, true, fn...)
 }
 
 // FindMaps 查询多条记录（返回 ztype.Maps）
@@ -90,7 +109,11 @@ func FindMaps(m *Store, filter QueryFilter, fn ...func(*CondOptions)) (ztype.Map
 // FindOne 查询单条记录
 func FindOne[R any](m *Store, filter QueryFilter, fn ...func(*CondOptions)) (R, error) {
 	var zero R
-	rows, err := find[R](m, getFilter(m.schema, filter), true, func(so *CondOptions) {
+	f, err := getFilter(m.schema, filter)
+	if err != nil {
+		return zero, err
+	}
+	rows, err := find[R](m, f, true, func(so *CondOptions) {
 		for i := range fn {
 			if fn[i] == nil {
 				continue
@@ -114,7 +137,11 @@ func findColsRaw(
 	filter QueryFilter,
 	fn ...func(*CondOptions),
 ) (ztype.SliceType, error) {
-	rows, err := findMaps(m, getFilter(m.schema, filter), true, func(so *CondOptions) {
+	f, err := getFilter(m.schema, filter)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := findMaps(m, f, true, func(so *CondOptions) {
 		for i := range fn {
 			fn[i](so)
 		}
@@ -168,6 +195,8 @@ func Count(m *Store, filter QueryFilter, fn ...func(*CondOptions)) (uint64, erro
 			fn[i](co)
 		}
 		co.OrderBy = nil
+		// 聚合查询不支持行锁
+		co.Lock = LockNone
 	})
 	if err != nil {
 		return 0, err
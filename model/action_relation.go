@@ -160,7 +160,11 @@ func buildManyToManyFields(relatedSchema *Schema, d schema.Relation, node *neste
 
 	relatedKeys := d.SchemaKey
 	if len(relatedKeys) == 0 {
-		relatedKeys = []string{idKey}
+		if relatedSchema != nil {
+			relatedKeys = relatedSchema.PrimaryKeys()
+		} else {
+			relatedKeys = []string{idKey}
+		}
 	}
 	for _, k := range relatedKeys {
 		addKey(k)
@@ -305,7 +309,7 @@ func relationson(
 		if d.Type == schema.RelationManyToMany {
 			fk := d.ForeignKey
 			if len(fk) == 0 {
-				fk = []string{idKey}
+				fk = m.PrimaryKeys()
 			}
 			for i := range fk {
 				if _, ok := originFieldSet[fk[i]]; ok {
@@ -556,7 +560,11 @@ func handlerRelationson(
 			continue
 		}
 
-		items, err := findMaps(childSchema.Model(), getFilter(childSchema, Filter(filter)), false, func(co *CondOptions) {
+		childFilter, err := getFilter(childSchema, Filter(filter))
+		if err != nil {
+			return nil, err
+		}
+		items, err := findMaps(childSchema.Model(), childFilter, false, func(co *CondOptions) {
 			co.Fields = queryFields
 		})
 		if err != nil {
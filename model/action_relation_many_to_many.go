@@ -146,7 +146,11 @@ func (mtr *ManyToManyRelation) Load(rows ztype.Maps, key string, fields []string
 	}
 
 	tmpKeys := make([]string, 0, len(relatedKeys))
-	items, err := findMaps(mtr.related.Model(), getFilter(mtr.related, Filter(relatedFilter)), false, func(co *CondOptions) {
+	f, err := getFilter(mtr.related, Filter(relatedFilter))
+	if err != nil {
+		return nil, err
+	}
+	items, err := findMaps(mtr.related.Model(), f, false, func(co *CondOptions) {
 		co.Fields = fields
 		if len(co.Fields) == 0 {
 			co.Fields = allFields
@@ -239,13 +243,16 @@ func (mtr *ManyToManyRelation) parentKeyFields() []string {
 	if len(mtr.relation.ForeignKey) > 0 {
 		return mtr.relation.ForeignKey
 	}
-	return []string{idKey}
+	return mtr.schema.PrimaryKeys()
 }
 
 func (mtr *ManyToManyRelation) relatedKeyFields() []string {
 	if len(mtr.relation.SchemaKey) > 0 {
 		return mtr.relation.SchemaKey
 	}
+	if mtr.related != nil {
+		return mtr.related.PrimaryKeys()
+	}
 	return []string{idKey}
 }
 
@@ -274,8 +281,11 @@ func NewPivotManager(schema *Schema) *PivotManager {
 }
 
 func (pm *PivotManager) GetPivotTableName(relation *schema.Relation) (string, error) {
+	if _, err := pm.schema.requireTenant(); err != nil {
+		return "", err
+	}
 	if relation.PivotTable != "" {
-		return pm.schema.tablePrefix + relation.PivotTable, nil
+		return pm.schema.getTablePrefix() + relation.PivotTable, nil
 	}
 	relatedSchema, ok := pm.schema.getSchema(relation.Schema)
 	if !ok {
@@ -286,7 +296,7 @@ func (pm *PivotManager) GetPivotTableName(relation *schema.Relation) (string, er
 	if strings.Compare(tables[0], tables[1]) > 0 {
 		tables[0], tables[1] = tables[1], tables[0]
 	}
-	return pm.schema.tablePrefix + strings.Join(tables, "_"), nil
+	return pm.schema.getTablePrefix() + strings.Join(tables, "_"), nil
 }
 
 func (pm *PivotManager) CreatePivotTable(relation *schema.Relation) error {
@@ -307,11 +317,11 @@ func (pm *PivotManager) CreatePivotTable(relation *schema.Relation) error {
 
 	parentKeys := relation.ForeignKey
 	if len(parentKeys) == 0 {
-		parentKeys = []string{idKey}
+		parentKeys = pm.schema.PrimaryKeys()
 	}
 	relatedKeys := relation.SchemaKey
 	if len(relatedKeys) == 0 {
-		relatedKeys = []string{idKey}
+		relatedKeys = relatedPrimaryKeys(pm.schema, relation.Schema)
 	}
 
 	create := builder.NewTable(pivotTable).Create().IfNotExists()
@@ -337,6 +347,7 @@ func (pm *PivotManager) CreatePivotTable(relation *schema.Relation) error {
 	if err != nil {
 		return err
 	}
+	sql = withPrimaryKeyConstraint(sql, zarray.Unique(append(append([]string{}, foreign...), related...)))
 	_, err = db.Exec(sql, values...)
 	return err
 }
@@ -373,11 +384,11 @@ func (pm *PivotManager) SyncPivotSchema(relation *schema.Relation) error {
 
 	parentKeys := relation.ForeignKey
 	if len(parentKeys) == 0 {
-		parentKeys = []string{idKey}
+		parentKeys = pm.schema.PrimaryKeys()
 	}
 	relatedKeys := relation.SchemaKey
 	if len(relatedKeys) == 0 {
-		relatedKeys = []string{idKey}
+		relatedKeys = relatedPrimaryKeys(pm.schema, relation.Schema)
 	}
 
 	for _, col := range required {
@@ -0,0 +1,435 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sohaha/zlsgo/zjson"
+	"github.com/sohaha/zlsgo/ztime"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/hook"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+// AuditSchemaName 变更历史模型名称
+const AuditSchemaName = "__audit_histories"
+
+// auditMask 加密字段在历史记录中的掩码
+const auditMask = "******"
+
+// AuditOperation 变更操作类型
+type AuditOperation string
+
+const (
+	AuditInsert  AuditOperation = "insert"
+	AuditUpdate  AuditOperation = "update"
+	AuditDelete  AuditOperation = "delete"
+	AuditRevert  AuditOperation = "revert"
+	AuditRestore AuditOperation = "restore"
+)
+
+// AuditRecord 变更历史记录
+type AuditRecord struct {
+	Time      time.Time      `json:"time"`
+	Before    ztype.Map      `json:"before,omitempty"`
+	After     ztype.Map      `json:"after,omitempty"`
+	Schema    string         `json:"schema"`
+	RecordID  string         `json:"record_id"`
+	Operation AuditOperation `json:"operation"`
+	Actor     string         `json:"actor,omitempty"`
+	Tenant    string         `json:"tenant,omitempty"`
+	Version   int            `json:"version"`
+}
+
+// ErrAuditDisabled 模型未开启变更历史
+var ErrAuditDisabled = errors.New("audit is not enabled")
+
+type (
+	actorCtxKey     struct{}
+	auditTimeCtxKey struct{}
+)
+
+// WithActor 在上下文中设置操作人
+func WithActor(ctx context.Context, actor string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, actorCtxKey{}, actor)
+}
+
+// ActorFromContext 从上下文中获取操作人
+func ActorFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	actor, _ := ctx.Value(actorCtxKey{}).(string)
+	return actor
+}
+
+// WithAuditTime 在上下文中指定变更时间
+func WithAuditTime(ctx context.Context, t time.Time) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, auditTimeCtxKey{}, t)
+}
+
+func auditSchemaDefine() schema.Schema {
+	f := false
+	return schema.Schema{
+		Name: AuditSchemaName,
+		Table: schema.Table{
+			Name:    "audit_histories",
+			Comment: "变更历史",
+		},
+		Options: schema.Options{
+			Audit:       &f,
+			SoftDeletes: &f,
+			Timestamps:  &f,
+			CryptID:     &f,
+			Tenancy:     schema.TenancyNone,
+		},
+		Fields: map[string]schema.Field{
+			"schema":      {Type: schema.String, Size: 120, Label: "模型", Index: "record"},
+			"record_id":   {Type: schema.String, Size: 64, Label: "记录 ID", Index: "record"},
+			"version":     {Type: schema.Int, Label: "版本"},
+			"operation":   {Type: schema.String, Size: 20, Label: "操作"},
+			"before":      {Type: schema.Text, Label: "变更前", Nullable: true},
+			"after":       {Type: schema.Text, Label: "变更后", Nullable: true},
+			"actor":       {Type: schema.String, Size: 120, Label: "操作人", Nullable: true},
+			"tenant":      {Type: schema.String, Size: 64, Label: "租户", Nullable: true},
+			"recorded_at": {Type: schema.Time, Label: "变更时间"},
+		},
+	}
+}
+
+// IsAudit 是否开启变更历史
+func (m *Schema) IsAudit() bool {
+	return m.define.Options.Audit != nil && *m.define.Options.Audit
+}
+
+func (m *Schema) auditSchema() (*Schema, error) {
+	return m.relatedSchema(AuditSchemaName, ErrAuditDisabled)
+}
+
+// recordsChange 是否需要记录数据变更
+func (m *Schema) recordsChange() bool {
+	return m.IsAudit() || m.IsOutbox()
+}
+
+// withChangeTx 记录变更历史或发件箱事件的模型在事务外执行变更时自动开启事务，保证数据与变更记录一同提交或回滚
+func withChangeTx[R any](m *Schema, run func(m *Schema) (R, error)) (R, error) {
+	if !m.recordsChange() || inTransaction(m.Storage) {
+		return run(m)
+	}
+
+	var resp R
+	err := transaction(m, func(tx *Schema) (err error) {
+		resp, err = run(tx)
+		return
+	})
+	if err != nil {
+		var zero R
+		return zero, err
+	}
+	return resp, nil
+}
+
+// recordChange 写入变更历史与发件箱事件
+func (m *Schema) recordChange(op AuditOperation, id any, before, after ztype.Map) error {
+	if err := m.audit(op, id, before, after); err != nil {
+		return err
+	}
+	return m.outbox(op, id, before, after)
+}
+
+// auditRows 获取变更前的完整记录
+func (m *Schema) auditRows(filter ztype.Map, fn ...func(*CondOptions)) (ztype.Maps, error) {
+	if !m.recordsChange() {
+		return nil, nil
+	}
+	return m.Storage.Find(m.GetTableName(), filter, func(so *CondOptions) {
+		for i := range fn {
+			if fn[i] != nil {
+				fn[i](so)
+			}
+		}
+		so.Fields = allFields
+		so.Join = nil
+		so.Relations = nil
+	})
+}
+
+// auditMaskValues 屏蔽加密字段
+func (m *Schema) auditMaskValues(data ztype.Map) ztype.Map {
+	if data == nil {
+		return nil
+	}
+	out := make(ztype.Map, len(data))
+	for k, v := range data {
+		if _, ok := m.cryptKeys[k]; ok {
+			out[k] = auditMask
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// audit 写入变更历史
+func (m *Schema) audit(op AuditOperation, id any, before, after ztype.Map) error {
+	if !m.IsAudit() {
+		return nil
+	}
+
+	hs, err := m.auditSchema()
+	if err != nil {
+		return err
+	}
+
+	ctx := m.Context()
+	rec := &AuditRecord{
+		Schema:    m.GetAlias(),
+		RecordID:  ztype.ToString(id),
+		Operation: op,
+		Before:    m.auditMaskValues(before),
+		After:     m.auditMaskValues(after),
+		Actor:     ActorFromContext(ctx),
+		Time:      ztime.Time(),
+	}
+	rec.Tenant, _ = m.Tenant()
+	if t, ok := ctx.Value(auditTimeCtxKey{}).(time.Time); ok && !t.IsZero() {
+		rec.Time = t
+	}
+
+	if err = m.hook(hook.EventBeforeAudit, rec); err != nil {
+		return err
+	}
+
+	last, err := FindCols[int](hs.Model(), "version", Filter{
+		"schema":    rec.Schema,
+		"record_id": rec.RecordID,
+		"tenant":    rec.Tenant,
+	}, func(co *CondOptions) {
+		co.OrderBy = []OrderByItem{{Field: "version", Direction: "DESC"}}
+		co.Limit = 1
+	})
+	if err != nil {
+		return err
+	}
+	rec.Version = 1
+	if len(last) > 0 {
+		rec.Version = last[0] + 1
+	}
+
+	data := ztype.Map{
+		"schema":      rec.Schema,
+		"record_id":   rec.RecordID,
+		"version":     rec.Version,
+		"operation":   string(rec.Operation),
+		"actor":       rec.Actor,
+		"tenant":      rec.Tenant,
+		"recorded_at": ztime.FormatTime(rec.Time),
+	}
+	for k, v := range map[string]ztype.Map{"before": rec.Before, "after": rec.After} {
+		if v == nil {
+			continue
+		}
+		b, err := zjson.Marshal(v)
+		if err != nil {
+			return err
+		}
+		data[k] = string(b)
+	}
+
+	_, err = Insert(hs, data)
+	return err
+}
+
+// auditUpdated 记录更新前后发生变化的字段
+func (m *Schema) auditUpdated(rows ztype.Maps, data ztype.Map) error {
+	for _, row := range rows {
+		before, after := make(ztype.Map, len(data)), make(ztype.Map, len(data))
+		for k, v := range data {
+			if k == UpdatedAtKey {
+				continue
+			}
+			old := row[k]
+			if ztype.ToString(old) == ztype.ToString(v) {
+				continue
+			}
+			before[k], after[k] = old, v
+		}
+		if len(after) == 0 {
+			continue
+		}
+		if err := m.recordChange(AuditUpdate, row[idKey], before, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// History 获取记录的变更历史（按版本升序）
+func History(m *Schema, id any) ([]AuditRecord, error) {
+	if !m.IsAudit() {
+		return nil, ErrAuditDisabled
+	}
+	hs, err := m.auditSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	rid, err := m.DeCryptID(ztype.ToString(id))
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := m.requireTenant()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := FindMaps(hs.Model(), Filter{
+		"schema":    m.GetAlias(),
+		"record_id": rid,
+		"tenant":    tenant,
+	}, func(co *CondOptions) {
+		co.OrderBy = []OrderByItem{{Field: "version", Direction: "ASC"}}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]AuditRecord, 0, len(rows))
+	for _, row := range rows {
+		rec := AuditRecord{
+			Schema:    row.Get("schema").String(),
+			RecordID:  row.Get("record_id").String(),
+			Version:   row.Get("version").Int(),
+			Operation: AuditOperation(row.Get("operation").String()),
+			Actor:     row.Get("actor").String(),
+			Tenant:    row.Get("tenant").String(),
+		}
+		if t, ok := row["recorded_at"].(time.Time); ok {
+			rec.Time = t
+		} else if t, err := ztime.Parse(row.Get("recorded_at").String()); err == nil {
+			rec.Time = t
+		}
+		if s := row.Get("before").String(); s != "" {
+			rec.Before = zjson.Parse(s).Map()
+		}
+		if s := row.Get("after").String(); s != "" {
+			rec.After = zjson.Parse(s).Map()
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// Revert 将记录恢复到指定版本之后的状态，加密字段不会被恢复
+func Revert(m *Schema, id any, version int) error {
+	_, err := withChangeTx(m, func(m *Schema) (struct{}, error) {
+		return struct{}{}, revert(m, id, version)
+	})
+	return err
+}
+
+func revert(m *Schema, id any, version int) error {
+	records, err := History(m, id)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return ErrNoRecord
+	}
+
+	values := make(ztype.Map)
+	found := false
+	for i := len(records) - 1; i >= 0; i-- {
+		rec := records[i]
+		if rec.Version == version {
+			found = true
+			break
+		}
+		if rec.Version < version {
+			break
+		}
+		switch rec.Operation {
+		case AuditInsert:
+			return errors.New("cannot revert before the record was created")
+		default:
+			for k, v := range rec.Before {
+				values[k] = v
+			}
+		}
+	}
+	if !found {
+		return errors.New("history version not found")
+	}
+
+	rid := records[0].RecordID
+	for k, v := range values {
+		if v == auditMask || k == idKey || k == TenantIDKey {
+			delete(values, k)
+		}
+	}
+	if *m.define.Options.SoftDeletes {
+		if *m.define.Options.SoftDeleteIsTime {
+			values[DeletedAtKey] = nil
+		} else {
+			values[DeletedAtKey] = 0
+		}
+	}
+
+	filter := ztype.Map{idKey: rid}
+	if err = m.tenantFilter(filter); err != nil {
+		return err
+	}
+
+	rows, err := m.Storage.Find(m.GetTableName(), filter, func(so *CondOptions) {
+		so.Fields = allFields
+		so.Limit = 1
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		data := make(ztype.Map, len(values)+2)
+		for k, v := range values {
+			data[k] = v
+		}
+		data[idKey] = rid
+		if tenant, ok := m.Tenant(); ok && m.Tenancy() == schema.TenancyColumn {
+			data[TenantIDKey] = tenant
+		}
+		if _, err = m.Storage.Insert(m.GetTableName(), data); err != nil {
+			return err
+		}
+		return m.recordChange(AuditRevert, rid, nil, values)
+	}
+
+	before := make(ztype.Map, len(values))
+	for k := range values {
+		before[k] = rows[0][k]
+	}
+	if *m.define.Options.Timestamps {
+		values[UpdatedAtKey] = storageNow()
+	}
+	if _, err = m.Storage.Update(m.GetTableName(), values, filter); err != nil {
+		return err
+	}
+	return m.recordChange(AuditRevert, rid, before, values)
+}
+
+// History 获取记录的变更历史
+func (o *Store) History(id any) ([]AuditRecord, error) {
+	return History(o.schema, id)
+}
+
+// Revert 将记录恢复到指定版本
+func (o *Store) Revert(id any, version int) error {
+	return Revert(o.schema, id, version)
+}
@@ -0,0 +1,164 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/hook"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func newAuditTestSchema(t *testing.T, softDeletes bool) *Schema {
+	b := true
+	s := schema.Schema{
+		Name:  "audit_users",
+		Table: schema.Table{Name: "audit_users"},
+		Options: schema.Options{
+			Audit:       &b,
+			SoftDeletes: &softDeletes,
+		},
+		Fields: map[string]schema.Field{
+			"name":     {Type: "string", Label: "Name"},
+			"age":      {Type: "int", Label: "Age", Default: "0"},
+			"password": {Type: "string", Label: "Password", Options: schema.FieldOption{Crypt: "md5"}},
+		},
+	}
+	_, schemas := newTestSchemas(t, s)
+	return schemas.MustGet("audit_users")
+}
+
+func TestAuditHistory(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	m := newAuditTestSchema(t, false)
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	store := m.Model().WithContext(WithAuditTime(WithActor(context.Background(), "admin"), at))
+
+	id, err := store.Insert(ztype.Map{"name": "a", "age": 1, "password": "secret"})
+	tt.NoError(err)
+
+	_, err = store.UpdateByID(id, ztype.Map{"age": 2, "password": "changed"})
+	tt.NoError(err)
+	_, err = store.UpdateByID(id, ztype.Map{"age": 2})
+	tt.NoError(err)
+	_, err = store.UpdateByID(id, ztype.Map{"name": "b"})
+	tt.NoError(err)
+
+	records, err := store.History(id)
+	tt.NoError(err)
+	tt.Equal(3, len(records))
+
+	tt.Equal(AuditInsert, records[0].Operation)
+	tt.Equal(1, records[0].Version)
+	tt.Equal("admin", records[0].Actor)
+	tt.Equal(at.Unix(), records[0].Time.Unix())
+	tt.Equal(auditMask, records[0].After.Get("password").String())
+
+	tt.Equal(AuditUpdate, records[1].Operation)
+	tt.Equal(1, records[1].Before.Get("age").Int())
+	tt.Equal(2, records[1].After.Get("age").Int())
+	tt.Equal(auditMask, records[1].After.Get("password").String())
+	_, ok := records[1].After["name"]
+	tt.Equal(false, ok)
+
+	tt.NoError(store.Revert(id, 2))
+	row, err := store.FindOneByID(id)
+	tt.NoError(err)
+	tt.Equal("a", row.Get("name").String())
+	tt.Equal(2, row.Get("age").Int())
+
+	records, err = store.History(id)
+	tt.NoError(err)
+	tt.Equal(AuditRevert, records[len(records)-1].Operation)
+}
+
+func TestAuditDeleteRevert(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	m := newAuditTestSchema(t, false)
+
+	var actors []string
+	m.define.Options.Hook = func(event hook.Event, data ...any) error {
+		if event == hook.EventBeforeAudit {
+			rec := data[0].(*AuditRecord)
+			rec.Actor = "hook"
+			actors = append(actors, rec.Actor)
+		}
+		return nil
+	}
+
+	store := m.Model()
+	id, err := store.Insert(ztype.Map{"name": "a", "age": 3})
+	tt.NoError(err)
+	_, err = store.DeleteByID(id)
+	tt.NoError(err)
+	tt.Equal(2, len(actors))
+
+	records, err := store.History(id)
+	tt.NoError(err)
+	tt.Equal(2, len(records))
+	tt.Equal(AuditDelete, records[1].Operation)
+	tt.Equal("a", records[1].Before.Get("name").String())
+	tt.Equal("hook", records[1].Actor)
+
+	tt.NoError(store.Revert(id, 1))
+	row, err := store.FindOneByID(id)
+	tt.NoError(err)
+	tt.Equal("a", row.Get("name").String())
+	tt.Equal(3, row.Get("age").Int())
+}
+
+func TestAuditTx(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	m := newAuditTestSchema(t, false)
+	store := m.Model()
+
+	id, err := store.Insert(ztype.Map{"name": "a", "age": 1})
+	tt.NoError(err)
+
+	// 变更历史写入失败时数据变更一同回滚
+	hs, err := m.relatedSchema(AuditSchemaName, ErrAuditDisabled)
+	tt.NoError(err)
+	_, err = m.Storage.(*SQL).GetDB().Exec("DROP TABLE " + hs.GetTableName())
+	tt.NoError(err)
+
+	_, err = store.Insert(ztype.Map{"name": "b", "age": 2})
+	tt.Equal(true, err != nil)
+	_, err = store.UpdateByID(id, ztype.Map{"age": 3})
+	tt.Equal(true, err != nil)
+
+	count, err := store.Count(Filter{})
+	tt.NoError(err)
+	tt.Equal(uint64(1), count)
+	row, err := store.FindOneByID(id)
+	tt.NoError(err)
+	tt.Equal(1, row.Get("age").Int())
+}
+
+func TestAuditDisabled(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	_, m := newTestDB(t, "audit_disabled")
+
+	_, err := m.Model().History(1)
+	tt.Equal(ErrAuditDisabled, err)
+}
+
+func TestAuditInsertMany(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	m := newAuditTestSchema(t, false)
+	store := m.Model()
+
+	ids, err := store.InsertMany(ztype.Maps{{"name": "a", "age": 1}, {"name": "b", "age": 2}, {"name": "c", "age": 3}})
+	tt.NoError(err)
+	tt.Equal(3, len(ids))
+
+	// 批量插入的每一行都应有对应的变更记录
+	for i, id := range ids {
+		records, err := store.History(id)
+		tt.NoError(err)
+		tt.Equal(1, len(records))
+		tt.Equal(AuditInsert, records[0].Operation)
+		tt.Equal(i+1, records[0].After.Get("age").Int())
+	}
+}
@@ -1,5 +1,13 @@
 package model
 
+import (
+	"fmt"
+
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/hook"
+	mSchema "github.com/zlsgo/app_module/model/schema"
+)
+
 // DefaultBatchSize 默认批次大小
 const DefaultBatchSize = 1000
 
@@ -110,6 +118,10 @@ func (r *Repository[T, F, C, U]) BatchInsertTx(data []C, opts ...BatchOption) ([
 
 // BatchUpdate 批量更新数据
 func (r *Repository[T, F, C, U]) BatchUpdate(filter F, data U, opts ...BatchOption) (int64, error) {
+	if r.store.schema.IsCompositeKey() {
+		return 0, ErrInvalidKey
+	}
+
 	options := &BatchOptions{Size: DefaultBatchSize}
 	for _, opt := range opts {
 		opt(options)
@@ -175,3 +187,256 @@ func (r *Repository[T, F, C, U]) BatchDelete(filter F, opts ...BatchOption) (int
 
 	return total, nil
 }
+
+// BatchUpdateRows 按 keyField 批量更新每行不同的值，keyField 为空时使用主键
+// SQL 存储每批生成一条 UPDATE ... CASE 语句，仍执行字段校验、时间戳与更新钩子
+func (r *Repository[T, F, C, U]) BatchUpdateRows(rows []U, keyField string, opts ...BatchOption) (int64, error) {
+	dataMaps, err := dataToMaps(rows)
+	if err != nil {
+		return 0, err
+	}
+	if len(dataMaps) == 0 {
+		return 0, nil
+	}
+
+	options := &BatchOptions{Size: DefaultBatchSize}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	var total int64
+	err = transaction(r.store.schema, func(m *Schema) (err error) {
+		total, err = batchUpdateRows(m, dataMaps, keyField, options.Size)
+		return
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// BatchDeleteByIDs 按主键批量删除，分批控制 IN 列表长度以免超出数据库参数上限
+func (r *Repository[T, F, C, U]) BatchDeleteByIDs(ids []any, opts ...BatchOption) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if r.store.schema.IsCompositeKey() {
+		return 0, ErrInvalidKey
+	}
+
+	options := &BatchOptions{Size: DefaultBatchSize}
+	for _, opt := range opts {
+		opt(options)
+	}
+	size := batchChunkSize(r.store.schema, options.Size, 1)
+
+	var total int64
+	for i := 0; i < len(ids); i += size {
+		end := i + size
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		count, err := r.store.DeleteMany(In(idKey, ids[i:end]))
+		if err != nil {
+			return total, err
+		}
+		total += count
+	}
+
+	return total, nil
+}
+
+// isUniqueKey 字段是否为单字段主键或独立的唯一字段，只有这类字段能唯一定位一行
+func (m *Schema) isUniqueKey(field string) bool {
+	if keys := m.PrimaryKeys(); len(keys) == 1 && keys[0] == field {
+		return true
+	}
+	// 与迁移生成唯一索引的分组规则一致，true 以字段名作为索引名
+	uniqueGroup := func(name string, f mSchema.Field) string {
+		group := ztype.ToString(f.Unique)
+		if group == "true" {
+			return name
+		}
+		return group
+	}
+	f, ok := m.define.Fields[field]
+	if !ok {
+		return false
+	}
+	group := uniqueGroup(field, f)
+	if group == "" {
+		return false
+	}
+	// 同一唯一索引包含多个字段时，单个字段并不唯一
+	for name, other := range m.define.Fields {
+		if name != field && uniqueGroup(name, other) == group {
+			return false
+		}
+	}
+	return true
+}
+
+// batchChunkSize 结合数据库参数上限计算每批行数，perRow 为每行占用的参数数量
+func batchChunkSize(m *Schema, size, perRow int) int {
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	// 预留部分参数给作用域、租户等附加条件
+	if limit := maxBindParams(m.Storage) - 32; limit > 0 && perRow > 0 && size*perRow > limit {
+		size = limit / perRow
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+func batchUpdateRows(m *Schema, rows ztype.Maps, keyField string, size int) (int64, error) {
+	if keyField == "" {
+		if m.IsCompositeKey() {
+			return 0, ErrInvalidKey
+		}
+		keyField = idKey
+	}
+	if err := m.checkColumn(keyField); err != nil {
+		return 0, err
+	}
+	if !m.isUniqueKey(keyField) {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidKeyField, keyField)
+	}
+
+	type pending struct {
+		key    any
+		filter ztype.Map
+		data   ztype.Map
+	}
+	items := make([]pending, 0, len(rows))
+	columns := make(map[string]struct{})
+	for i := range rows {
+		key, ok := rows[i][keyField]
+		if !ok || key == nil {
+			return 0, fmt.Errorf("%w: row %d missing %s", ErrInvalidData, i, keyField)
+		}
+		if keyField == idKey {
+			id, err := m.DeCryptID(ztype.ToString(key))
+			if err != nil {
+				return 0, errDecryptionFailed(err)
+			}
+			if id != ztype.ToString(key) {
+				key = id
+			}
+		}
+
+		data := make(ztype.Map, len(rows[i]))
+		for k, v := range rows[i] {
+			if k != keyField {
+				data[k] = v
+			}
+		}
+		data, err := updateData(m, data)
+		if err != nil {
+			return 0, err
+		}
+		for k := range data {
+			columns[k] = struct{}{}
+		}
+		items = append(items, pending{key: key, filter: ztype.Map{keyField: key}, data: data})
+	}
+
+	var total int64
+	size = batchChunkSize(m, size, len(columns)*2+1)
+	for i := 0; i < len(items); i += size {
+		end := i + size
+		if end > len(items) {
+			end = len(items)
+		}
+		chunk := items[i:end]
+
+		keys := make([]any, len(chunk))
+		for j := range chunk {
+			keys[j] = chunk[j].key
+		}
+		f, err := getFilter(m, In(keyField, keys))
+		if err != nil {
+			return total, err
+		}
+		if err := m.tenantFilter(f); err != nil {
+			return total, err
+		}
+
+		// 先确认作用域、软删除与租户条件下实际存在的行，再按键更新
+		fields := []string{keyField}
+		if m.recordsChange() {
+			fields = allFields
+		}
+		existing, err := m.Storage.Find(m.GetTableName(), f, func(so *CondOptions) {
+			so.Fields = append(so.Fields[:0], fields...)
+		})
+		if err != nil {
+			return total, err
+		}
+		matched := make(map[string]ztype.Map, len(existing))
+		for _, row := range existing {
+			matched[ztype.ToString(row[keyField])] = row
+		}
+
+		updates := make(ztype.Maps, 0, len(chunk))
+		applied := make([]pending, 0, len(chunk))
+		for j := range chunk {
+			if _, ok := matched[ztype.ToString(chunk[j].key)]; !ok {
+				continue
+			}
+			// 钩子只对实际存在的行触发
+			if err := m.hook(hook.EventBeforeUpdate, chunk[j].filter, chunk[j].data); err != nil {
+				return total, err
+			}
+			if len(chunk[j].data) == 0 {
+				continue
+			}
+			row := make(ztype.Map, len(chunk[j].data)+1)
+			for k, v := range chunk[j].data {
+				row[k] = v
+			}
+			row[keyField] = chunk[j].key
+			updates = append(updates, row)
+			applied = append(applied, chunk[j])
+		}
+		if len(updates) == 0 {
+			continue
+		}
+
+		if b, ok := m.Storage.(BulkUpdater); ok {
+			err = b.BulkUpdate(m.GetTableName(), keyField, updates, f)
+		} else {
+			for j := range applied {
+				var rf ztype.Map
+				if rf, err = getFilter(m, Filter{keyField: applied[j].key}); err != nil {
+					break
+				}
+				if err = m.tenantFilter(rf); err != nil {
+					break
+				}
+				if _, err = m.Storage.Update(m.GetTableName(), applied[j].data, rf); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return total, err
+		}
+
+		for j := range applied {
+			if m.recordsChange() {
+				row := matched[ztype.ToString(applied[j].key)]
+				if err = m.auditUpdated(ztype.Maps{row}, applied[j].data); err != nil {
+					return total, err
+				}
+			}
+			m.afterHook(hook.EventAfterUpdate, applied[j].filter, applied[j].data, int64(1))
+		}
+		total += int64(len(applied))
+	}
+
+	return total, nil
+}
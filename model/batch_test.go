@@ -0,0 +1,133 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func TestBatchUpdateRows(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	timestamps := true
+	items := schema.Schema{
+		Name:    "batch_prices",
+		Table:   schema.Table{Name: "batch_prices"},
+		Options: schema.Options{Timestamps: &timestamps},
+		Fields: map[string]schema.Field{
+			"sku":   {Type: schema.String, Size: 20, Unique: true},
+			"price": {Type: schema.Int},
+			"stock": {Type: schema.Int, Nullable: true},
+			"note":  {Type: schema.String, Size: 5, Nullable: true},
+		},
+	}
+	_, schemas := newTestSchemas(t, items)
+	m := schemas.MustGet("batch_prices")
+	repo := m.Model().Repository()
+
+	for i := 0; i < 5; i++ {
+		_, err := repo.Insert(ztype.Map{"sku": fmt.Sprintf("s%d", i), "price": 10, "stock": 1})
+		tt.NoError(err)
+	}
+
+	var befores, updates int
+	m.Hooks().OnBeforeUpdate(func(ctx context.Context, filter ztype.Map, data *ztype.Map) error {
+		befores++
+		return nil
+	})
+	m.Hooks().OnAfterUpdate(func(ctx context.Context, filter, data ztype.Map, total int64) error {
+		updates++
+		return nil
+	})
+
+	total, err := repo.BatchUpdateRows([]ztype.Map{
+		{"sku": "s0", "price": 100},
+		{"sku": "s1", "price": 110, "stock": 9},
+		{"sku": "s3", "price": 130},
+		{"sku": "missing", "price": 1},
+	}, "sku", BatchSize(2))
+	tt.NoError(err)
+	tt.Equal(int64(3), total)
+	tt.Equal(3, befores)
+	tt.Equal(3, updates)
+
+	rows, err := repo.Query().OrderBy("sku").Find()
+	tt.NoError(err)
+	prices := make([]int, 0, len(rows))
+	for _, row := range rows {
+		prices = append(prices, row.Get("price").Int())
+	}
+	tt.Equal([]int{100, 110, 10, 130, 10}, prices)
+	tt.Equal(9, rows[1].Get("stock").Int())
+	tt.Equal(1, rows[0].Get("stock").Int())
+
+	total, err = repo.BatchUpdateRows([]ztype.Map{{IDKey(): rows[4].Get(IDKey()).Value(), "price": 150}}, "")
+	tt.NoError(err)
+	tt.Equal(int64(1), total)
+
+	_, err = repo.BatchUpdateRows([]ztype.Map{{"sku": "s2", "note": "too long"}}, "sku")
+	tt.Equal(true, err != nil)
+	_, err = repo.BatchUpdateRows([]ztype.Map{{"price": 1}}, "sku")
+	tt.Equal(true, err != nil)
+	_, err = repo.BatchUpdateRows([]ztype.Map{{"sku": "s2", "price": 1}}, "nope")
+	tt.Equal(true, err != nil)
+
+	// 非唯一字段不能作为批量更新的键
+	_, err = repo.BatchUpdateRows([]ztype.Map{{"price": 10, "stock": 2}}, "price")
+	tt.Equal(true, errors.Is(err, ErrInvalidKeyField))
+
+	row, err := repo.Query().Where("sku", "s4").First()
+	tt.NoError(err)
+	tt.Equal(150, row.Get("price").Int())
+	row, err = repo.Query().Where("sku", "s2").First()
+	tt.NoError(err)
+	tt.Equal(10, row.Get("price").Int())
+
+	tt.Equal(1, row.Get("stock").Int())
+
+	// 单条语句更新时同样受过滤条件约束，不会波及条件外的行
+	_, err = repo.Insert(ztype.Map{"sku": "s5", "price": 10, "stock": 5})
+	tt.NoError(err)
+	err = m.Storage.(BulkUpdater).BulkUpdate(m.GetTableName(), "price", ztype.Maps{{"price": 10, "stock": 99}}, ztype.Map{"sku": "s2"})
+	tt.NoError(err)
+	rows, err = repo.Query().Where("price", 10).OrderBy("sku").Find()
+	tt.NoError(err)
+	tt.Equal(2, len(rows))
+	tt.Equal(99, rows[0].Get("stock").Int())
+	tt.Equal(5, rows[1].Get("stock").Int())
+}
+
+func TestBatchDeleteByIDs(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	items := schema.Schema{
+		Name:  "batch_delete_ids",
+		Table: schema.Table{Name: "batch_delete_ids"},
+		Fields: map[string]schema.Field{
+			"name": {Type: schema.String, Size: 20},
+		},
+	}
+	_, schemas := newTestSchemas(t, items)
+	repo := schemas.MustGet("batch_delete_ids").Model().Repository()
+
+	data := make([]ztype.Map, 2500)
+	for i := range data {
+		data[i] = ztype.Map{"name": fmt.Sprintf("n%d", i)}
+	}
+	ids, err := repo.BatchInsert(data, BatchSize(200))
+	tt.NoError(err)
+	tt.Equal(2500, len(ids))
+
+	deleted, err := repo.BatchDeleteByIDs(ids[:2400], BatchSize(5000))
+	tt.NoError(err)
+	tt.Equal(int64(2400), deleted)
+
+	count, err := repo.Query().Count()
+	tt.NoError(err)
+	tt.Equal(uint64(100), count)
+}
@@ -0,0 +1,66 @@
+// modelgen 根据模型目录生成类型化的结构体、过滤条件与仓储构造函数
+//
+//	go run github.com/zlsgo/app_module/model/cmd/modelgen -dir ./schema -out ./models/models_gen.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zlsgo/app_module/model"
+)
+
+func main() {
+	var (
+		dir     = flag.String("dir", "./schema", "模型目录")
+		out     = flag.String("out", "", "输出文件，为空时输出到标准输出")
+		pkg     = flag.String("package", "", "生成文件的包名，默认使用输出目录名")
+		env     = flag.String("env", "", "模型文件的环境覆盖")
+		names   = flag.String("names", "", "仅生成指定模型，多个以逗号分隔")
+		stamps  = flag.Bool("timestamps", false, "与模块 SchemaOptions.Timestamps 保持一致")
+		softDel = flag.Bool("soft-deletes", false, "与模块 SchemaOptions.SoftDeletes 保持一致")
+		cryptID = flag.Bool("crypt-id", false, "与模块 SchemaOptions.CryptID 保持一致")
+	)
+	flag.Parse()
+
+	opt := model.GoGenOptions{
+		Package: *pkg,
+		Env:     *env,
+		SchemaOptions: model.SchemaOptions{
+			Timestamps:  *stamps,
+			SoftDeletes: *softDel,
+			CryptID:     *cryptID,
+		},
+	}
+	if opt.Package == "" && *out != "" {
+		if abs, err := filepath.Abs(filepath.Dir(*out)); err == nil {
+			opt.Package = strings.ReplaceAll(filepath.Base(abs), "-", "_")
+		}
+	}
+	for _, name := range strings.Split(*names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			opt.Names = append(opt.Names, name)
+		}
+	}
+
+	src, err := model.GenerateGoFromDir(*dir, opt)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "modelgen:", err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		_, _ = os.Stdout.Write(src)
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(*out), 0o755); err == nil {
+		err = os.WriteFile(*out, src, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "modelgen:", err)
+		os.Exit(1)
+	}
+}
@@ -98,6 +98,8 @@ func (m *Schema) GetCryptProcess(cryptName string) (fn CryptProcess, err error)
 	switch strings.ToLower(cryptName) {
 	default:
 		return nil, errors.New("crypt name not found")
+	case FieldCryptAES:
+		fn = m.fieldEncryptProcess()
 	case "md5":
 		fn = func(s string) (string, error) {
 			return zstring.Md5(s), nil
@@ -0,0 +1,392 @@
+package model
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/sohaha/zlsgo/ztype"
+	mSchema "github.com/zlsgo/app_module/model/schema"
+	"github.com/zlsgo/zdb/schema"
+)
+
+const (
+	// FieldCryptAES 可逆的 AES-GCM 字段加密
+	FieldCryptAES = "aes"
+	// BlindIndexSuffix 盲索引列后缀
+	BlindIndexSuffix = "_bidx"
+
+	fieldCipherPrefix = "aes:"
+)
+
+var (
+	// ErrFieldKeyNotSet 未配置字段加密密钥
+	ErrFieldKeyNotSet = errors.New("field encryption key not configured")
+	// ErrFieldKeyNotFound 密文对应的密钥不存在
+	ErrFieldKeyNotFound = errors.New("field encryption key not found")
+	// ErrBlindIndexKeyNotSet 未配置盲索引密钥
+	ErrBlindIndexKeyNotSet = errors.New("blind index key not configured")
+	// ErrInvalidCiphertext 无效的密文
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+)
+
+// FieldKeyring 字段加密密钥环，密文中保存密钥 ID 以便轮换密钥
+type FieldKeyring struct {
+	keys     map[string]cipher.AEAD
+	primary  string
+	blindKey []byte
+	mu       sync.RWMutex
+}
+
+// NewFieldKeyring 创建字段加密密钥环
+func NewFieldKeyring() *FieldKeyring {
+	return &FieldKeyring{keys: make(map[string]cipher.AEAD)}
+}
+
+// AddKey 添加密钥，第一个添加的密钥默认作为加密密钥
+func (k *FieldKeyring) AddKey(id string, key []byte) error {
+	if id == "" || strings.Contains(id, ":") {
+		return errors.New("invalid key id")
+	}
+	keyLen := len(key)
+	if keyLen != 16 && keyLen != 24 && keyLen != 32 {
+		return ErrInvalidKeyLength
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[id] = gcm
+	if k.primary == "" {
+		k.primary = id
+	}
+	return nil
+}
+
+// SetPrimary 设置用于加密的密钥，旧密钥仍可用于解密
+func (k *FieldKeyring) SetPrimary(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return ErrFieldKeyNotFound
+	}
+	k.primary = id
+	return nil
+}
+
+// Primary 当前加密密钥 ID
+func (k *FieldKeyring) Primary() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.primary
+}
+
+// SetBlindIndexKey 设置盲索引密钥，轮换加密密钥时无需变更
+func (k *FieldKeyring) SetBlindIndexKey(key []byte) {
+	k.mu.Lock()
+	k.blindKey = append([]byte(nil), key...)
+	k.mu.Unlock()
+}
+
+// Encrypt 使用当前密钥加密
+func (k *FieldKeyring) Encrypt(plaintext string) (string, error) {
+	k.mu.RLock()
+	id := k.primary
+	gcm := k.keys[id]
+	k.mu.RUnlock()
+	if gcm == nil {
+		return "", ErrFieldKeyNotSet
+	}
+
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), []byte(id))
+	return fieldCipherPrefix + id + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 根据密文中的密钥 ID 解密
+func (k *FieldKeyring) Decrypt(ciphertext string) (string, error) {
+	id, payload, ok := splitFieldCipher(ciphertext)
+	if !ok {
+		return "", ErrInvalidCiphertext
+	}
+
+	k.mu.RLock()
+	gcm := k.keys[id]
+	k.mu.RUnlock()
+	if gcm == nil {
+		return "", ErrFieldKeyNotFound
+	}
+
+	raw, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil || len(raw) < gcm.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+	plain, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], []byte(id))
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	return string(plain), nil
+}
+
+// BlindIndex 计算字段值的盲索引
+func (k *FieldKeyring) BlindIndex(field, value string) (string, error) {
+	k.mu.RLock()
+	key := k.blindKey
+	k.mu.RUnlock()
+	if len(key) == 0 {
+		return "", ErrBlindIndexKeyNotSet
+	}
+
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(field))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(value))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func splitFieldCipher(s string) (id, payload string, ok bool) {
+	if !strings.HasPrefix(s, fieldCipherPrefix) {
+		return "", "", false
+	}
+	id, payload, ok = strings.Cut(s[len(fieldCipherPrefix):], ":")
+	return id, payload, ok && id != ""
+}
+
+// SetKeyring 设置模型集合的字段加密密钥环
+func (ss *Schemas) SetKeyring(k *FieldKeyring) {
+	ss.mu.Lock()
+	ss.keyring = k
+	ss.mu.Unlock()
+}
+
+// Keyring 获取模型集合的字段加密密钥环
+func (ss *Schemas) Keyring() *FieldKeyring {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.keyring
+}
+
+func (m *Schema) fieldKeyring() *FieldKeyring {
+	if m.getKeyring == nil {
+		return nil
+	}
+	return m.getKeyring()
+}
+
+// isFieldCrypt 是否为可逆加密字段
+func isFieldCrypt(f *mSchema.Field) bool {
+	return strings.EqualFold(f.Options.Crypt, FieldCryptAES)
+}
+
+// blindIndexField 生成盲索引列定义
+func blindIndexField(name string, f *mSchema.Field) (string, mSchema.Field, bool) {
+	if !f.Options.BlindIndex || !isFieldCrypt(f) {
+		return "", mSchema.Field{}, false
+	}
+	return name + BlindIndexSuffix, mSchema.Field{
+		Type:     schema.String,
+		Size:     64,
+		Label:    f.Label + "索引",
+		Nullable: true,
+		Index:    true,
+		Options: mSchema.FieldOption{
+			ReadOnly: true,
+		},
+	}, true
+}
+
+func (m *Schema) fieldEncryptProcess() CryptProcess {
+	return func(s string) (string, error) {
+		if s == "" {
+			return s, nil
+		}
+		k := m.fieldKeyring()
+		if k == nil {
+			return "", ErrFieldKeyNotSet
+		}
+		return k.Encrypt(s)
+	}
+}
+
+func (m *Schema) fieldDecryptProcess() afterProcess {
+	return func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			if b, isBytes := v.([]byte); isBytes {
+				s = string(b)
+			} else {
+				return v, nil
+			}
+		}
+		if _, _, ok = splitFieldCipher(s); !ok {
+			return s, nil
+		}
+		k := m.fieldKeyring()
+		if k == nil {
+			return nil, ErrFieldKeyNotSet
+		}
+		return k.Decrypt(s)
+	}
+}
+
+// valuesBlindIndex 根据明文计算盲索引列
+func (m *Schema) valuesBlindIndex(data ztype.Map) error {
+	for name, column := range m.blindIndexes {
+		v, ok := data[name]
+		if !ok {
+			continue
+		}
+		s := ztype.ToString(v)
+		if s == "" {
+			data[column] = nil
+			continue
+		}
+		k := m.fieldKeyring()
+		if k == nil {
+			return ErrFieldKeyNotSet
+		}
+		idx, err := k.BlindIndex(name, s)
+		if err != nil {
+			return err
+		}
+		data[column] = idx
+	}
+	return nil
+}
+
+// blindIndexFilter 将加密字段的等值条件改写为盲索引列条件，无法计算盲索引时返回错误，避免以明文查询
+func (m *Schema) blindIndexFilter(filter ztype.Map) error {
+	if len(m.blindIndexes) == 0 || len(filter) == 0 {
+		return nil
+	}
+	var k *FieldKeyring
+
+	for key, v := range filter {
+		name, op := strings.TrimSpace(key), ""
+		if i := strings.IndexAny(name, " \t"); i > 0 {
+			name, op = name[:i], strings.ToUpper(strings.TrimSpace(name[i+1:]))
+		}
+		column, ok := m.blindIndexes[name]
+		if !ok {
+			continue
+		}
+		if k == nil {
+			if k = m.fieldKeyring(); k == nil {
+				return ErrFieldKeyNotSet
+			}
+		}
+
+		var (
+			nv  any
+			err error
+		)
+		switch op {
+		case "", "=", "!=", "<>":
+			switch v.(type) {
+			case []interface{}, []string:
+				nv, err = blindIndexValues(k, name, ztype.ToSlice(v).Value())
+			default:
+				nv, err = k.BlindIndex(name, ztype.ToString(v))
+			}
+		case "IN", "NOT IN", "NOTIN":
+			nv, err = blindIndexValues(k, name, ztype.ToSlice(v).Value())
+		default:
+			modelLogger.Warnf("encrypted field %s does not support %s condition\n", name, op)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		delete(filter, key)
+		if op == "" {
+			filter[column] = nv
+		} else {
+			filter[column+" "+op] = nv
+		}
+	}
+	return nil
+}
+
+func blindIndexValues(k *FieldKeyring, name string, values []interface{}) ([]interface{}, error) {
+	out := make([]interface{}, 0, len(values))
+	for i := range values {
+		idx, err := k.BlindIndex(name, ztype.ToString(values[i]))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, idx)
+	}
+	return out, nil
+}
+
+// ReEncryptFields 使用当前密钥重新加密模型中由旧密钥加密的字段，返回更新的记录数
+func ReEncryptFields(m *Schema) (int64, error) {
+	k := m.fieldKeyring()
+	if k == nil {
+		return 0, ErrFieldKeyNotSet
+	}
+
+	fields := make([]string, 0, len(m.define.Fields))
+	for name := range m.define.Fields {
+		f := m.define.Fields[name]
+		if isFieldCrypt(&f) {
+			fields = append(fields, name)
+		}
+	}
+	if len(fields) == 0 {
+		return 0, nil
+	}
+
+	rows, err := m.Storage.Find(m.GetTableName(), ztype.Map{}, func(so *CondOptions) {
+		so.Fields = append([]string{idKey}, fields...)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	primary := k.Primary()
+	var total int64
+	for _, row := range rows {
+		data := make(ztype.Map, len(fields))
+		for _, name := range fields {
+			id, _, ok := splitFieldCipher(row.Get(name).String())
+			if !ok || id == primary {
+				continue
+			}
+			plain, err := k.Decrypt(row.Get(name).String())
+			if err != nil {
+				return total, err
+			}
+			if data[name], err = k.Encrypt(plain); err != nil {
+				return total, err
+			}
+		}
+		if len(data) == 0 {
+			continue
+		}
+		if _, err = m.Storage.Update(m.GetTableName(), data, ztype.Map{idKey: row.Get(idKey).Value()}); err != nil {
+			return total, err
+		}
+		total++
+	}
+	return total, nil
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func TestFieldKeyring(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	k := NewFieldKeyring()
+	tt.Equal(ErrInvalidKeyLength, k.AddKey("k1", []byte("short")))
+	tt.NoError(k.AddKey("k1", []byte("0123456789abcdef")))
+	tt.NoError(k.AddKey("k2", []byte("fedcba9876543210")))
+	tt.Equal("k1", k.Primary())
+
+	c1, err := k.Encrypt("13800000000")
+	tt.NoError(err)
+	tt.Equal(true, strings.HasPrefix(c1, "aes:k1:"))
+
+	tt.NoError(k.SetPrimary("k2"))
+	c2, err := k.Encrypt("13800000000")
+	tt.NoError(err)
+	tt.Equal(true, strings.HasPrefix(c2, "aes:k2:"))
+
+	for _, c := range []string{c1, c2} {
+		plain, err := k.Decrypt(c)
+		tt.NoError(err)
+		tt.Equal("13800000000", plain)
+	}
+
+	_, err = k.Decrypt("aes:k3:xxxx")
+	tt.Equal(ErrFieldKeyNotFound, err)
+	_, err = k.Decrypt(c1[:len(c1)-2])
+	tt.Equal(ErrInvalidCiphertext, err)
+
+	_, err = k.BlindIndex("phone", "1")
+	tt.Equal(ErrBlindIndexKeyNotSet, err)
+	k.SetBlindIndexKey([]byte("blind"))
+	a, _ := k.BlindIndex("phone", "1")
+	b, _ := k.BlindIndex("mobile", "1")
+	tt.Equal(64, len(a))
+	tt.Equal(false, a == b)
+}
+
+func TestFieldCryptAES(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	s := schema.Schema{
+		Name:  "crypt_members",
+		Table: schema.Table{Name: "crypt_members"},
+		Fields: map[string]schema.Field{
+			"name":  {Type: "string", Label: "Name"},
+			"phone": {Type: "string", Label: "Phone", Size: 20, Options: schema.FieldOption{Crypt: FieldCryptAES, BlindIndex: true}},
+			"token": {Type: "string", Label: "Token", Nullable: true, Options: schema.FieldOption{Crypt: FieldCryptAES}},
+		},
+	}
+	db, schemas := newTestSchemas(t, s)
+	m := schemas.MustGet("crypt_members")
+	tt.Equal(true, zarray.Contains(m.GetFields(), "phone"+BlindIndexSuffix))
+
+	_, err := m.Model().Insert(ztype.Map{"name": "a", "phone": "13800000001"})
+	tt.Equal(ErrFieldKeyNotSet, err)
+	// 无法计算盲索引时不能以明文查询
+	_, err = m.Model().Find(Filter{"phone": "13800000001"})
+	tt.Equal(ErrFieldKeyNotSet, err)
+
+	k := NewFieldKeyring()
+	tt.NoError(k.AddKey("k1", []byte("0123456789abcdef")))
+	schemas.SetKeyring(k)
+	_, err = m.Model().Find(Filter{"phone IN": []string{"13800000001"}})
+	tt.Equal(ErrBlindIndexKeyNotSet, err)
+
+	k.SetBlindIndexKey([]byte("blind-index-key"))
+
+	store := m.Model()
+	id, err := store.Insert(ztype.Map{"name": "a", "phone": "13800000001", "token": "secret"})
+	tt.NoError(err)
+	_, err = store.Insert(ztype.Map{"name": "b", "phone": "13800000002"})
+	tt.NoError(err)
+
+	raw, err := db.QueryToMaps("SELECT phone, token FROM crypt_members WHERE id = ?", id)
+	tt.NoError(err)
+	tt.Equal(true, strings.HasPrefix(raw[0].Get("phone").String(), "aes:k1:"))
+	tt.Equal(true, strings.HasPrefix(raw[0].Get("token").String(), "aes:k1:"))
+
+	row, err := store.FindOneByID(id)
+	tt.NoError(err)
+	tt.Equal("13800000001", row.Get("phone").String())
+	tt.Equal("secret", row.Get("token").String())
+
+	rows, err := store.Find(Filter{"phone": "13800000002"})
+	tt.NoError(err)
+	tt.Equal(1, len(rows))
+	tt.Equal("b", rows[0].Get("name").String())
+
+	rows, err = store.Find(Filter{"phone": []string{"13800000001", "13800000002"}})
+	tt.NoError(err)
+	tt.Equal(2, len(rows))
+
+	for _, op := range []string{" !=", " <>"} {
+		rows, err = store.Find(Filter{"phone" + op: "13800000002"})
+		tt.NoError(err)
+		tt.Equal(1, len(rows))
+		tt.Equal("a", rows[0].Get("name").String())
+	}
+	rows, err = store.Find(Filter{"phone NOT IN": []string{"13800000001"}})
+	tt.NoError(err)
+	tt.Equal(1, len(rows))
+	tt.Equal("b", rows[0].Get("name").String())
+
+	_, err = store.Update(Filter{"phone": "13800000002"}, ztype.Map{"phone": "13800000003"})
+	tt.NoError(err)
+	count, err := store.Count(Filter{"phone": "13800000003"})
+	tt.NoError(err)
+	tt.Equal(uint64(1), count)
+
+	tt.NoError(k.AddKey("k2", []byte("fedcba9876543210")))
+	tt.NoError(k.SetPrimary("k2"))
+	total, err := ReEncryptFields(m)
+	tt.NoError(err)
+	tt.Equal(int64(2), total)
+
+	raw, err = db.QueryToMaps("SELECT phone FROM crypt_members WHERE id = ?", id)
+	tt.NoError(err)
+	tt.Equal(true, strings.HasPrefix(raw[0].Get("phone").String(), "aes:k2:"))
+
+	row, err = store.FindOne(Filter{"phone": "13800000001"})
+	tt.NoError(err)
+	tt.Equal("a", row.Get("name").String())
+}
@@ -27,6 +27,15 @@ func dataToMap(value any) (ztype.Map, error) {
 			return ztype.Map{}, nil
 		}
 		return ztype.Map(v), nil
+	case QueryFilter:
+		// 自定义 ToMap 的写入参数（如生成代码中的 Create/Update 结构体）
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil, ErrInvalidData
+		}
+		if m := v.ToMap(); m != nil {
+			return m, nil
+		}
+		return ztype.Map{}, nil
 	}
 
 	rv := reflect.ValueOf(value)
@@ -14,6 +14,18 @@ type convertUser struct {
 	Age  int    `json:"age"`
 }
 
+type convertPatch struct {
+	Name *string
+}
+
+func (p convertPatch) ToMap() ztype.Map {
+	data := ztype.Map{}
+	if p.Name != nil {
+		data["name"] = *p.Name
+	}
+	return data
+}
+
 func TestDataToMap(t *testing.T) {
 	tt := zlsgo.NewTest(t)
 
@@ -35,6 +47,16 @@ func TestDataToMap(t *testing.T) {
 
 	_, err = dataToMap(123)
 	tt.Equal(true, err == ErrInvalidData)
+
+	name := "n4"
+	m, err = dataToMap(convertPatch{Name: &name})
+	tt.NoError(err)
+	tt.Equal(ztype.Map{"name": "n4"}, m)
+	m, err = dataToMap(convertPatch{})
+	tt.NoError(err)
+	tt.Equal(0, len(m))
+	_, err = dataToMap((*convertPatch)(nil))
+	tt.Equal(true, err == ErrInvalidData)
 }
 
 func TestDataToMaps(t *testing.T) {
@@ -62,15 +84,18 @@ func TestGetFilter(t *testing.T) {
 	tt := zlsgo.NewTest(t)
 	_, m := newTestDB(t, "filter_convert")
 
-	f := getFilter(m, ID(123))
+	f, err := getFilter(m, ID(123))
+	tt.NoError(err)
 	tt.Equal(123, f[idKey])
 
-	f = getFilter(m, Q(TestUserFilter{Status: 1}))
+	f, err = getFilter(m, Q(TestUserFilter{Status: 1}))
+	tt.NoError(err)
 	tt.Equal(int8(1), f["status"])
 	_, hasName := f["name"]
 	tt.Equal(false, hasName)
 
-	f = getFilter(m, Filter{"status": 1, "unknown": 2})
+	f, err = getFilter(m, Filter{"status": 1, "unknown": 2})
+	tt.NoError(err)
 	tt.Equal(1, f["status"])
 	_, ok := f["unknown"]
 	tt.Equal(false, ok)
@@ -98,7 +123,8 @@ func TestGetFilterSoftDeletes(t *testing.T) {
 	soft.Options.SoftDeleteIsTime = &softTime
 	_, schemas := newTestSchemas(t, soft)
 	m := schemas.MustGet("soft_users")
-	f := getFilter(m, Filter{})
+	f, err := getFilter(m, Filter{})
+	tt.NoError(err)
 	_, ok := f[DeletedAtKey]
 	tt.Equal(true, ok)
 }
@@ -125,7 +151,8 @@ func TestGetFilterSoftDeletesOverride(t *testing.T) {
 	_, schemas := newTestSchemas(t, soft)
 	m := schemas.MustGet("soft_users_override")
 
-	f := getFilter(m, Filter{DeletedAtKey + " IS NOT NULL": true})
+	f, err := getFilter(m, Filter{DeletedAtKey + " IS NOT NULL": true})
+	tt.NoError(err)
 	_, hasDefault := f[DeletedAtKey]
 	tt.Equal(false, hasDefault)
 	_, hasExplicit := f[DeletedAtKey+" IS NOT NULL"]
@@ -154,7 +181,8 @@ func TestGetFilterSoftDeletesQualifiedDoesNotOverride(t *testing.T) {
 	_, schemas := newTestSchemas(t, soft)
 	m := schemas.MustGet("soft_users_qualified")
 
-	f := getFilter(m, Filter{"other.deleted_at IS NOT NULL": true})
+	f, err := getFilter(m, Filter{"other.deleted_at IS NOT NULL": true})
+	tt.NoError(err)
 	_, hasDefault := f[DeletedAtKey]
 	tt.Equal(true, hasDefault)
 	_, hasQualified := f["other.deleted_at IS NOT NULL"]
@@ -0,0 +1,272 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/sohaha/zlsgo/ztype"
+	mSchema "github.com/zlsgo/app_module/model/schema"
+	"github.com/zlsgo/zdb/schema"
+)
+
+const (
+	defaultDecimalPrecision = 10
+	defaultDecimalScale     = 2
+	// maxDecimalPrecision 各数据库均支持的最大总位数（MySQL 为 65）
+	maxDecimalPrecision = 65
+)
+
+// ErrInvalidDecimal 无效的定点数
+var ErrInvalidDecimal = errors.New("invalid decimal")
+
+// Decimal 以字符串保存的定点数，运算过程不经过浮点数
+type Decimal string
+
+// ParseDecimal 解析十进制字符串，如 "-12.30"
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	intPart, fracPart, hasDot := strings.Cut(s, ".")
+	if (intPart == "" && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) || (hasDot && fracPart == "" && intPart == "") {
+		return "", ErrInvalidDecimal
+	}
+
+	intPart = strings.TrimLeft(intPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	out := intPart
+	if fracPart != "" {
+		out += "." + fracPart
+	}
+	if neg && strings.Trim(out, "0.") != "" {
+		out = "-" + out
+	}
+	return Decimal(out), nil
+}
+
+// NewDecimal 将字符串、整数或浮点数转换为定点数
+func NewDecimal(v any) (Decimal, error) {
+	switch n := v.(type) {
+	case Decimal:
+		return ParseDecimal(string(n))
+	case string:
+		return ParseDecimal(n)
+	case []byte:
+		return ParseDecimal(string(n))
+	case float32:
+		return ParseDecimal(strconv.FormatFloat(float64(n), 'f', -1, 32))
+	case float64:
+		return ParseDecimal(strconv.FormatFloat(n, 'f', -1, 64))
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return ParseDecimal(ztype.ToString(n))
+	case ztype.Type:
+		return NewDecimal(n.Value())
+	case fmt.Stringer:
+		return ParseDecimal(n.String())
+	default:
+		return "", ErrInvalidDecimal
+	}
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String 返回十进制字符串
+func (d Decimal) String() string {
+	if d == "" {
+		return "0"
+	}
+	return string(d)
+}
+
+// Scale 小数位数
+func (d Decimal) Scale() int {
+	_, frac, _ := strings.Cut(string(d), ".")
+	return len(frac)
+}
+
+// intDigits 整数部分有效位数
+func (d Decimal) intDigits() int {
+	s := strings.TrimPrefix(string(d), "-")
+	intPart, _, _ := strings.Cut(s, ".")
+	intPart = strings.TrimLeft(intPart, "0")
+	return len(intPart)
+}
+
+func (d Decimal) rat() *big.Rat {
+	r, ok := new(big.Rat).SetString(d.String())
+	if !ok {
+		return new(big.Rat)
+	}
+	return r
+}
+
+func ratDecimal(r *big.Rat, scale int) Decimal {
+	s := r.FloatString(scale)
+	if strings.Trim(s, "-0.") == "" {
+		s = strings.TrimPrefix(s, "-")
+	}
+	return Decimal(s)
+}
+
+// Add 加法
+func (d Decimal) Add(o Decimal) Decimal {
+	return ratDecimal(new(big.Rat).Add(d.rat(), o.rat()), max(d.Scale(), o.Scale()))
+}
+
+// Sub 减法
+func (d Decimal) Sub(o Decimal) Decimal {
+	return ratDecimal(new(big.Rat).Sub(d.rat(), o.rat()), max(d.Scale(), o.Scale()))
+}
+
+// Mul 乘法
+func (d Decimal) Mul(o Decimal) Decimal {
+	return ratDecimal(new(big.Rat).Mul(d.rat(), o.rat()), d.Scale()+o.Scale())
+}
+
+// Round 按小数位数四舍五入
+func (d Decimal) Round(scale int) Decimal {
+	return ratDecimal(d.rat(), scale)
+}
+
+// Cmp 比较大小，返回 -1、0、1
+func (d Decimal) Cmp(o Decimal) int {
+	return d.rat().Cmp(o.rat())
+}
+
+// IsZero 是否为零
+func (d Decimal) IsZero() bool {
+	return d.rat().Sign() == 0
+}
+
+// Float64 转换为浮点数，可能丢失精度
+func (d Decimal) Float64() float64 {
+	f, _ := d.rat().Float64()
+	return f
+}
+
+// Value 实现 driver.Valuer 接口，以字符串写入数据库
+func (d Decimal) Value() (driver.Value, error) {
+	if d == "" {
+		return nil, nil
+	}
+	return string(d), nil
+}
+
+// decimalSpec 返回字段的总位数与小数位数
+func decimalSpec(f *mSchema.Field) (precision, scale int) {
+	if f.Size == 0 && f.Scale == 0 {
+		return defaultDecimalPrecision, defaultDecimalScale
+	}
+	precision, scale = int(f.Size), int(f.Scale)
+	if precision == 0 {
+		precision = defaultDecimalPrecision
+	}
+	return
+}
+
+// checkDecimalSpec 校验定点数的总位数与小数位数，避免生成无效的列类型
+func checkDecimalSpec(name string, f *mSchema.Field) error {
+	if f.Size > maxDecimalPrecision || f.Scale > maxDecimalPrecision {
+		return fmt.Errorf("%w: %s precision must be between 1 and %d", ErrInvalidDecimal, name, maxDecimalPrecision)
+	}
+	precision, scale := decimalSpec(f)
+	if scale > precision {
+		return fmt.Errorf("%w: %s scale %d exceeds precision %d", ErrInvalidDecimal, name, scale, precision)
+	}
+	return nil
+}
+
+// decimalColumnType 定点数列类型
+func decimalColumnType(f *mSchema.Field) schema.DataType {
+	precision, scale := decimalSpec(f)
+	return schema.DataType(fmt.Sprintf("decimal(%d,%d)", precision, scale))
+}
+
+// verifyDecimal 校验定点数的范围与小数位数，返回按小数位数补齐后的值
+func verifyDecimal(label string, f *mSchema.Field, v any) (Decimal, error) {
+	d, err := NewDecimal(v)
+	if err != nil {
+		return "", errors.New(label + "必须是数字")
+	}
+	precision, scale := decimalSpec(f)
+	if d.Scale() > scale {
+		return "", fmt.Errorf("%s小数位数不能超过 %d 位", label, scale)
+	}
+	if d.intDigits() > precision-scale {
+		return "", errors.New(label + "超出取值范围")
+	}
+	return d.Round(scale), nil
+}
+
+// decimalUnmarshalProcess 读取时将数据库值转换为 Decimal
+func decimalUnmarshalProcess(scale int) func(v interface{}) (interface{}, error) {
+	return func(v interface{}) (interface{}, error) {
+		if v == nil {
+			return nil, nil
+		}
+		d, err := NewDecimal(v)
+		if err != nil {
+			return nil, err
+		}
+		return d.Round(scale), nil
+	}
+}
+
+// Sum 对定点数字段求和，结果按字段小数位数返回，field 只能是模型的数值字段
+func Sum(m *Store, field string, filter QueryFilter, fn ...func(*CondOptions)) (Decimal, error) {
+	if err := m.schema.checkColumn(field); err != nil {
+		return "", err
+	}
+	f, ok := m.schema.getField(field)
+	if !ok || !isNumericField(f) {
+		return "", fmt.Errorf("%w: %s is not a numeric field", ErrUnknownField, field)
+	}
+
+	values, err := findColsRaw(m, "SUM("+field+") AS sum", filter, fn...)
+	if err != nil || values.Len() == 0 {
+		return "0", err
+	}
+	raw := values.First().Value()
+	if raw == nil {
+		raw = "0"
+	}
+	d, err := NewDecimal(raw)
+	if err != nil {
+		return "", err
+	}
+	if f.Type == mSchema.Decimal {
+		_, scale := decimalSpec(f)
+		d = d.Round(scale)
+	}
+	return d, nil
+}
+
+// isNumericField 字段是否为数值类型，加密字段以密文存储不参与计算
+func isNumericField(f *mSchema.Field) bool {
+	if isFieldCrypt(f) {
+		return false
+	}
+	switch f.Type {
+	case mSchema.Decimal, mSchema.Float,
+		mSchema.Int, mSchema.Int8, mSchema.Int16, mSchema.Int32, mSchema.Int64,
+		mSchema.Uint, mSchema.Uint8, mSchema.Uint16, mSchema.Uint32, mSchema.Uint64:
+		return true
+	}
+	return false
+}
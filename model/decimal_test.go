@@ -0,0 +1,111 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func TestDecimal(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	d, err := ParseDecimal(" -007.50 ")
+	tt.NoError(err)
+	tt.Equal(Decimal("-7.50"), d)
+	for _, s := range []string{"", ".", "1e3", "1.2.3", "abc", "--1"} {
+		_, err = ParseDecimal(s)
+		tt.Equal(ErrInvalidDecimal, err)
+	}
+
+	a, _ := NewDecimal(0.1)
+	b, _ := NewDecimal("0.2")
+	tt.Equal(Decimal("0.3"), a.Add(b))
+	tt.Equal(Decimal("-0.1"), a.Sub(b))
+	tt.Equal(Decimal("0.02"), a.Mul(b))
+	tt.Equal(Decimal("1.24"), Decimal("1.235").Round(2))
+	tt.Equal(Decimal("-1.24"), Decimal("-1.235").Round(2))
+	tt.Equal(Decimal("0.00"), Decimal("-0.001").Round(2))
+	tt.Equal(-1, a.Cmp(b))
+	tt.Equal(true, Decimal("0.000").IsZero())
+
+	v, err := Decimal("9.90").Value()
+	tt.NoError(err)
+	tt.Equal("9.90", v)
+}
+
+func TestDecimalField(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	s := schema.Schema{
+		Name:  "decimal_orders",
+		Table: schema.Table{Name: "decimal_orders"},
+		Fields: map[string]schema.Field{
+			"amount": {Type: schema.Decimal, Label: "Amount", Size: 8, Scale: 2},
+			"rate":   {Type: schema.Decimal, Label: "Rate", Nullable: true},
+		},
+	}
+	_, schemas := newTestSchemas(t, s)
+	m := schemas.MustGet("decimal_orders")
+	store := m.Model()
+
+	_, err := store.Insert(ztype.Map{"amount": "1.234"})
+	tt.Equal(true, err != nil)
+	_, err = store.Insert(ztype.Map{"amount": "1000000"})
+	tt.Equal(true, err != nil)
+	_, err = store.Insert(ztype.Map{"amount": "abc"})
+	tt.Equal(true, err != nil)
+
+	id, err := store.Insert(ztype.Map{"amount": "0.1", "rate": 3})
+	tt.NoError(err)
+	_, err = store.Insert(ztype.Map{"amount": 0.2})
+	tt.NoError(err)
+
+	row, err := store.FindOneByID(id)
+	tt.NoError(err)
+	tt.Equal(Decimal("0.10"), row.Get("amount").Value())
+	tt.Equal(Decimal("3.00"), row.Get("rate").Value())
+
+	type order struct {
+		Amount Decimal  `json:"amount" field:"precision:8,scale:2"`
+		Rate   *Decimal `json:"rate"`
+	}
+	orders, err := Find[order](store, Filter{})
+	tt.NoError(err)
+	tt.Equal(Decimal("0.20"), orders[1].Amount)
+
+	total, err := store.Sum("amount", Filter{})
+	tt.NoError(err)
+	tt.Equal(Decimal("0.30"), total)
+
+	total, err = store.Sum("amount", Filter{"amount >": 1})
+	tt.NoError(err)
+	tt.Equal(Decimal("0.00"), total)
+
+	for _, field := range []string{"amount) + (SELECT 1", "unknown"} {
+		_, err = store.Sum(field, Filter{})
+		tt.Equal(true, errors.Is(err, ErrUnknownField))
+	}
+
+	fields := schema.FieldsFromStruct[order]()
+	tt.Equal(schema.Decimal, fields["amount"].Type)
+	tt.Equal(uint64(8), fields["amount"].Size)
+	tt.Equal(uint64(2), fields["amount"].Scale)
+	tt.Equal(true, fields["rate"].Nullable)
+
+	// 超出范围或小数位数大于总位数的定义注册失败
+	for _, f := range []schema.Field{
+		{Type: schema.Decimal, Size: 4, Scale: 6},
+		{Type: schema.Decimal, Scale: 12},
+		{Type: schema.Decimal, Size: 100},
+	} {
+		_, err = schemas.Reg("decimal_invalid", schema.Schema{
+			Name:   "decimal_invalid",
+			Table:  schema.Table{Name: "decimal_invalid"},
+			Fields: map[string]schema.Field{"amount": f},
+		}, false)
+		tt.Equal(true, errors.Is(err, ErrInvalidDecimal))
+	}
+}
@@ -1,8 +1,11 @@
 package model
 
 import (
+	"context"
 	"database/sql/driver"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/zlsgo/app_module/database/hashid"
@@ -10,7 +13,6 @@ import (
 
 	"github.com/sohaha/zlsgo/zdi"
 	"github.com/sohaha/zlsgo/zstring"
-	"github.com/sohaha/zlsgo/ztime"
 	"github.com/sohaha/zlsgo/ztype"
 	"github.com/zlsgo/zdb/builder"
 )
@@ -21,6 +23,7 @@ type (
 		define         schema.Schema
 		Storage        Storageer
 		di             zdi.Injector
+		ctx            context.Context
 		model          *Store
 		cryptKeys      map[string]CryptProcess
 		Hashid         *hashid.HashID `json:"-"`
@@ -29,6 +32,12 @@ type (
 		beforeProcess  map[string][]beforeProcess
 		views          ztype.Map
 		getSchema      func(alias string) (*Schema, bool)
+		hooks          *Hooks
+		schemasHooks   *Hooks
+		getKeyring     func() *FieldKeyring
+		blindIndexes   map[string]string
+		policies       map[string]fieldPolicy
+		timeFields     map[string]string
 		JSONPath       string
 		alias          string
 		tablePrefix    string
@@ -83,13 +92,14 @@ type DataTime struct {
 	time.Time
 }
 
-// UnmarshalJSON 将 JSON 数据解析为 DataTime 类型
+// UnmarshalJSON 将 JSON 数据解析为 DataTime 类型，不含时区的时间按展示时区解释
 func (t *DataTime) UnmarshalJSON(data []byte) error {
-	if len(data) == 2 {
+	raw := strings.Trim(zstring.Bytes2String(data), "\"")
+	if raw == "" || raw == "null" {
 		*t = DataTime{Time: time.Time{}}
 		return nil
 	}
-	now, err := ztime.Parse(zstring.Bytes2String(data))
+	now, err := parseTime(raw, TimeFormat(), DisplayTimeZone())
 	if err != nil {
 		return err
 	}
@@ -97,21 +107,21 @@ func (t *DataTime) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// MarshalJSON 将 DataTime 序列化为 JSON 格式
+// MarshalJSON 将 DataTime 按默认时间格式与展示时区序列化为 JSON
 func (t DataTime) MarshalJSON() ([]byte, error) {
 	if t.Time.IsZero() {
 		return []byte("null"), nil
 	}
-	return zstring.String2Bytes(ztime.FormatTime(t.Time, "\"Y-m-d H:i:s\"")), nil
+	return zstring.String2Bytes(strconv.Quote(formatTime(t.Time, TimeFormat(), DisplayTimeZone()))), nil
 }
 
-// Value 返回 driver.Value，实现 driver.Valuer 接口
+// Value 返回转换为存储时区的时间，实现 driver.Valuer 接口
 func (t DataTime) Value() (driver.Value, error) {
 	var zeroTime time.Time
 	if t.Time.IsZero() || t.Time.UnixNano() == zeroTime.UnixNano() {
 		return nil, nil
 	}
-	return t.Time, nil
+	return storageTime(t.Time), nil
 }
 
 // String 返回格式化的时间字符串
@@ -119,18 +129,17 @@ func (t *DataTime) String() string {
 	if t.Time.IsZero() {
 		return "0000-00-00 00:00:00"
 	}
-	return ztime.FormatTime(t.Time)
+	return formatTime(t.Time, TimeFormat(), DisplayTimeZone())
 }
 
-// Scan 将数据库值扫描到 DataTime，实现 sql.Scanner 接口
+// Scan 将数据库值扫描到 DataTime，实现 sql.Scanner 接口，不含时区的值按存储时区解释
 func (t *DataTime) Scan(v interface{}) error {
-	value, ok := v.(time.Time)
-	if ok {
+	switch value := v.(type) {
+	case time.Time:
 		*t = DataTime{Time: value}
 		return nil
-	}
-	if b, ok := v.([]byte); ok {
-		parse, err := ztime.Parse(zstring.Bytes2String(b))
+	case []byte, string:
+		parse, err := parseTime(value, "", StorageTimeZone())
 		if err != nil {
 			return err
 		}
@@ -56,7 +56,10 @@ func (m *Schema) valuesBeforeProcess(data ztype.Map) (ztype.Map, error) {
 }
 
 func (m *Schema) valuesCryptProcess(data ztype.Map) (ztype.Map, error) {
-	var err error
+	err := m.valuesBlindIndex(data)
+	if err != nil {
+		return nil, err
+	}
 	for k := range m.cryptKeys {
 		if _, ok := data[k]; ok {
 			data[k], err = m.cryptKeys[k](data.Get(k).String())
@@ -82,6 +85,9 @@ func (m *Schema) GetAfterProcess(p []string) (fn []afterProcess, err error) {
 						fn = append(fn, dateUnmarshalProcess(v[1]))
 					}
 					continue
+				case "decimal":
+					fn = append(fn, decimalUnmarshalProcess(ztype.ToInt(v[1])))
+					continue
 				}
 			}
 			return nil, errors.New("after name not found")
@@ -0,0 +1,147 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/sohaha/zlsgo/zjson"
+	"github.com/sohaha/zlsgo/zstring"
+	"github.com/sohaha/zlsgo/ztype"
+	mSchema "github.com/zlsgo/app_module/model/schema"
+	"github.com/zlsgo/zdb/schema"
+)
+
+// enumValues 字段的合法枚举值
+func enumValues(f *mSchema.Field) []string {
+	return zarray.Map(f.Options.Enum, func(_ int, v mSchema.FieldEnum) string {
+		return v.Value
+	})
+}
+
+// verifyEnum 校验枚举字段的取值，多选字段逐项校验
+func verifyEnum(label string, f *mSchema.Field, v any) error {
+	if len(f.Options.Enum) == 0 || v == nil {
+		return nil
+	}
+
+	allowed := enumValues(f)
+	if !f.Options.IsArray {
+		s := ztype.ToString(v)
+		if (s == "" && f.Nullable) || zarray.Contains(allowed, s) {
+			return nil
+		}
+		return errors.New(label + "枚举值不在合法范围")
+	}
+
+	var items []string
+	if s, ok := v.(string); ok {
+		j := zjson.Parse(s)
+		if s != "" && !j.IsArray() {
+			return errors.New(label + "必须是数组")
+		}
+		items = j.Slice().String()
+	} else {
+		items = ztype.ToSlice(v).String()
+	}
+	for i := range items {
+		if !zarray.Contains(allowed, items[i]) {
+			return errors.New(label + "枚举值不在合法范围")
+		}
+	}
+	return nil
+}
+
+// enumCheck 开启 EnumCheck 的单选枚举字段生成 CHECK 表达式
+func enumCheck(name string, f *mSchema.Field) string {
+	if !f.Options.EnumCheck || f.Options.IsArray || len(f.Options.Enum) == 0 {
+		return ""
+	}
+
+	numeric := isNumericType(f.Type)
+	values := make([]string, 0, len(f.Options.Enum))
+	for _, v := range enumValues(f) {
+		if numeric {
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				return ""
+			}
+			values = append(values, v)
+			continue
+		}
+		values = append(values, "'"+strings.ReplaceAll(v, "'", "''")+"'")
+	}
+	return "CHECK (" + name + " IN (" + strings.Join(values, ", ") + "))"
+}
+
+// enumCheckPrefix 枚举约束名前缀，约束名以 CHECK 表达式的摘要结尾，枚举值变化时可识别出过期的约束
+func enumCheckPrefix(table, name string) string {
+	prefix := "ck_" + table + "_" + name + "_"
+	if len(prefix)+enumCheckHashLen > 63 {
+		prefix = "ck_" + zstring.Md5(table + "." + name)[:16] + "_"
+	}
+	return prefix
+}
+
+const enumCheckHashLen = 8
+
+// enumCheckName 枚举约束名，未开启约束时为空
+func enumCheckName(table, name string, f *mSchema.Field) string {
+	check := enumCheck(name, f)
+	if check == "" {
+		return ""
+	}
+	return enumCheckPrefix(table, name) + zstring.Md5(check)[:enumCheckHashLen]
+}
+
+// enumCheckConstraint 开启 EnumCheck 的单选枚举字段生成具名 CHECK 约束
+func enumCheckConstraint(table, name string, f *mSchema.Field) string {
+	check := enumCheck(name, f)
+	if check == "" {
+		return ""
+	}
+	return "CONSTRAINT " + enumCheckName(table, name, f) + " " + check
+}
+
+// enumCheckConstraints 建表时需要追加的 CHECK 约束
+func enumCheckConstraints(m *Schema, fields mSchema.Fields) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	checks := make([]string, 0)
+	for _, name := range names {
+		if isDisableMigratioField(m, name) {
+			continue
+		}
+		f := fields[name]
+		if check := enumCheckConstraint(m.GetTableName(), name, &f); check != "" {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+func isNumericType(t schema.DataType) bool {
+	switch t {
+	case schema.Int, schema.Int8, schema.Int16, schema.Int32, schema.Int64,
+		schema.Uint, schema.Uint8, schema.Uint16, schema.Uint32, schema.Uint64,
+		schema.Float, mSchema.Decimal:
+		return true
+	}
+	return false
+}
+
+// GetEnums 返回模型中枚举字段的可选值
+func (m *Schema) GetEnums() map[string][]mSchema.FieldEnum {
+	enums := make(map[string][]mSchema.FieldEnum)
+	for name, f := range m.define.Fields {
+		if len(f.Options.Enum) > 0 {
+			enums[name] = f.Options.Enum
+		}
+	}
+	return enums
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+type enumStatus string
+
+func (enumStatus) EnumValues() []schema.FieldEnum {
+	return []schema.FieldEnum{{Value: "draft", Label: "草稿"}, {Value: "published", Label: "已发布"}}
+}
+
+func TestEnumField(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	status := schema.Field{Type: schema.Int8, Label: "状态", Options: schema.FieldOption{
+		Enum:      []schema.FieldEnum{{Value: "1", Label: "启用"}, {Value: "2", Label: "停用"}},
+		EnumCheck: true,
+	}}
+	tt.Equal("CHECK (status IN (1, 2))", enumCheck("status", &status))
+	tt.EqualTrue(strings.HasPrefix(enumCheckConstraint("posts", "status", &status), "CONSTRAINT ck_posts_status_"))
+	level := schema.Field{Type: schema.String, Options: schema.FieldOption{
+		Enum:      []schema.FieldEnum{{Value: "it's"}},
+		EnumCheck: true,
+	}}
+	tt.Equal("CHECK (level IN ('it''s'))", enumCheck("level", &level))
+
+	s := schema.Schema{
+		Name:  "enum_posts",
+		Table: schema.Table{Name: "enum_posts"},
+		Fields: map[string]schema.Field{
+			"status": status,
+			"tags": {Type: schema.JSON, Label: "标签", Options: schema.FieldOption{
+				IsArray: true,
+				Enum:    []schema.FieldEnum{{Value: "go", Label: "Go"}, {Value: "db", Label: "DB"}},
+			}},
+		},
+	}
+	db, schemas := newTestSchemas(t, s)
+	m := schemas.MustGet("enum_posts")
+	store := m.Model()
+
+	id, err := store.Insert(ztype.Map{"status": 1, "tags": []string{"go", "db"}})
+	tt.NoError(err)
+	_, err = store.Insert(ztype.Map{"status": 3, "tags": []string{"go"}})
+	tt.Equal(true, err != nil)
+	_, err = store.Insert(ztype.Map{"status": 1, "tags": []string{"go", "rust"}})
+	tt.Equal(true, err != nil)
+	_, err = store.UpdateByID(id, ztype.Map{"status": 5})
+	tt.Equal(true, err != nil)
+	_, err = store.UpdateByID(id, ztype.Map{"status": "2"})
+	tt.NoError(err)
+
+	_, err = db.Exec("INSERT INTO "+m.GetTableName()+" (status, tags) VALUES (?, ?)", 9, "[]")
+	tt.Equal(true, err != nil)
+
+	row, err := store.FindOneByID(id)
+	tt.NoError(err)
+	rows := m.ParseLables(ztype.Maps{row})
+	tt.Equal("停用", rows[0].Get("status_label").String())
+	tt.Equal([]string{"Go", "DB"}, rows[0].Get("tags_label").Value())
+
+	tt.Equal(2, len(m.GetEnums()))
+
+	type post struct {
+		Status enumStatus   `json:"status"`
+		Flags  []enumStatus `json:"flags"`
+		Kind   enumStatus   `json:"kind" field:"enum:a|b"`
+	}
+	fields := schema.FieldsFromStruct[post]()
+	tt.Equal(2, len(fields["status"].Options.Enum))
+	tt.Equal("已发布", fields["status"].Options.Enum[1].Label)
+	tt.Equal(true, fields["flags"].Options.IsArray)
+	tt.Equal("a", fields["kind"].Options.Enum[0].Value)
+}
+
+func TestEnumCheckMigration(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	field := func(values ...string) schema.Field {
+		enum := make([]schema.FieldEnum, 0, len(values))
+		for _, v := range values {
+			enum = append(enum, schema.FieldEnum{Value: v})
+		}
+		return schema.Field{Type: schema.Int8, Label: "状态", Index: true, Options: schema.FieldOption{Enum: enum, EnumCheck: true}}
+	}
+	b := false
+	s := schema.Schema{
+		Name:    "enum_check_posts",
+		Table:   schema.Table{Name: "enum_check_posts"},
+		Options: schema.Options{Timestamps: &b},
+		Fields:  map[string]schema.Field{"status": field("1", "2")},
+	}
+	db, schemas := newTestSchemas(t, s)
+	table := schemas.MustGet("enum_check_posts").GetTableName()
+	_, err := db.Exec("INSERT INTO "+table+" (status) VALUES (?)", 1)
+	tt.NoError(err)
+	_, err = db.Exec("INSERT INTO "+table+" (status) VALUES (?)", 3)
+	tt.EqualTrue(err != nil)
+
+	// 新增枚举值后重建约束，已有数据与索引保留
+	s.Fields = map[string]schema.Field{"status": field("1", "2", "3")}
+	m, err := schemas.Reg("enum_check_posts", s, true)
+	tt.NoError(err)
+	_, err = db.Exec("INSERT INTO "+table+" (status) VALUES (?)", 3)
+	tt.NoError(err)
+	_, err = db.Exec("INSERT INTO "+table+" (status) VALUES (?)", 4)
+	tt.EqualTrue(err != nil)
+	total, err := m.Model().Count(Filter{})
+	tt.NoError(err)
+	tt.Equal(uint64(2), total)
+	rows, err := db.QueryToMaps("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ?", table)
+	tt.NoError(err)
+	tt.EqualTrue(len(rows) > 0)
+
+	// 关闭约束后移除
+	f := field("1")
+	f.Options.EnumCheck = false
+	s.Fields = map[string]schema.Field{"status": f}
+	_, err = schemas.Reg("enum_check_posts", s, true)
+	tt.NoError(err)
+	_, err = db.Exec("INSERT INTO "+table+" (status) VALUES (?)", 9)
+	tt.NoError(err)
+}
@@ -30,6 +30,38 @@ var (
 	ErrSoftDeleteNotSupported = errors.New("soft delete not supported")
 	// ErrHookCancelled 钩子取消操作
 	ErrHookCancelled = errors.New("operation cancelled by hook")
+	// ErrTenantRequired 缺少租户
+	ErrTenantRequired = errors.New("tenant is required")
+	// ErrInvalidTenant 无效租户标识
+	ErrInvalidTenant = errors.New("invalid tenant")
+	// ErrLockOutsideTransaction 事务外使用行锁
+	ErrLockOutsideTransaction = errors.New("row lock requires a transaction")
+	// ErrLockNotSupported 当前查询不支持行锁
+	ErrLockNotSupported = errors.New("row lock not supported")
+	// ErrTxOptionsNotSupported 当前存储不支持的事务选项
+	ErrTxOptionsNotSupported = errors.New("transaction options not supported")
+	// ErrUnknownField 字段不存在
+	ErrUnknownField = errors.New("unknown field")
+	// ErrInvalidKeyField 批量更新的键字段不是主键或唯一字段
+	ErrInvalidKeyField = errors.New("key field must be a primary key or unique field")
+	// ErrUnknownScope 作用域不存在
+	ErrUnknownScope = errors.New("unknown scope")
+	// ErrInvalidSubQuery 无效子查询
+	ErrInvalidSubQuery = errors.New("invalid subquery")
+	// ErrInvalidExpr 无效的原生表达式
+	ErrInvalidExpr = errors.New("invalid raw expression")
+	// ErrUnsupportedFormat 不支持的导入导出格式
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	// ErrFileTooLarge 导入文件超过大小上限
+	ErrFileTooLarge = errors.New("file too large")
+	// ErrImportFailed 事务模式下存在失败的行，全部写入已回滚
+	ErrImportFailed = errors.New("import failed")
+	// ErrInvalidSeeder 无效的填充器
+	ErrInvalidSeeder = errors.New("invalid seeder")
+	// ErrSeedCycle 填充器存在循环依赖
+	ErrSeedCycle = errors.New("seeder dependency cycle")
+	// ErrMigrationPlanNotSupported 存储不支持迁移预演
+	ErrMigrationPlanNotSupported = errors.New("migration plan not supported")
 )
 
 // ModelError 模型错误
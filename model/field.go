@@ -58,15 +58,8 @@ func (m *Schema) getField(name string) (*mSchema.Field, bool) {
 		return &field, true
 	}
 
-	if name == idKey {
-		return &mSchema.Field{
-			Type:     schema.Int,
-			Nullable: false,
-			Label:    "ID",
-			Options: mSchema.FieldOption{
-				ReadOnly: true,
-			},
-		}, true
+	if name == idKey && !m.IsCompositeKey() {
+		return m.primaryKeyField(), true
 	}
 	if *m.define.Options.Timestamps {
 		switch name {
@@ -108,6 +101,17 @@ func (m *Schema) getField(name string) (*mSchema.Field, bool) {
 		}
 	}
 
+	if name == TenantIDKey && m.Tenancy() == mSchema.TenancyColumn {
+		return &mSchema.Field{
+			Type:  schema.String,
+			Size:  64,
+			Label: "租户",
+			Options: mSchema.FieldOption{
+				ReadOnly: true,
+			},
+		}, true
+	}
+
 	// if m.models.Options.CreatedBy {
 	// 	if name == CreatedByKey {
 	// 		return &Field{
@@ -136,7 +140,10 @@ func (m *Schema) isInlayField(field string) bool {
 		_, ok := m.inlayFieldsMap[field]
 		return ok
 	}
-	inlayFields := []string{idKey}
+	inlayFields := make([]string, 0, 5)
+	if !m.IsCompositeKey() {
+		inlayFields = append(inlayFields, idKey)
+	}
 	if *m.define.Options.Timestamps {
 		inlayFields = append(inlayFields, CreatedAtKey, UpdatedAtKey)
 	}
@@ -146,6 +153,9 @@ func (m *Schema) isInlayField(field string) bool {
 	if *m.define.Options.SoftDeletes {
 		inlayFields = append(inlayFields, DeletedAtKey)
 	}
+	if m.Tenancy() == mSchema.TenancyColumn {
+		inlayFields = append(inlayFields, TenantIDKey)
+	}
 	return zarray.Contains(inlayFields, field)
 }
 
@@ -169,6 +179,18 @@ func perfectField(m *Schema) ([]string, error) {
 
 		fields = append(fields, name)
 		nFields[name] = field
+
+		if column, idx, ok := blindIndexField(name, &field); ok {
+			if _, exists := m.define.Fields[column]; exists {
+				return nil, errors.New(column + " is a reserved field")
+			}
+			if err := parseField(m, column, &idx); err != nil {
+				return nil, err
+			}
+			m.blindIndexes[name] = column
+			fields = append(fields, column)
+			nFields[column] = idx
+		}
 	}
 	m.define.Fields = nFields
 
@@ -201,15 +223,16 @@ func parseField(m *Schema, name string, f *mSchema.Field) error {
 		f.Before = append(f.Before, jsonProcess)
 		f.After = append(f.After, jsonProcess)
 	case schema.Time:
-		format := f.Options.FormatTime
-		if format == "" {
-			format = "date|Y-m-d H:i:s"
-		} else {
-			format = "date|" + format
+		if m.timeFields == nil {
+			m.timeFields = make(map[string]string)
 		}
-
-		f.Before = append(f.Before, format)
-		f.After = append(f.After, format)
+		m.timeFields[name] = m.timeFormat(f)
+	case mSchema.Decimal:
+		if err := checkDecimalSpec(name, f); err != nil {
+			return err
+		}
+		_, scale := decimalSpec(f)
+		f.After = append(f.After, "decimal|"+strconv.Itoa(scale))
 	}
 
 	if f.Options.Crypt != "" {
@@ -236,6 +259,14 @@ func parseField(m *Schema, name string, f *mSchema.Field) error {
 		m.afterProcess[name] = ps
 	}
 
+	if isFieldCrypt(f) {
+		m.afterProcess[name] = append([]afterProcess{m.fieldDecryptProcess()}, m.afterProcess[name]...)
+	}
+
+	if err := parseFieldPolicy(m, name, f); err != nil {
+		return err
+	}
+
 	parseFieldValidRule(name, f)
 	parseFieldModelOptions(name, f)
 	return nil
@@ -254,11 +285,9 @@ func parseFieldModelOptions(_ string, c *mSchema.Field) {
 			},
 		)
 
-		c.ValidRules = c.ValidRules.EnumString(
-			zarray.Map(c.Options.Enum, func(_ int, v mSchema.FieldEnum) string {
-				return v.Value
-			}),
-		)
+		if !c.Options.IsArray {
+			c.ValidRules = c.ValidRules.EnumString(enumValues(c))
+		}
 	}
 }
 
@@ -296,13 +325,9 @@ func parseFieldValidRule(name string, c *mSchema.Field) {
 				if rawValue == "" {
 					return rawValue, nil
 				}
-				t, parseErr := ztime.Parse(rawValue)
+				t, parseErr := parseTime(rawValue, c.Options.FormatTime, nil)
 				if parseErr != nil {
-					if ts, err2 := strconv.ParseInt(rawValue, 10, 64); err2 == nil {
-						t = ztime.Unix(ts)
-					} else {
-						return rawValue, errors.New(label + ": 时间格式错误")
-					}
+					return rawValue, errors.New(label + ": 时间格式错误")
 				}
 				if t.After(ztime.Unix(int64(c.Size))) {
 					return rawValue, errors.New(label + "时间不能大于指定时间")
@@ -375,6 +400,22 @@ func perfectOptions(m *Schema, o *SchemaOptions) error {
 	if m.define.Options.CryptID == nil {
 		m.define.Options.CryptID = &o.CryptID
 	}
+	if *m.define.Options.CryptID && (m.isStringPrimaryKey() || m.IsCompositeKey()) {
+		cryptID := false
+		m.define.Options.CryptID = &cryptID
+	}
+
+	if m.define.Options.Audit == nil {
+		m.define.Options.Audit = &o.Audit
+	}
+
+	if m.define.Options.Outbox == nil {
+		m.define.Options.Outbox = &o.Outbox
+	}
+
+	if m.define.Options.Tenancy == "" {
+		m.define.Options.Tenancy = o.Tenancy
+	}
 	return nil
 }
 
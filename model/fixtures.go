@@ -0,0 +1,152 @@
+package model
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sohaha/zlsgo/ztype"
+	"gopkg.in/yaml.v3"
+)
+
+// FixturesT 测试辅助所需的方法，*testing.T 与 *testing.B 均满足
+type FixturesT interface {
+	Helper()
+	Fatalf(format string, args ...any)
+	Cleanup(func())
+}
+
+type fixtureSet struct {
+	alias string
+	rows  []ztype.Map
+}
+
+// Fixtures 测试数据集，按添加顺序写入，按相反顺序清空
+type Fixtures struct {
+	schemas *Schemas
+	storage Storageer
+	sets    []fixtureSet
+}
+
+// NewFixtures 创建测试数据集，storage 为空时使用模型集合的存储
+func NewFixtures(ss *Schemas, storage ...Storageer) *Fixtures {
+	f := &Fixtures{schemas: ss, storage: ss.Storage()}
+	if len(storage) > 0 && storage[0] != nil {
+		f.storage = storage[0]
+	}
+	return f
+}
+
+// LoadFixtures 加载 YAML/JSON 数据文件，测试结束时自动清空相关表
+func LoadFixtures(t FixturesT, ss *Schemas, storage Storageer, paths ...string) *Fixtures {
+	t.Helper()
+	f := NewFixtures(ss, storage)
+	if err := f.File(paths...); err != nil {
+		t.Fatalf("load fixtures: %v", err)
+	}
+	if err := f.Load(); err != nil {
+		t.Fatalf("load fixtures: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = f.Reset()
+	})
+	return f
+}
+
+// Add 添加模型数据
+func (f *Fixtures) Add(alias string, rows ...ztype.Map) *Fixtures {
+	for i := range f.sets {
+		if f.sets[i].alias == alias {
+			f.sets[i].rows = append(f.sets[i].rows, rows...)
+			return f
+		}
+	}
+	f.sets = append(f.sets, fixtureSet{alias: alias, rows: rows})
+	return f
+}
+
+// File 读取数据文件，顶层键为模型别名，值为行列表
+func (f *Fixtures) File(paths ...string) error {
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err = f.Parse(data); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Parse 解析 YAML 或 JSON 数据，保持模型的书写顺序
+func (f *Fixtures) Parse(data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%w: fixtures must be a mapping of schema to rows", ErrInvalidData)
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		alias := root.Content[i].Value
+		if _, ok := f.schemas.Get(alias); !ok {
+			return fmt.Errorf("%w: schema %s not found", ErrInvalidData, alias)
+		}
+		var rows []map[string]any
+		if err := root.Content[i+1].Decode(&rows); err != nil {
+			return fmt.Errorf("%s: %w", alias, err)
+		}
+		items := make([]ztype.Map, len(rows))
+		for j := range rows {
+			items[j] = ztype.Map(rows[j])
+		}
+		f.Add(alias, items...)
+	}
+	return nil
+}
+
+// Load 清空相关表后写入全部数据
+func (f *Fixtures) Load() error {
+	if err := f.Reset(); err != nil {
+		return err
+	}
+	for _, set := range f.sets {
+		m, err := f.schema(set.alias)
+		if err != nil {
+			return err
+		}
+		for i := range set.rows {
+			if _, err = Insert(m, cloneFilterMap(set.rows[i])); err != nil {
+				return fmt.Errorf("%s row %d: %w", set.alias, i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Reset 物理删除相关表的全部记录，不触发钩子
+func (f *Fixtures) Reset() error {
+	for i := len(f.sets) - 1; i >= 0; i-- {
+		m, err := f.schema(f.sets[i].alias)
+		if err != nil {
+			return err
+		}
+		if _, err = m.Storage.Delete(m.GetTableName(), ztype.Map{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Fixtures) schema(alias string) (*Schema, error) {
+	m, ok := f.schemas.Get(alias)
+	if !ok {
+		return nil, fmt.Errorf("%w: schema %s not found", ErrInvalidData, alias)
+	}
+	return cloneSchemaWithStorage(m, f.storage), nil
+}
@@ -0,0 +1,536 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"go/format"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/sohaha/zlsgo/zstring"
+	"github.com/sohaha/zlsgo/ztype"
+	mSchema "github.com/zlsgo/app_module/model/schema"
+	"github.com/zlsgo/zdb/schema"
+)
+
+// GoGenOptions Go 代码生成选项
+type GoGenOptions struct {
+	// Package 生成文件的包名，默认 models
+	Package string
+	// Env 读取模型目录时使用的环境覆盖
+	Env string
+	// Names 仅生成指定别名的模型，为空时生成全部
+	Names []string
+	// SchemaOptions 读取模型目录时的全局模型选项，需与模块配置一致
+	SchemaOptions SchemaOptions
+}
+
+// GenerateGoFromDir 读取模型目录并生成 Go 代码
+func GenerateGoFromDir(dir string, opt GoGenOptions) ([]byte, error) {
+	defines, err := parseSchema(dir, opt.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	ss := NewSchemas(nil, nil, opt.SchemaOptions)
+	names := make([]string, 0, len(defines))
+	for i := range defines {
+		if _, err = ss.Reg(defines[i].Name, defines[i], false); err != nil {
+			return nil, err
+		}
+		names = append(names, defines[i].Name)
+	}
+	if len(opt.Names) == 0 {
+		opt.Names = names
+	}
+	return ss.GenerateGo(opt)
+}
+
+// GenerateGo 为已注册模型生成结构体、写入参数、过滤条件与仓储构造函数
+func (ss *Schemas) GenerateGo(opt GoGenOptions) ([]byte, error) {
+	if opt.Package == "" {
+		opt.Package = "models"
+	}
+
+	names := opt.Names
+	if len(names) == 0 {
+		ss.ForEach(func(key string, _ *Schema) bool {
+			names = append(names, key)
+			return true
+		})
+	}
+	names = zarray.Unique(names)
+	sort.Strings(names)
+
+	g := &goGenerator{imports: map[string]bool{
+		"github.com/sohaha/zlsgo/ztype":            true,
+		"github.com/zlsgo/app_module/model":        true,
+		"github.com/zlsgo/app_module/model/schema": true,
+	}}
+	for _, name := range names {
+		m, ok := ss.Get(name)
+		if !ok {
+			return nil, errors.New("schema " + name + " not found")
+		}
+		if err := g.schema(m); err != nil {
+			return nil, errors.New("schema " + name + ": " + err.Error())
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by modelgen. DO NOT EDIT.\n\n")
+	out.WriteString("package " + opt.Package + "\n\nimport (\n")
+	imports := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+	for _, path := range imports {
+		out.WriteString("\t" + strconv.Quote(path) + "\n")
+	}
+	out.WriteString(")\n")
+	out.Write(g.body.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+type goGenerator struct {
+	imports map[string]bool
+	body    bytes.Buffer
+}
+
+type goGenField struct {
+	field  *mSchema.Field
+	name   string
+	ident  string
+	typ    string
+	inlay  bool
+	scalar bool
+}
+
+func (g *goGenerator) printf(format string, args ...string) {
+	writef(&g.body, format, args...)
+}
+
+// writef 将 format 中的 $1、$2… 依次替换为 args 后写入
+func writef(b *bytes.Buffer, format string, args ...string) {
+	r := make([]string, 0, len(args)*2)
+	for i, v := range args {
+		r = append(r, "$"+strconv.Itoa(i+1), v)
+	}
+	b.WriteString(strings.NewReplacer(r...).Replace(format))
+}
+
+func (g *goGenerator) schema(m *Schema) error {
+	fields, err := g.fields(m)
+	if err != nil {
+		return err
+	}
+
+	meta, err := goMetaTag(m)
+	if err != nil {
+		return err
+	}
+
+	name := goIdent(m.GetAlias())
+	comment := strings.Join(strings.Fields(m.GetComment()), " ")
+	if comment == "" {
+		comment = m.GetAlias()
+	}
+	generic := "[" + name + ", " + name + "Filter, " + name + "Create, " + name + "Update]"
+
+	g.printf("\n// $1 $2\ntype $1 struct {\n\t_ schema.Meta `schema:$3`\n", name, comment, strconv.Quote(meta))
+	for _, f := range fields {
+		tag := "-"
+		if !f.inlay {
+			if tag, err = goFieldTag(f.name, f.field); err != nil {
+				return errors.New("field " + f.name + ": " + err.Error())
+			}
+		}
+		typ := f.typ
+		if f.field.Type == schema.Time {
+			// 查询结果中的时间已按模型时间格式转为字符串
+			typ = "string"
+		}
+		if f.scalar && f.field.Nullable && !f.inlay {
+			typ = "*" + typ
+		}
+		g.printf("\t$1 $2 `json:$3 field:$4`\n", f.ident, typ, strconv.Quote(f.name), strconv.Quote(tag))
+	}
+	g.body.WriteString("}\n")
+
+	writable := zarray.Filter(fields, func(_ int, f goGenField) bool {
+		return !f.inlay
+	})
+	updatable := zarray.Filter(writable, func(_ int, f goGenField) bool {
+		return !f.field.Options.ReadOnly && !zarray.Contains(m.readOnlyKeys, f.name)
+	})
+	filterable := zarray.Filter(fields, func(_ int, f goGenField) bool {
+		return f.scalar
+	})
+
+	g.printf("\n// $1Create 新增$2的写入参数\ntype $1Create struct {\n", name, comment)
+	for _, f := range writable {
+		typ := f.typ
+		if f.scalar && f.field.Nullable {
+			typ = "*" + typ
+		}
+		g.printf("\t$1 $2 `json:$3`\n", f.ident, typ, strconv.Quote(f.name+",omitempty"))
+	}
+	g.body.WriteString("}\n")
+	g.toMap(name+"Create", "转换为写入数据，未赋值的可空字段不写入", writable, func(f goGenField) bool {
+		return f.scalar && f.field.Nullable
+	})
+
+	g.printf("\n// $1Update 更新$2的写入参数，nil 字段不更新\ntype $1Update struct {\n", name, comment)
+	for _, f := range updatable {
+		g.printf("\t$1 *$2 `json:$3`\n", f.ident, f.typ, strconv.Quote(f.name+",omitempty"))
+	}
+	g.body.WriteString("}\n")
+	g.toMap(name+"Update", "转换为更新数据，忽略 nil 字段", updatable, func(goGenField) bool {
+		return true
+	})
+
+	g.printf("\n// $1Filter $2的查询条件，nil 字段不参与过滤\ntype $1Filter struct {\n", name, comment)
+	for _, f := range filterable {
+		g.printf("\t$1 *$2 `json:$3`\n", f.ident, f.typ, strconv.Quote(f.name+",omitempty"))
+	}
+	g.body.WriteString("}\n")
+	g.toMap(name+"Filter", "转换为查询条件，忽略 nil 字段", filterable, func(goGenField) bool {
+		return true
+	})
+
+	g.printf("\n// $1Repository $2仓储\ntype $1Repository = model.Repository$3\n", name, comment, generic)
+	g.printf("\n// New$1Repository 创建$2仓储\nfunc New$1Repository(store *model.Store) *$1Repository {\n\treturn model.NewStructRepository$3(store)\n}\n", name, comment, generic)
+	return nil
+}
+
+// toMap 生成 ToMap 方法，指针、切片与 map 字段仅在非 nil 时写入
+func (g *goGenerator) toMap(typ, comment string, fields []goGenField, pointer func(goGenField) bool) {
+	g.printf("\n// ToMap $1\nfunc (v $2) ToMap() ztype.Map {\n\tdata := make(ztype.Map, $3)\n", comment, typ, strconv.Itoa(len(fields)))
+	for _, f := range fields {
+		switch {
+		case pointer(f):
+			g.printf("\tif v.$1 != nil {\n\t\tdata[$2] = *v.$1\n\t}\n", f.ident, strconv.Quote(f.name))
+		case !f.scalar:
+			g.printf("\tif v.$1 != nil {\n\t\tdata[$2] = v.$1\n\t}\n", f.ident, strconv.Quote(f.name))
+		default:
+			g.printf("\tdata[$2] = v.$1\n", f.ident, strconv.Quote(f.name))
+		}
+	}
+	g.body.WriteString("\treturn data\n}\n")
+}
+
+// fields 返回生成代码使用的字段
+func (g *goGenerator) fields(m *Schema) ([]goGenField, error) {
+	names := m.genFieldNames()
+	fields := make([]goGenField, 0, len(names))
+	idents := make(map[string]string, len(names))
+	for _, name := range names {
+		f, ok := m.getField(name)
+		if !ok {
+			continue
+		}
+		inlay := m.isInlayField(name)
+		ident := goIdent(name)
+		if exists, ok := idents[ident]; ok {
+			return nil, errors.New("fields " + exists + " and " + name + " map to the same Go name " + ident)
+		}
+		idents[ident] = name
+
+		typ, scalar := g.goType(f)
+		if name == idKey && *m.define.Options.CryptID {
+			typ = "string"
+		}
+		fields = append(fields, goGenField{name: name, ident: ident, field: f, typ: typ, inlay: inlay, scalar: scalar})
+	}
+	return fields, nil
+}
+
+// goType 字段对应的 Go 类型，scalar 表示可通过指针表达可空
+func (g *goGenerator) goType(f *mSchema.Field) (string, bool) {
+	switch f.Type {
+	case schema.Bool:
+		return "bool", true
+	case schema.Int:
+		return "int", true
+	case schema.Int8:
+		return "int8", true
+	case schema.Int16:
+		return "int16", true
+	case schema.Int32:
+		return "int32", true
+	case schema.Int64:
+		return "int64", true
+	case schema.Uint:
+		return "uint", true
+	case schema.Uint8:
+		return "uint8", true
+	case schema.Uint16:
+		return "uint16", true
+	case schema.Uint32:
+		return "uint32", true
+	case schema.Uint64:
+		return "uint64", true
+	case schema.Float:
+		return "float64", true
+	case mSchema.Decimal:
+		return "model.Decimal", true
+	case schema.Time:
+		g.imports["time"] = true
+		return "time.Time", true
+	case schema.Bytes:
+		return "[]byte", false
+	case schema.JSON:
+		if !f.Options.IsArray {
+			return "map[string]any", false
+		}
+		if len(f.Options.Enum) > 0 {
+			return "[]string", false
+		}
+		return "[]any", false
+	default:
+		return "string", true
+	}
+}
+
+// goFieldTag 生成 schema/reflect.go 可解析的 field tag
+func goFieldTag(name string, f *mSchema.Field) (string, error) {
+	parts := []string{"type:" + string(f.Type)}
+	add := func(key, value string) error {
+		if strings.ContainsAny(value, ",\"`") {
+			return errors.New(key + " value " + strconv.Quote(value) + " cannot be represented in a struct tag")
+		}
+		parts = append(parts, key+":"+value)
+		return nil
+	}
+	list := func(key string, items []string, reserved string) error {
+		for _, item := range items {
+			if strings.ContainsAny(item, reserved) {
+				return errors.New(key + " item " + strconv.Quote(item) + " cannot be represented in a struct tag")
+			}
+		}
+		return add(key, strings.Join(items, "|"))
+	}
+
+	var err error
+	if f.Size > 0 {
+		parts = append(parts, "size:"+strconv.FormatUint(f.Size, 10))
+	}
+	if f.Scale > 0 {
+		parts = append(parts, "scale:"+strconv.FormatUint(f.Scale, 10))
+	}
+	if f.Nullable {
+		parts = append(parts, "nullable")
+	}
+	for _, kv := range []struct {
+		key string
+		val any
+	}{{"unique", f.Unique}, {"index", f.Index}} {
+		switch v := kv.val.(type) {
+		case bool:
+			if v {
+				parts = append(parts, kv.key)
+			}
+		case string:
+			if v != "" {
+				err = errors.Join(err, add(kv.key, v))
+			}
+		}
+	}
+	if f.Default != nil {
+		err = errors.Join(err, add("default", ztype.ToString(f.Default)))
+	}
+	if f.Label != "" && f.Label != name {
+		err = errors.Join(err, add("label", f.Label))
+	}
+	if f.Comment != "" {
+		err = errors.Join(err, add("comment", f.Comment))
+	}
+
+	o := f.Options
+	if o.ReadOnly {
+		parts = append(parts, "readonly")
+	}
+	if o.Crypt != "" {
+		err = errors.Join(err, add("crypt", o.Crypt))
+	}
+	if o.BlindIndex {
+		parts = append(parts, "blind_index")
+	}
+	if o.IsArray {
+		parts = append(parts, "array")
+	}
+	if o.FormatTime != "" {
+		err = errors.Join(err, add("format", o.FormatTime))
+	}
+	if len(o.Enum) > 0 {
+		items := make([]string, 0, len(o.Enum))
+		for _, e := range o.Enum {
+			if strings.Contains(e.Value, "=") {
+				return "", errors.New("enum value " + strconv.Quote(e.Value) + " cannot be represented in a struct tag")
+			}
+			items = append(items, e.Value+"="+e.Label)
+		}
+		err = errors.Join(err, list("enum", items, "|;"))
+	}
+	if o.EnumCheck {
+		parts = append(parts, "enum_check")
+	}
+	if len(f.Validations) > 0 {
+		items := make([]string, 0, len(f.Validations))
+		for _, v := range f.Validations {
+			item := v.Method
+			if v.Args != nil {
+				item += "=" + ztype.ToString(v.Args)
+			}
+			if strings.Contains(item, "@") {
+				return "", errors.New("validation " + strconv.Quote(item) + " cannot be represented in a struct tag")
+			}
+			if v.Message != "" {
+				item += "@" + v.Message
+			}
+			items = append(items, item)
+		}
+		err = errors.Join(err, list("valid", items, "|;"))
+	}
+	if o.DisableMigration {
+		parts = append(parts, "disable_migration")
+	}
+	switch o.Visibility {
+	case mSchema.VisibilityHidden:
+		parts = append(parts, "hidden")
+	case mSchema.VisibilityMasked:
+		err = errors.Join(err, add("mask", o.Mask))
+	}
+	if len(o.VisibleRoles) > 0 {
+		err = errors.Join(err, list("visible_roles", o.VisibleRoles, "|;"))
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// goMetaTag 生成 schema.Meta 的 schema tag，记录模型名、表名与选项
+func goMetaTag(m *Schema) (string, error) {
+	d := m.define
+	parts := []string{"name:" + m.GetAlias(), "table:" + d.Table.Name}
+	if d.Table.Comment != "" {
+		parts = append(parts, "comment:"+d.Table.Comment)
+	}
+	for _, kv := range []struct {
+		key string
+		val *bool
+	}{
+		{"timestamps", d.Options.Timestamps},
+		{"soft_deletes", d.Options.SoftDeletes},
+		{"soft_delete_is_time", d.Options.SoftDeleteIsTime},
+		{"crypt_id", d.Options.CryptID},
+		{"disabled_migrator", d.Options.DisabledMigrator},
+		{"audit", d.Options.Audit},
+		{"outbox", d.Options.Outbox},
+	} {
+		if kv.val != nil {
+			parts = append(parts, kv.key+":"+strconv.FormatBool(*kv.val))
+		}
+	}
+	if d.Options.Salt != "" {
+		parts = append(parts, "crypt_salt:"+d.Options.Salt)
+	}
+	if d.Options.CryptLen > 0 {
+		parts = append(parts, "crypt_len:"+strconv.Itoa(d.Options.CryptLen))
+	}
+	if len(d.Options.LowFields) > 0 {
+		parts = append(parts, "low_fields:"+strings.Join(d.Options.LowFields, "|"))
+	}
+	if len(d.Options.FieldsSort) > 0 {
+		parts = append(parts, "fields_sort:"+strings.Join(d.Options.FieldsSort, "|"))
+	}
+	if d.Options.Tenancy != "" {
+		parts = append(parts, "tenancy:"+string(d.Options.Tenancy))
+	}
+	if d.Options.TimeFormat != "" {
+		parts = append(parts, "time_format:"+d.Options.TimeFormat)
+	}
+	if pk := d.Options.PrimaryKey; pk != nil {
+		if len(pk.Fields) > 0 {
+			parts = append(parts, "composite_key:"+strings.Join(pk.Fields, "|"))
+		} else if pk.Type != "" {
+			parts = append(parts, "primary_key:"+string(pk.Type))
+		}
+	}
+
+	for _, p := range parts {
+		if strings.ContainsAny(p, ",\"`") {
+			return "", errors.New("schema option " + strconv.Quote(p) + " cannot be represented in a struct tag")
+		}
+	}
+	return strings.Join(parts, ","), nil
+}
+
+var goInitialisms = map[string]bool{
+	"id": true, "ip": true, "url": true, "uri": true, "uuid": true, "ulid": true,
+	"api": true, "json": true, "http": true, "sql": true, "html": true, "xml": true,
+}
+
+// goIdent 将模型别名或字段名转换为导出的 Go 标识符，常见缩写保持大写
+func goIdent(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	var b strings.Builder
+	for _, w := range words {
+		if goInitialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		b.WriteString(zstring.Ucfirst(w))
+	}
+	ident := b.String()
+	if ident == "" || ident[0] >= '0' && ident[0] <= '9' {
+		ident = "X" + ident
+	}
+	return ident
+}
+
+// genFieldNames 按主键、定义字段（先 FieldsSort 后字典序）、内置字段的顺序返回字段，不含盲索引列与软删除列
+func (m *Schema) genFieldNames() []string {
+	blindIndexes := make([]string, 0, len(m.blindIndexes))
+	for _, column := range m.blindIndexes {
+		blindIndexes = append(blindIndexes, column)
+	}
+
+	names := make([]string, 0, len(m.define.Fields))
+	for name := range m.define.Fields {
+		if !zarray.Contains(blindIndexes, name) {
+			names = append(names, name)
+		}
+	}
+	sortIndex := func(name string) int {
+		if i := slices.Index(m.define.Options.FieldsSort, name); i >= 0 {
+			return i
+		}
+		return len(m.define.Options.FieldsSort)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := sortIndex(names[i]), sortIndex(names[j])
+		if a != b {
+			return a < b
+		}
+		return names[i] < names[j]
+	})
+
+	if !m.IsCompositeKey() {
+		names = append([]string{idKey}, names...)
+	}
+	for _, name := range m.inlayFields {
+		if name != idKey && name != DeletedAtKey {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,170 @@
+package model
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+// generatedStructs 解析生成代码中的结构体字段
+func generatedStructs(t *testing.T, src []byte) map[string][]*ast.Field {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "gen.go", src, 0)
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, src)
+	}
+	structs := make(map[string][]*ast.Field)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ts, ok := n.(*ast.TypeSpec); ok {
+			if st, ok := ts.Type.(*ast.StructType); ok {
+				structs[ts.Name.Name] = st.Fields.List
+			}
+		}
+		return true
+	})
+	return structs
+}
+
+func TestGenerateGo(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	timestamps := true
+	articles := schema.Schema{
+		Name:    "gen_articles",
+		Table:   schema.Table{Name: "gen_articles", Comment: "文章"},
+		Options: schema.Options{Timestamps: &timestamps},
+		Fields: map[string]schema.Field{
+			"title": {Type: schema.String, Size: 80, Label: "标题", Validations: []schema.Validations{
+				{Method: "minLength", Args: 2, Message: "标题太短"},
+			}},
+			"author_id": {Type: schema.Int, Index: true},
+			"summary":   {Type: schema.Text, Nullable: true},
+			"status": {Type: schema.Int8, Default: 1, Options: schema.FieldOption{
+				Enum: []schema.FieldEnum{{Value: "1", Label: "草稿"}, {Value: "2", Label: "发布"}},
+			}},
+			"tags":         {Type: schema.JSON, Nullable: true, Options: schema.FieldOption{IsArray: true}},
+			"price":        {Type: schema.Decimal, Size: 8, Scale: 2},
+			"code":         {Type: schema.String, Size: 10, Unique: true, Options: schema.FieldOption{ReadOnly: true}},
+			"published_at": {Type: schema.Time, Nullable: true},
+		},
+	}
+	_, ss := newTestSchemas(t, articles)
+
+	src, err := ss.GenerateGo(GoGenOptions{Package: "blog", Names: []string{"gen_articles"}})
+	tt.NoError(err)
+	code := string(src)
+	tt.Equal(true, strings.HasPrefix(code, "// Code generated by modelgen. DO NOT EDIT."))
+	tt.Equal(true, strings.Contains(code, "package blog"))
+	tt.Equal(true, strings.Contains(code, "type GenArticlesRepository = model.Repository[GenArticles, GenArticlesFilter, GenArticlesCreate, GenArticlesUpdate]"))
+	tt.Equal(true, strings.Contains(code, "func NewGenArticlesRepository(store *model.Store) *GenArticlesRepository"))
+
+	structs := generatedStructs(t, src)
+	names := func(fields []*ast.Field) []string {
+		out := make([]string, 0, len(fields))
+		for _, f := range fields {
+			out = append(out, f.Names[0].Name)
+		}
+		return out
+	}
+	tt.Equal([]string{"_", "ID", "AuthorID", "Code", "Price", "PublishedAt", "Status", "Summary", "Tags", "Title", "CreatedAt", "UpdatedAt"}, names(structs["GenArticles"]))
+	tt.Equal([]string{"AuthorID", "Code", "Price", "PublishedAt", "Status", "Summary", "Tags", "Title"}, names(structs["GenArticlesCreate"]))
+	tt.Equal([]string{"AuthorID", "Price", "PublishedAt", "Status", "Summary", "Tags", "Title"}, names(structs["GenArticlesUpdate"]))
+	tt.Equal([]string{"ID", "AuthorID", "Code", "Price", "PublishedAt", "Status", "Summary", "Title", "CreatedAt", "UpdatedAt"}, names(structs["GenArticlesFilter"]))
+
+	// field tag 经 schema/reflect.go 解析后与原定义一致
+	fields := make([]reflect.StructField, 0)
+	for _, f := range structs["GenArticles"] {
+		tag, _ := strconv.Unquote(f.Tag.Value)
+		if f.Names[0].Name == "_" || reflect.StructTag(tag).Get("field") == "-" {
+			continue
+		}
+		fields = append(fields, reflect.StructField{Name: f.Names[0].Name, Type: reflect.TypeOf(""), Tag: reflect.StructTag(tag)})
+	}
+	parsed := schema.NewFromStructType("gen_articles", reflect.StructOf(fields)).Fields
+	m := ss.MustGet("gen_articles")
+	tt.Equal(len(m.define.Fields), len(parsed))
+	for name, want := range m.define.Fields {
+		got, ok := parsed[name]
+		tt.Equal(true, ok)
+		tt.Equal(want.Type, got.Type)
+		tt.Equal(want.Size, got.Size)
+		tt.Equal(want.Scale, got.Scale)
+		tt.Equal(want.Nullable, got.Nullable)
+		tt.Equal(want.Label, got.Label)
+		tt.Equal(want.Unique, got.Unique)
+		tt.Equal(want.Index, got.Index)
+		tt.Equal(want.Options.ReadOnly, got.Options.ReadOnly)
+		tt.Equal(want.Options.IsArray, got.Options.IsArray)
+		tt.Equal(want.Options.Enum, got.Options.Enum)
+		tt.Equal(len(want.Validations), len(got.Validations))
+		for i := range want.Validations {
+			tt.Equal(want.Validations[i].Method, got.Validations[i].Method)
+			tt.Equal(ztype.ToString(want.Validations[i].Args), ztype.ToString(got.Validations[i].Args))
+			tt.Equal(want.Validations[i].Message, got.Validations[i].Message)
+		}
+		if want.Default != nil {
+			tt.Equal(ztype.ToString(want.Default), ztype.ToString(got.Default))
+		}
+	}
+
+	bad := schema.Schema{
+		Name:   "gen_bad",
+		Fields: map[string]schema.Field{"title": {Type: schema.String, Label: "a,b"}},
+	}
+	_, ss = newTestSchemas(t, bad)
+	_, err = ss.GenerateGo(GoGenOptions{Names: []string{"gen_bad"}})
+	tt.Equal(true, err != nil)
+	_, err = ss.GenerateGo(GoGenOptions{Names: []string{"missing"}})
+	tt.Equal(true, err != nil)
+}
+
+func TestGenerateGoFromDir(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	dir := t.TempDir()
+	writeSchemaFile(t, dir, "user.yaml", `
+name: user
+table: {comment: 用户}
+options: {crypt_id: true, soft_deletes: true}
+fields:
+  email: {type: string, size: 100, unique: true}
+  nickname: {type: string, size: 20, nullable: true}
+  avatar_url: {type: string, nullable: true}
+`)
+
+	src, err := GenerateGoFromDir(dir, GoGenOptions{SchemaOptions: SchemaOptions{Timestamps: true}})
+	tt.NoError(err)
+	code := string(src)
+	tt.Equal(true, strings.Contains(code, "package models"))
+	tt.Equal(true, strings.Contains(code, `schema:"name:user,table:user,comment:用户,timestamps:true,soft_deletes:true`))
+
+	structs := generatedStructs(t, src)
+	typeOf := func(st, field string) string {
+		for _, f := range structs[st] {
+			if f.Names[0].Name == field {
+				var b strings.Builder
+				ast.Fprint(&b, token.NewFileSet(), f.Type, nil)
+				return b.String()
+			}
+		}
+		return ""
+	}
+	tt.Equal(true, strings.Contains(typeOf("User", "ID"), `Name: "string"`))
+	tt.Equal(true, strings.Contains(typeOf("User", "AvatarURL"), "StarExpr"))
+	tt.Equal(true, strings.Contains(typeOf("User", "CreatedAt"), `Name: "string"`))
+	tt.Equal(true, strings.Contains(typeOf("UserCreate", "Email"), `Name: "string"`))
+	tt.Equal(false, strings.Contains(typeOf("UserCreate", "Email"), "StarExpr"))
+	tt.Equal("", typeOf("User", "DeletedAt"))
+	tt.Equal("", typeOf("UserCreate", "CreatedAt"))
+
+	_, err = GenerateGoFromDir(filepath.Join(dir, "missing"), GoGenOptions{})
+	tt.Equal(true, err != nil)
+}
@@ -0,0 +1,233 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sohaha/zlsgo/zarray"
+	mSchema "github.com/zlsgo/app_module/model/schema"
+	"github.com/zlsgo/zdb/schema"
+)
+
+// TSTypeName 模型在生成的 TypeScript 中的类型名前缀
+func TSTypeName(alias string) string {
+	return goIdent(alias)
+}
+
+// GenerateTS 为已注册模型生成 TypeScript 类型：枚举联合类型、Read/Create/Update 接口与关联类型，names 为空时生成全部
+func (ss *Schemas) GenerateTS(names ...string) ([]byte, error) {
+	if len(names) == 0 {
+		ss.ForEach(func(key string, _ *Schema) bool {
+			names = append(names, key)
+			return true
+		})
+	}
+	names = zarray.Unique(names)
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by modelgen. DO NOT EDIT.\n")
+	for _, name := range names {
+		m, ok := ss.Get(name)
+		if !ok {
+			return nil, errors.New("schema " + name + " not found")
+		}
+		tsSchema(&b, m, names)
+	}
+	return b.Bytes(), nil
+}
+
+func tsSchema(b *bytes.Buffer, m *Schema, generated []string) {
+	name := TSTypeName(m.GetAlias())
+	fields := m.genFieldNames()
+
+	enums := make(map[string]string)
+	for _, field := range fields {
+		f, ok := m.getField(field)
+		if !ok || len(f.Options.Enum) == 0 {
+			continue
+		}
+		typ := name + TSTypeName(field)
+		enums[field] = typ
+
+		values := make([]string, 0, len(f.Options.Enum))
+		labels := make([]string, 0, len(f.Options.Enum))
+		for _, e := range f.Options.Enum {
+			values = append(values, tsEnumLiteral(f, e.Value))
+			labels = append(labels, e.Value+" "+e.Label)
+		}
+		label := f.Label
+		if label == "" {
+			label = field
+		}
+		writef(b, "\n/** $1：$2 */\nexport type $3 = $4;\n", label, strings.Join(labels, "，"), typ, strings.Join(values, " | "))
+	}
+
+	comment := strings.Join(strings.Fields(m.GetComment()), " ")
+	for _, variant := range jsonSchemaVariants {
+		s := m.JSONSchemaOf("", variant)
+		props := s.Get("properties").Map()
+		required, _ := s.Get("required").Value().([]string)
+
+		if comment != "" {
+			writef(b, "\n/** $1 */", comment)
+		}
+		writef(b, "\nexport interface $1$2 {\n", name, TSTypeName(string(variant)))
+		for _, field := range fields {
+			if !props.Has(field) {
+				continue
+			}
+			f, _ := m.getField(field)
+			typ := tsFieldType(m, field, f, enums[field])
+			if variant == JSONSchemaRead && m.FieldVisibility(field) == mSchema.VisibilityMasked {
+				typ = "string"
+			}
+
+			optional := ""
+			switch {
+			case variant == JSONSchemaUpdate:
+				optional = "?"
+			case variant == JSONSchemaCreate && (f.Default != nil || !zarray.Contains(required, field)):
+				optional = "?"
+			}
+			if f.Nullable {
+				typ += " | null"
+			}
+			if f.Label != "" && f.Label != field {
+				writef(b, "  /** $1 */\n", f.Label)
+			}
+			writef(b, "  $1$2: $3;\n", tsPropName(field), optional, typ)
+		}
+		b.WriteString("}\n")
+	}
+
+	relations := make([]string, 0, len(m.define.Relations))
+	for key := range m.define.Relations {
+		relations = append(relations, key)
+	}
+	sort.Strings(relations)
+
+	if len(relations) == 0 {
+		writef(b, "\nexport type $1RelationName = never;\n", name)
+	} else {
+		literals := make([]string, 0, len(relations))
+		for _, key := range relations {
+			literals = append(literals, strconv.Quote(key))
+		}
+		writef(b, "\nexport type $1RelationName = $2;\n", name, strings.Join(literals, " | "))
+	}
+
+	// single_merge 关联的字段直接合并到主记录中，不单独列出
+	writef(b, "\nexport interface $1Relations {\n", name)
+	for _, key := range relations {
+		rel := m.define.Relations[key]
+		if rel.Type == mSchema.RelationSingleMerge {
+			continue
+		}
+
+		// 关联模型未参与生成时退化为普通对象
+		typ := "Record<string, unknown>"
+		var related *Schema
+		if m.getSchema != nil && zarray.Contains(generated, rel.Schema) {
+			related, _ = m.getSchema(rel.Schema)
+		}
+		if related != nil {
+			typ = TSTypeName(related.GetAlias()) + "Read"
+			if picked := tsPickFields(related, rel.Fields); picked != "" {
+				typ = "Pick<" + typ + ", " + picked + ">"
+			}
+		}
+		switch rel.Type {
+		case mSchema.RelationMany, mSchema.RelationManyToMany:
+			typ += "[]"
+		default:
+			// 未匹配到关联数据时非空关联返回空对象
+			typ = "Partial<" + typ + ">"
+		}
+		if rel.Nullable {
+			typ += " | null"
+		}
+		if rel.Label != "" && rel.Label != key {
+			writef(b, "  /** $1 */\n", rel.Label)
+		}
+		writef(b, "  $1: $2;\n", tsPropName(key), typ)
+	}
+	b.WriteString("}\n")
+}
+
+// tsFieldType 字段对应的 TypeScript 类型，不含 null
+func tsFieldType(m *Schema, name string, f *mSchema.Field, enum string) string {
+	if name == idKey && *m.define.Options.CryptID {
+		return "string"
+	}
+	if enum != "" {
+		if f.Options.IsArray {
+			return enum + "[]"
+		}
+		return enum
+	}
+
+	switch f.Type {
+	case schema.Bool:
+		return "boolean"
+	case schema.Int, schema.Int8, schema.Int16, schema.Int32, schema.Int64,
+		schema.Uint, schema.Uint8, schema.Uint16, schema.Uint32, schema.Uint64, schema.Float:
+		return "number"
+	case schema.JSON:
+		if f.Options.IsArray {
+			return "unknown[]"
+		}
+		return "Record<string, unknown>"
+	default:
+		// 字符串、定点数、时间与 base64 编码的字节
+		return "string"
+	}
+}
+
+// tsEnumLiteral 枚举值字面量，整数字段输出数字
+func tsEnumLiteral(f *mSchema.Field, value string) string {
+	switch f.Type {
+	case schema.Int, schema.Int8, schema.Int16, schema.Int32, schema.Int64,
+		schema.Uint, schema.Uint8, schema.Uint16, schema.Uint32, schema.Uint64, schema.Float:
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return value
+		}
+	case schema.Bool:
+		if value == "true" || value == "false" {
+			return value
+		}
+	}
+	return strconv.Quote(value)
+}
+
+// tsPickFields 关联限定字段在关联模型读取类型中存在时返回 Pick 的键
+func tsPickFields(related *Schema, fields []string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	props := related.JSONSchemaOf("", JSONSchemaRead).Get("properties").Map()
+	keys := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if props.Has(field) {
+			keys = append(keys, strconv.Quote(field))
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	return strings.Join(keys, " | ")
+}
+
+// tsPropName 非标识符的属性名加引号
+func tsPropName(name string) string {
+	for i, r := range name {
+		if r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9' {
+			continue
+		}
+		return strconv.Quote(name)
+	}
+	return name
+}
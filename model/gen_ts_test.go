@@ -0,0 +1,76 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func TestGenerateTS(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	authors := schema.Schema{
+		Name:   "ts_authors",
+		Fields: map[string]schema.Field{"name": {Type: schema.String, Size: 20, Label: "姓名"}},
+	}
+	articles := schema.Schema{
+		Name:  "ts_articles",
+		Table: schema.Table{Comment: "文章"},
+		Fields: map[string]schema.Field{
+			"title":     {Type: schema.String, Size: 80},
+			"author_id": {Type: schema.Int},
+			"summary":   {Type: schema.Text, Nullable: true},
+			"status": {Type: schema.Int8, Default: 1, Options: schema.FieldOption{
+				Enum: []schema.FieldEnum{{Value: "1", Label: "草稿"}, {Value: "2", Label: "发布"}},
+			}},
+			"code": {Type: schema.String, Size: 10, Options: schema.FieldOption{ReadOnly: true}},
+			"tags": {Type: schema.JSON, Nullable: true, Options: schema.FieldOption{IsArray: true}},
+		},
+		Relations: map[string]schema.Relation{
+			"author": {
+				Type:       schema.RelationSingle,
+				Schema:     "ts_authors",
+				ForeignKey: []string{"author_id"},
+				SchemaKey:  []string{IDKey()},
+				Fields:     []string{"name"},
+			},
+			"comments": {
+				Type:       schema.RelationMany,
+				Schema:     "ts_comments",
+				ForeignKey: []string{IDKey()},
+				SchemaKey:  []string{"article_id"},
+				Nullable:   true,
+			},
+		},
+	}
+	_, ss := newTestSchemas(t, authors, articles)
+
+	src, err := ss.GenerateTS()
+	tt.NoError(err)
+	code := string(src)
+	tt.Equal(true, strings.HasPrefix(code, "// Code generated by modelgen. DO NOT EDIT."))
+	tt.Equal(true, strings.Contains(code, "export type TsArticlesStatus = 1 | 2;"))
+	tt.Equal(true, strings.Contains(code, "/** 文章 */\nexport interface TsArticlesRead {"))
+	tt.Equal(true, strings.Contains(code, "  status: TsArticlesStatus;"))
+	tt.Equal(true, strings.Contains(code, "  summary: string | null;"))
+	tt.Equal(true, strings.Contains(code, "  tags?: unknown[] | null;"))
+	tt.Equal(true, strings.Contains(code, "  /** 姓名 */\n  name: string;"))
+	tt.Equal(true, strings.Contains(code, `export type TsArticlesRelationName = "author" | "comments";`))
+	tt.Equal(true, strings.Contains(code, `  author: Partial<Pick<TsAuthorsRead, "name">>;`))
+	tt.Equal(true, strings.Contains(code, "  comments: Record<string, unknown>[] | null;"))
+
+	create := code[strings.Index(code, "export interface TsArticlesCreate {"):]
+	create = create[:strings.Index(create, "}")]
+	tt.Equal(true, strings.Contains(create, "  title: string;"))
+	tt.Equal(true, strings.Contains(create, "  status?: TsArticlesStatus;"))
+
+	update := code[strings.Index(code, "export interface TsArticlesUpdate {"):]
+	update = update[:strings.Index(update, "}")]
+	tt.Equal(true, strings.Contains(update, "  title?: string;"))
+	tt.Equal(false, strings.Contains(update, "code"))
+
+	_, err = ss.GenerateTS("missing")
+	tt.Equal(true, err != nil)
+}
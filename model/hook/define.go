@@ -19,4 +19,22 @@ const (
 	// Delete events
 	EventBeforeDelete Event = "BeforeDelete"
 	EventAfterDelete  Event = "AfterDelete"
+
+	// Restore events
+	EventBeforeRestore Event = "BeforeRestore"
+	EventAfterRestore  Event = "AfterRestore"
+
+	// Find events
+	EventBeforeFind Event = "BeforeFind"
+	EventAfterFind  Event = "AfterFind"
+
+	// Commit events
+	EventAfterCommit Event = "AfterCommit"
+
+	// Audit events
+	EventBeforeAudit Event = "BeforeAudit"
+
+	// Schema reload events
+	EventSchemaReload       Event = "SchemaReload"
+	EventSchemaReloadFailed Event = "SchemaReloadFailed"
 )
@@ -0,0 +1,329 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/hook"
+)
+
+type (
+	// HookFunc 钩子处理函数
+	HookFunc func(ctx context.Context, data ...any) error
+
+	// Hooks 钩子注册表，同一事件可注册多个处理函数
+	Hooks struct {
+		handlers map[hook.Event][]hookHandler
+		mu       sync.RWMutex
+	}
+
+	hookHandler struct {
+		fn       HookFunc
+		priority int
+	}
+)
+
+var globalHooks = NewHooks()
+
+// NewHooks 创建钩子注册表
+func NewHooks() *Hooks {
+	return &Hooks{handlers: make(map[hook.Event][]hookHandler)}
+}
+
+// GlobalHooks 全局钩子，作用于所有模型
+func GlobalHooks() *Hooks {
+	return globalHooks
+}
+
+// On 注册事件处理函数，priority 越小越先执行，默认为 0
+func (h *Hooks) On(event hook.Event, fn HookFunc, priority ...int) *Hooks {
+	if fn == nil {
+		return h
+	}
+
+	p := 0
+	if len(priority) > 0 {
+		p = priority[0]
+	}
+
+	h.mu.Lock()
+	h.handlers[event] = append(h.handlers[event], hookHandler{fn: fn, priority: p})
+	h.mu.Unlock()
+	return h
+}
+
+// Off 移除事件的所有处理函数
+func (h *Hooks) Off(event hook.Event) *Hooks {
+	h.mu.Lock()
+	delete(h.handlers, event)
+	h.mu.Unlock()
+	return h
+}
+
+// OnBeforeInsert 插入前，可修改或替换待插入数据，批量插入时逐条触发
+func (h *Hooks) OnBeforeInsert(fn func(ctx context.Context, data *ztype.Map) error, priority ...int) *Hooks {
+	return h.On(hook.EventBeforeInsert, func(ctx context.Context, data ...any) error {
+		if len(data) == 0 {
+			return nil
+		}
+		switch v := data[0].(type) {
+		case ztype.Map:
+			return callMapHook(ctx, v, fn)
+		case ztype.Maps:
+			for i := range v {
+				if err := callMapHook(ctx, v[i], fn); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}, priority...)
+}
+
+// OnAfterInsert 插入后，批量插入时逐条触发
+func (h *Hooks) OnAfterInsert(fn func(ctx context.Context, id any, data ztype.Map) error, priority ...int) *Hooks {
+	return h.On(hook.EventAfterInsert, func(ctx context.Context, data ...any) error {
+		if len(data) < 2 {
+			return nil
+		}
+		switch v := data[1].(type) {
+		case ztype.Map:
+			return fn(ctx, data[0], v)
+		case ztype.Maps:
+			ids, _ := data[0].([]interface{})
+			for i := range v {
+				var id any
+				if i < len(ids) {
+					id = ids[i]
+				}
+				if err := fn(ctx, id, v[i]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}, priority...)
+}
+
+// OnBeforeUpdate 更新前，可修改条件或替换更新数据
+func (h *Hooks) OnBeforeUpdate(fn func(ctx context.Context, filter ztype.Map, data *ztype.Map) error, priority ...int) *Hooks {
+	return h.On(hook.EventBeforeUpdate, func(ctx context.Context, data ...any) error {
+		if len(data) < 2 {
+			return nil
+		}
+		filter, _ := data[0].(ztype.Map)
+		values, _ := data[1].(ztype.Map)
+		return callMapHook(ctx, values, func(ctx context.Context, values *ztype.Map) error {
+			return fn(ctx, filter, values)
+		})
+	}, priority...)
+}
+
+// OnAfterUpdate 更新后
+func (h *Hooks) OnAfterUpdate(fn func(ctx context.Context, filter, data ztype.Map, total int64) error, priority ...int) *Hooks {
+	return h.On(hook.EventAfterUpdate, func(ctx context.Context, data ...any) error {
+		if len(data) < 3 {
+			return nil
+		}
+		filter, _ := data[0].(ztype.Map)
+		values, _ := data[1].(ztype.Map)
+		total, _ := data[2].(int64)
+		return fn(ctx, filter, values, total)
+	}, priority...)
+}
+
+// OnBeforeDelete 删除前
+func (h *Hooks) OnBeforeDelete(fn func(ctx context.Context, filter ztype.Map) error, priority ...int) *Hooks {
+	return h.On(hook.EventBeforeDelete, filterHook(fn), priority...)
+}
+
+// OnAfterDelete 删除后
+func (h *Hooks) OnAfterDelete(fn func(ctx context.Context, filter ztype.Map, total int64) error, priority ...int) *Hooks {
+	return h.On(hook.EventAfterDelete, filterTotalHook(fn), priority...)
+}
+
+// OnBeforeRestore 恢复软删除记录前
+func (h *Hooks) OnBeforeRestore(fn func(ctx context.Context, filter ztype.Map) error, priority ...int) *Hooks {
+	return h.On(hook.EventBeforeRestore, filterHook(fn), priority...)
+}
+
+// OnAfterRestore 恢复软删除记录后
+func (h *Hooks) OnAfterRestore(fn func(ctx context.Context, filter ztype.Map, total int64) error, priority ...int) *Hooks {
+	return h.On(hook.EventAfterRestore, filterTotalHook(fn), priority...)
+}
+
+// OnBeforeFind 查询前，可修改查询条件
+func (h *Hooks) OnBeforeFind(fn func(ctx context.Context, filter ztype.Map) error, priority ...int) *Hooks {
+	return h.On(hook.EventBeforeFind, filterHook(fn), priority...)
+}
+
+// OnAfterFind 查询后，可修改或替换查询结果
+func (h *Hooks) OnAfterFind(fn func(ctx context.Context, rows *ztype.Maps) error, priority ...int) *Hooks {
+	return h.On(hook.EventAfterFind, func(ctx context.Context, data ...any) error {
+		if len(data) == 0 {
+			return nil
+		}
+		rows, ok := data[0].(*ztype.Maps)
+		if !ok || rows == nil {
+			return nil
+		}
+		return fn(ctx, rows)
+	}, priority...)
+}
+
+// OnAfterCommit 数据变更提交后，event 为对应的 After 事件；事务中的变更在事务提交后触发
+func (h *Hooks) OnAfterCommit(fn func(ctx context.Context, event hook.Event, data ...any) error, priority ...int) *Hooks {
+	return h.On(hook.EventAfterCommit, func(ctx context.Context, data ...any) error {
+		if len(data) == 0 {
+			return nil
+		}
+		event, _ := data[0].(hook.Event)
+		return fn(ctx, event, data[1:]...)
+	}, priority...)
+}
+
+// OnSchemaReload 模型目录热加载后，name 为重新注册的模型，path 为其定义文件
+func (h *Hooks) OnSchemaReload(fn func(ctx context.Context, name, path string) error, priority ...int) *Hooks {
+	return h.On(hook.EventSchemaReload, func(ctx context.Context, data ...any) error {
+		if len(data) < 2 {
+			return nil
+		}
+		name, _ := data[0].(string)
+		path, _ := data[1].(string)
+		return fn(ctx, name, path)
+	}, priority...)
+}
+
+// OnSchemaReloadFailed 模型目录热加载失败，path 为出错的目录或定义文件，原定义继续生效
+func (h *Hooks) OnSchemaReloadFailed(fn func(ctx context.Context, path string, err error) error, priority ...int) *Hooks {
+	return h.On(hook.EventSchemaReloadFailed, func(ctx context.Context, data ...any) error {
+		if len(data) < 2 {
+			return nil
+		}
+		path, _ := data[0].(string)
+		err, _ := data[1].(error)
+		return fn(ctx, path, err)
+	}, priority...)
+}
+
+func (h *Hooks) get(event hook.Event) []hookHandler {
+	if h == nil {
+		return nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.handlers[event]
+}
+
+func filterHook(fn func(ctx context.Context, filter ztype.Map) error) HookFunc {
+	return func(ctx context.Context, data ...any) error {
+		if len(data) == 0 {
+			return nil
+		}
+		filter, _ := data[0].(ztype.Map)
+		return fn(ctx, filter)
+	}
+}
+
+func filterTotalHook(fn func(ctx context.Context, filter ztype.Map, total int64) error) HookFunc {
+	return func(ctx context.Context, data ...any) error {
+		if len(data) < 2 {
+			return nil
+		}
+		filter, _ := data[0].(ztype.Map)
+		total, _ := data[1].(int64)
+		return fn(ctx, filter, total)
+	}
+}
+
+// callMapHook 调用可替换数据的钩子，替换后的内容会回写到原数据中
+func callMapHook(ctx context.Context, data ztype.Map, fn func(ctx context.Context, data *ztype.Map) error) error {
+	replaced := data
+	if err := fn(ctx, &replaced); err != nil {
+		return err
+	}
+	if data == nil || reflect.ValueOf(replaced).Pointer() == reflect.ValueOf(data).Pointer() {
+		return nil
+	}
+	for k := range data {
+		delete(data, k)
+	}
+	for k, v := range replaced {
+		data[k] = v
+	}
+	return nil
+}
+
+type hookSchemaKey struct{}
+
+// HookSchema 获取触发钩子的模型，用于全局或集合钩子区分模型
+func HookSchema(ctx context.Context) (*Schema, bool) {
+	s, ok := ctx.Value(hookSchemaKey{}).(*Schema)
+	return s, ok
+}
+
+// Hooks 当前模型的钩子
+func (m *Schema) Hooks() *Hooks {
+	if m.hooks == nil {
+		m.hooks = NewHooks()
+	}
+	return m.hooks
+}
+
+// Hooks 模型集合的钩子，作用于集合内所有模型
+func (ss *Schemas) Hooks() *Hooks {
+	return ss.hooks
+}
+
+// hook 触发事件，依次执行 Options.Hook 与按优先级排序的全局、集合及模型钩子
+func (m *Schema) hook(name hook.Event, data ...any) error {
+	if m.define.Options.Hook != nil {
+		if err := m.define.Options.Hook(name, data...); err != nil {
+			return hookError(name, err)
+		}
+	}
+
+	if strings.HasPrefix(m.GetAlias(), "__") {
+		return nil
+	}
+
+	var handlers []hookHandler
+	for _, h := range []*Hooks{globalHooks, m.schemasHooks, m.hooks} {
+		handlers = append(handlers, h.get(name)...)
+	}
+	if len(handlers) == 0 {
+		return nil
+	}
+	sort.SliceStable(handlers, func(i, j int) bool {
+		return handlers[i].priority < handlers[j].priority
+	})
+
+	ctx := context.WithValue(m.Context(), hookSchemaKey{}, m)
+	for i := range handlers {
+		if err := handlers[i].fn(ctx, data...); err != nil {
+			return hookError(name, err)
+		}
+	}
+	return nil
+}
+
+// hookError 取消操作时包装为 HookError，其余错误原样返回
+func hookError(name hook.Event, err error) error {
+	if errors.Is(err, ErrHookCancelled) {
+		return NewHookError(string(name), err)
+	}
+	return err
+}
+
+// afterHook 触发 After 事件，并在数据提交后触发 AfterCommit
+func (m *Schema) afterHook(name hook.Event, data ...any) {
+	_ = m.hook(name, data...)
+	m.afterCommit(func() {
+		_ = m.hook(hook.EventAfterCommit, append([]any{name}, data...)...)
+	})
+}
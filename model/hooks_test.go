@@ -0,0 +1,180 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/hook"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func newHooksTestSchema(t *testing.T) (*Schemas, *Schema) {
+	b := true
+	s := schema.Schema{
+		Name:  "hooks_users",
+		Table: schema.Table{Name: "hooks_users"},
+		Options: schema.Options{
+			SoftDeletes: &b,
+		},
+		Fields: map[string]schema.Field{
+			"name": {Type: "string", Label: "Name"},
+			"age":  {Type: "int", Label: "Age", Default: "0"},
+		},
+	}
+	_, schemas := newTestSchemas(t, s)
+	return schemas, schemas.MustGet("hooks_users")
+}
+
+func TestHooksPriority(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	schemas, m := newHooksTestSchema(t)
+
+	var order []string
+	m.Hooks().OnBeforeInsert(func(ctx context.Context, data *ztype.Map) error {
+		order = append(order, "schema")
+		replaced := make(ztype.Map, len(*data))
+		for k, v := range *data {
+			replaced[k] = v
+		}
+		replaced["name"] = "replaced"
+		*data = replaced
+		return nil
+	}, 10)
+	schemas.Hooks().OnBeforeInsert(func(ctx context.Context, data *ztype.Map) error {
+		s, ok := HookSchema(ctx)
+		tt.Equal(true, ok)
+		tt.Equal("hooks_users", s.GetAlias())
+		order = append(order, "schemas")
+		return nil
+	}, -1)
+	m.Hooks().OnAfterInsert(func(ctx context.Context, id any, data ztype.Map) error {
+		order = append(order, "after")
+		return nil
+	})
+
+	id, err := m.Model().Insert(ztype.Map{"name": "a", "age": 1})
+	tt.NoError(err)
+	tt.Equal([]string{"schemas", "schema", "after"}, order)
+
+	row, err := m.Model().FindOneByID(id)
+	tt.NoError(err)
+	tt.Equal("replaced", row.Get("name").String())
+	tt.Equal(1, row.Get("age").Int())
+}
+
+func TestHooksFind(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	_, m := newHooksTestSchema(t)
+
+	store := m.Model()
+	_, err := store.InsertMany(ztype.Maps{{"name": "a", "age": 1}, {"name": "b", "age": 2}})
+	tt.NoError(err)
+
+	m.Hooks().OnBeforeFind(func(ctx context.Context, filter ztype.Map) error {
+		filter["age >"] = 1
+		return nil
+	})
+	m.Hooks().OnAfterFind(func(ctx context.Context, rows *ztype.Maps) error {
+		for i := range *rows {
+			(*rows)[i]["checked"] = true
+		}
+		return nil
+	})
+
+	rows, err := store.Find(Filter{})
+	tt.NoError(err)
+	tt.Equal(1, len(rows))
+	tt.Equal("b", rows[0].Get("name").String())
+	tt.Equal(true, rows[0].Get("checked").Bool())
+
+	page, err := store.Pages(1, 10, Filter{})
+	tt.NoError(err)
+	tt.Equal(1, len(page.Items))
+	tt.Equal(true, page.Items[0].Get("checked").Bool())
+}
+
+func TestHooksCancelAndRestore(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	_, m := newHooksTestSchema(t)
+	store := m.Model()
+
+	id, err := store.Insert(ztype.Map{"name": "a"})
+	tt.NoError(err)
+
+	m.Hooks().OnBeforeDelete(func(ctx context.Context, filter ztype.Map) error {
+		return ErrHookCancelled
+	})
+	_, err = store.DeleteByID(id)
+	tt.Equal(true, errors.Is(err, ErrHookCancelled))
+	var he *HookError
+	tt.Equal(true, errors.As(err, &he))
+	tt.Equal(string(hook.EventBeforeDelete), he.Event)
+
+	count, err := store.Count(Filter{})
+	tt.NoError(err)
+	tt.Equal(uint64(1), count)
+
+	m.Hooks().Off(hook.EventBeforeDelete)
+	_, err = store.DeleteByID(id)
+	tt.NoError(err)
+
+	var restored int64
+	m.Hooks().OnAfterRestore(func(ctx context.Context, filter ztype.Map, total int64) error {
+		restored = total
+		return nil
+	})
+	total, err := store.RestoreByID(id)
+	tt.NoError(err)
+	tt.Equal(int64(1), total)
+	tt.Equal(int64(1), restored)
+
+	row, err := store.FindOneByID(id)
+	tt.NoError(err)
+	tt.Equal("a", row.Get("name").String())
+
+	_, m2 := newTestDB(t, "hooks_no_soft")
+	_, err = m2.Model().Restore(Filter{})
+	tt.Equal(ErrSoftDeleteNotSupported, err)
+}
+
+func TestHooksAfterCommit(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	_, m := newHooksTestSchema(t)
+
+	var events []hook.Event
+	m.Hooks().OnAfterCommit(func(ctx context.Context, event hook.Event, data ...any) error {
+		events = append(events, event)
+		return nil
+	})
+
+	_, err := m.Model().Insert(ztype.Map{"name": "a"})
+	tt.NoError(err)
+	tt.Equal([]hook.Event{hook.EventAfterInsert}, events)
+
+	repo := m.Model().Repository()
+	rollback := errors.New("rollback")
+	err = repo.Tx(func(txRepo *Repository[ztype.Map, QueryFilter, ztype.Map, ztype.Map]) error {
+		if _, err := txRepo.Insert(ztype.Map{"name": "b"}); err != nil {
+			return err
+		}
+		return rollback
+	})
+	tt.Equal(rollback, err)
+	tt.Equal(1, len(events))
+
+	err = repo.Tx(func(txRepo *Repository[ztype.Map, QueryFilter, ztype.Map, ztype.Map]) error {
+		if _, err := txRepo.Insert(ztype.Map{"name": "c"}); err != nil {
+			return err
+		}
+		if _, err := txRepo.UpdateMany(Filter{"name": "c"}, ztype.Map{"age": 3}); err != nil {
+			return err
+		}
+		tt.Equal(1, len(events))
+		return nil
+	})
+	tt.NoError(err)
+	tt.Equal([]hook.Event{hook.EventAfterInsert, hook.EventAfterInsert, hook.EventAfterUpdate}, events)
+}
@@ -19,6 +19,8 @@ type Schemas struct {
 	models        *Stores
 	SchemaOption  SchemaOptions
 	cacheGet      map[string]*Schema
+	hooks         *Hooks
+	keyring       *FieldKeyring
 	mu            sync.RWMutex
 }
 
@@ -30,6 +32,7 @@ func NewSchemas(di zdi.Injector, s Storageer, o SchemaOptions) *Schemas {
 		data:         zarray.NewHashMap[string, *Schema](),
 		SchemaOption: o,
 		cacheGet:     make(map[string]*Schema),
+		hooks:        NewHooks(),
 	}
 }
 
@@ -38,16 +41,8 @@ func (ss *Schemas) String() string {
 	return "[" + strings.Join(ss.data.Keys(), ", ") + "]"
 }
 
-// set 设置模型到集合中
-func (ss *Schemas) set(alias string, s *Schema, force ...bool) (err error) {
-	if s.define.Table.Name == "" {
-		tableName := strings.Replace(alias, "-", "_", -1)
-		tableName = strings.Replace(tableName, "::", "__", -1)
-		s.define.Table.Name = tableName
-	}
-
-	err = perfect(alias, s, &ss.SchemaOption)
-
+// publish 在锁内发布模型实例，替换同名的已注册模型
+func (ss *Schemas) publish(alias string, s *Schema) {
 	ss.mu.Lock()
 	ss.data.Set(alias, s)
 	ss.cacheGet[alias] = s
@@ -55,7 +50,23 @@ func (ss *Schemas) set(alias string, s *Schema, force ...bool) (err error) {
 		ss.models.items.Set(alias, s.Model())
 	}
 	ss.mu.Unlock()
-	return
+}
+
+// prepare 补全表名并解析模型定义
+func (ss *Schemas) prepare(alias string, s *Schema) error {
+	if s.define.Table.Name == "" {
+		s.define.Table.Name = schemaTableName(alias, s.define)
+	}
+
+	return perfect(alias, s, &ss.SchemaOption)
+}
+
+// schemaTableName 模型使用的表名，未设置时由别名生成
+func schemaTableName(alias string, d schema.Schema) string {
+	if d.Table.Name != "" {
+		return d.Table.Name
+	}
+	return strings.Replace(strings.Replace(alias, "-", "_", -1), "::", "__", -1)
 }
 
 // Get 获取指定别名的模型
@@ -144,40 +155,49 @@ func (ss *Schemas) ForEach(fn func(key string, m *Schema) bool) {
 	}
 }
 
-// Reg 注册模型到集合中
+// Reg 注册模型到集合中，force 覆盖已注册模型时沿用其模型钩子，注册或迁移失败则保留原模型
 func (ss *Schemas) Reg(name string, data schema.Schema, force bool) (*Schema, error) {
 	if name == "" {
 		return nil, errors.New("models name can not be empty")
 	}
 
-	if !force && ss.data.Has(name) {
+	prev, replace := ss.data.Get(name)
+	if !force && replace {
 		return nil, errors.New("models " + name + " has been registered")
 	}
 
-	var tablePrefix string
-	if ss.storage != nil {
-		if opts := ss.storage.GetOptions(); opts != nil {
-			prefixVal := opts.Get("prefix")
-			if prefixVal.Exists() {
-				tablePrefix = prefixVal.String()
-			}
-		}
-	}
-
-	m := &Schema{
-		Storage:     ss.storage,
-		define:      data,
-		di:          ss.di,
-		getSchema:   ss.Get,
-		tablePrefix: tablePrefix,
+	m := ss.newSchema(data)
+	if replace {
+		m.hooks = prev.Hooks()
 	}
 
-	err := ss.set(name, m, force)
+	// 解析与迁移均在未发布的实例上进行，全部成功后才替换，失败时并发读取始终看到原模型
+	err := ss.prepare(name, m)
 	if err != nil {
 		err = zerror.With(err, "models "+name+" register error")
 		return nil, err
 	}
 
+	if m.IsAudit() && !ss.data.Has(AuditSchemaName) {
+		if _, err = ss.Reg(AuditSchemaName, auditSchemaDefine(), false); err != nil {
+			return nil, zerror.With(err, "models "+AuditSchemaName+" register error")
+		}
+	}
+
+	if m.IsOutbox() {
+		for name, define := range map[string]func() schema.Schema{
+			OutboxSchemaName:       outboxSchemaDefine,
+			OutboxOffsetSchemaName: outboxOffsetSchemaDefine,
+		} {
+			if ss.data.Has(name) {
+				continue
+			}
+			if _, err = ss.Reg(name, define(), false); err != nil {
+				return nil, zerror.With(err, "models "+name+" register error")
+			}
+		}
+	}
+
 	if *m.GetDefine().Options.DisabledMigrator {
 		if ss.storage != nil {
 			migration := m.Migration()
@@ -190,10 +210,12 @@ func (ss *Schemas) Reg(name string, data schema.Schema, force bool) (*Schema, er
 			}
 		}
 		m.refreshFieldsSet()
+		ss.publish(name, m)
 		return m, nil
 	}
 
-	if ss.storage != nil {
+	// 前缀模式的租户表通过 MigrateTenant 按租户迁移
+	if ss.storage != nil && m.Tenancy() != schema.TenancyPrefix {
 		err = m.Migration().Auto(ss.SchemaOption.OldColumn)
 		if err != nil {
 			err = zerror.With(err, "models "+name+" migration error")
@@ -201,9 +223,55 @@ func (ss *Schemas) Reg(name string, data schema.Schema, force bool) (*Schema, er
 		}
 	}
 
+	ss.publish(name, m)
 	return m, nil
 }
 
+// newSchema 创建未注册的模型实例
+func (ss *Schemas) newSchema(data schema.Schema) *Schema {
+	var tablePrefix string
+	if ss.storage != nil {
+		if opts := ss.storage.GetOptions(); opts != nil {
+			prefixVal := opts.Get("prefix")
+			if prefixVal.Exists() {
+				tablePrefix = prefixVal.String()
+			}
+		}
+	}
+
+	return &Schema{
+		Storage:      ss.storage,
+		define:       data,
+		di:           ss.di,
+		getSchema:    ss.Get,
+		tablePrefix:  tablePrefix,
+		hooks:        NewHooks(),
+		schemasHooks: ss.hooks,
+		getKeyring:   ss.Keyring,
+	}
+}
+
+// Plan 预演以 data 注册模型 name 时的迁移语句，不注册模型也不修改数据库
+func (ss *Schemas) Plan(name string, data schema.Schema) ([]MigrationStep, error) {
+	if name == "" {
+		return nil, errors.New("models name can not be empty")
+	}
+
+	m := ss.newSchema(data)
+	if err := ss.prepare(name, m); err != nil {
+		return nil, zerror.With(err, "models "+name+" register error")
+	}
+	if ss.storage == nil || *m.define.Options.DisabledMigrator || m.Tenancy() == schema.TenancyPrefix {
+		return []MigrationStep{}, nil
+	}
+
+	planner, ok := m.Migration().(MigrationPlanner)
+	if !ok {
+		return nil, ErrMigrationPlanNotSupported
+	}
+	return planner.Plan(ss.SchemaOption.OldColumn)
+}
+
 // BatchReg 批量注册模型
 func (ss *Schemas) BatchReg(models map[string]schema.Schema, force bool) error {
 	for name, data := range models {
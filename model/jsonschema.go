@@ -0,0 +1,240 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/sohaha/zlsgo/zstring"
+	"github.com/sohaha/zlsgo/ztype"
+	mSchema "github.com/zlsgo/app_module/model/schema"
+	"github.com/zlsgo/zdb/schema"
+)
+
+// JSONSchemaDialect 生成文档使用的 JSON Schema 版本
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchemaVariant 模型 JSON Schema 的用途
+type JSONSchemaVariant string
+
+const (
+	// JSONSchemaRead 查询结果，包含主键与内置字段
+	JSONSchemaRead JSONSchemaVariant = "read"
+	// JSONSchemaCreate 新增数据，不含内置字段
+	JSONSchemaCreate JSONSchemaVariant = "create"
+	// JSONSchemaUpdate 更新数据，不含内置字段与只读字段，所有字段可选
+	JSONSchemaUpdate JSONSchemaVariant = "update"
+)
+
+var jsonSchemaVariants = []JSONSchemaVariant{JSONSchemaRead, JSONSchemaCreate, JSONSchemaUpdate}
+
+// JSONSchema 生成 JSON Schema 文档，三种用途分别位于 $defs 中，view 为空时使用全部可见字段
+func (m *Schema) JSONSchema(view string) ztype.Map {
+	defs := make(ztype.Map, len(jsonSchemaVariants))
+	for _, variant := range jsonSchemaVariants {
+		defs[string(variant)] = m.JSONSchemaOf(view, variant)
+	}
+	return ztype.Map{
+		"$schema": JSONSchemaDialect,
+		"$id":     m.GetAlias(),
+		"title":   m.GetComment(),
+		"$ref":    "#/$defs/" + string(JSONSchemaRead),
+		"$defs":   defs,
+	}
+}
+
+// OpenAPIComponents 生成 OpenAPI 3.1 components.schemas，名称为模型别名加用途后缀
+func (m *Schema) OpenAPIComponents(view string) ztype.Map {
+	name := zstring.SnakeCaseToCamelCase(strings.NewReplacer("-", "_", "::", "_", "/", "_").Replace(m.GetAlias()), true)
+	components := make(ztype.Map, len(jsonSchemaVariants))
+	for _, variant := range jsonSchemaVariants {
+		components[name+zstring.Ucfirst(string(variant))] = m.JSONSchemaOf(view, variant)
+	}
+	return components
+}
+
+// JSONSchemaOf 生成单个用途的对象结构
+func (m *Schema) JSONSchemaOf(view string, variant JSONSchemaVariant) ztype.Map {
+	var fields []string
+	if view == "" {
+		fields = m.GetFields()
+		if variant == JSONSchemaRead {
+			fields = zarray.Unique(append(m.PrimaryKeys(), fields...))
+		}
+	} else {
+		fields = m.GetViewFields(view)
+	}
+
+	blindIndexes := make([]string, 0, len(m.blindIndexes))
+	for _, column := range m.blindIndexes {
+		blindIndexes = append(blindIndexes, column)
+	}
+
+	properties := make(ztype.Map, len(fields))
+	required := make([]string, 0, len(fields))
+	for _, name := range fields {
+		f, ok := m.getField(name)
+		if !ok || name == DeletedAtKey || zarray.Contains(blindIndexes, name) {
+			continue
+		}
+
+		visibility := m.FieldVisibility(name)
+		if visibility == mSchema.VisibilityHidden && variant == JSONSchemaRead {
+			continue
+		}
+		if variant != JSONSchemaRead {
+			if m.isInlayField(name) {
+				continue
+			}
+			if variant == JSONSchemaUpdate && (f.Options.ReadOnly || zarray.Contains(m.readOnlyKeys, name)) {
+				continue
+			}
+		}
+
+		prop := m.jsonSchemaField(name, f, variant)
+		if variant == JSONSchemaRead && visibility == mSchema.VisibilityMasked {
+			// 脱敏后的值不再满足原有格式约束
+			prop = ztype.Map{"type": "string", "title": f.Label}
+		}
+		properties[name] = prop
+
+		if !f.Nullable && variant != JSONSchemaUpdate {
+			required = append(required, name)
+		}
+	}
+
+	s := ztype.Map{
+		"type":       "object",
+		"title":      m.GetComment(),
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// jsonSchemaField 字段类型、长度、枚举与校验规则转换为 JSON Schema 关键字
+func (m *Schema) jsonSchemaField(name string, f *mSchema.Field, variant JSONSchemaVariant) ztype.Map {
+	prop := ztype.Map{}
+	typ := "string"
+
+	switch f.Type {
+	case schema.Bool:
+		typ = "boolean"
+	case schema.Int, schema.Int8, schema.Int16, schema.Int32, schema.Int64,
+		schema.Uint, schema.Uint8, schema.Uint16, schema.Uint32, schema.Uint64:
+		typ = "integer"
+		if r, ok := jsonSchemaIntRanges[f.Type]; ok {
+			prop["minimum"], prop["maximum"] = r[0], r[1]
+		}
+		if f.Size > 0 {
+			prop["maximum"] = f.Size
+		}
+	case schema.Float:
+		typ = "number"
+		if f.Size > 0 {
+			prop["maximum"] = f.Size
+		}
+	case mSchema.Decimal:
+		precision, scale := decimalSpec(f)
+		pattern := `^-?\d{1,` + strconv.Itoa(max(precision-scale, 1)) + `}`
+		if scale > 0 {
+			pattern += `(\.\d{1,` + strconv.Itoa(scale) + `})?`
+		}
+		prop["pattern"] = pattern + "$"
+	case schema.Time:
+		prop["x-time-format"] = m.timeFormat(f)
+	case schema.Bytes:
+		prop["contentEncoding"] = "base64"
+	case schema.JSON:
+		typ = "object"
+		if f.Options.IsArray {
+			typ = "array"
+		}
+	case schema.String:
+		if f.Size > 0 {
+			prop["maxLength"] = f.Size
+		}
+	}
+
+	if name == idKey && *m.define.Options.CryptID {
+		typ = "string"
+		delete(prop, "minimum")
+		delete(prop, "maximum")
+	}
+
+	for _, v := range f.Validations {
+		switch v.Method {
+		case "regex":
+			prop["pattern"] = ztype.ToString(v.Args)
+		case "minLength":
+			prop["minLength"] = ztype.ToInt(v.Args)
+		case "maxLength":
+			prop["maxLength"] = ztype.ToInt(v.Args)
+		case "min":
+			prop["minimum"] = ztype.ToFloat64(v.Args)
+		case "max":
+			prop["maximum"] = ztype.ToFloat64(v.Args)
+		case "mail":
+			prop["format"] = "email"
+		case "url":
+			prop["format"] = "uri"
+		case "ip":
+			prop["anyOf"] = []ztype.Map{{"format": "ipv4"}, {"format": "ipv6"}}
+		case "mobile":
+			prop["pattern"] = `^1[3-9]\d{9}$`
+		case "enum":
+			prop["enum"] = ztype.ToSlice(v.Args).Value()
+		}
+	}
+
+	if len(f.Options.Enum) > 0 {
+		options := make([]ztype.Map, 0, len(f.Options.Enum))
+		for _, e := range f.Options.Enum {
+			var value any = e.Value
+			if typ == "integer" {
+				if n, err := strconv.ParseInt(e.Value, 10, 64); err == nil {
+					value = n
+				}
+			}
+			options = append(options, ztype.Map{"const": value, "title": e.Label})
+		}
+		if f.Options.IsArray {
+			typ = "array"
+			prop = ztype.Map{"items": ztype.Map{"oneOf": options}, "uniqueItems": true}
+		} else {
+			prop["oneOf"] = options
+		}
+	}
+
+	if f.Nullable && variant != JSONSchemaUpdate {
+		prop["type"] = []string{typ, "null"}
+	} else {
+		prop["type"] = typ
+	}
+	if f.Label != "" {
+		prop["title"] = f.Label
+	}
+	if f.Comment != "" {
+		prop["description"] = f.Comment
+	}
+	if f.Default != nil && variant != JSONSchemaRead {
+		prop["default"] = f.Default
+	}
+	if variant == JSONSchemaRead && (f.Options.ReadOnly || m.isInlayField(name)) {
+		prop["readOnly"] = true
+	}
+	return prop
+}
+
+var jsonSchemaIntRanges = map[schema.DataType][2]int64{
+	schema.Int8:   {-1 << 7, 1<<7 - 1},
+	schema.Int16:  {-1 << 15, 1<<15 - 1},
+	schema.Int32:  {-1 << 31, 1<<31 - 1},
+	schema.Uint:   {0, 1<<32 - 1},
+	schema.Uint8:  {0, 1<<8 - 1},
+	schema.Uint16: {0, 1<<16 - 1},
+	schema.Uint32: {0, 1<<32 - 1},
+	schema.Uint64: {0, 1<<63 - 1},
+}
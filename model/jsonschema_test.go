@@ -0,0 +1,93 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func TestJSONSchema(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	timestamps := true
+	articles := schema.Schema{
+		Name:    "json_articles",
+		Table:   schema.Table{Name: "json_articles", Comment: "文章"},
+		Options: schema.Options{Timestamps: &timestamps},
+		Fields: map[string]schema.Field{
+			"title": {Type: schema.String, Size: 80, Label: "标题", Validations: []schema.Validations{
+				{Method: "minLength", Args: 2},
+			}},
+			"email": {Type: schema.String, Size: 100, Nullable: true, Validations: []schema.Validations{{Method: "mail"}}},
+			"status": {Type: schema.Int8, Label: "状态", Default: 1, Options: schema.FieldOption{
+				Enum: []schema.FieldEnum{{Value: "1", Label: "草稿"}, {Value: "2", Label: "发布"}},
+			}},
+			"tags": {Type: schema.JSON, Nullable: true, Options: schema.FieldOption{
+				IsArray: true,
+				Enum:    []schema.FieldEnum{{Value: "go"}, {Value: "js"}},
+			}},
+			"price":  {Type: schema.Decimal, Size: 8, Scale: 2},
+			"code":   {Type: schema.String, Size: 10, Options: schema.FieldOption{ReadOnly: true}},
+			"secret": {Type: schema.String, Nullable: true, Options: schema.FieldOption{Visibility: schema.VisibilityHidden}},
+		},
+	}
+	_, schemas := newTestSchemas(t, articles)
+	m := schemas.MustGet("json_articles")
+
+	doc := m.JSONSchema("")
+	tt.Equal(JSONSchemaDialect, doc.Get("$schema").String())
+	tt.Equal("#/$defs/read", doc.Get("$ref").String())
+
+	read := doc.Get("$defs").Get("read").Map()
+	props := read.Get("properties").Map()
+	tt.Equal(true, props.Has(IDKey()))
+	tt.Equal(true, props.Has(CreatedAtKey))
+	tt.Equal(false, props.Has("secret"))
+	tt.Equal(true, props.Get(IDKey()).Get("readOnly").Bool())
+	tt.Equal(true, props.Get("code").Get("readOnly").Bool())
+
+	title := props.Get("title").Map()
+	tt.Equal("string", title.Get("type").String())
+	tt.Equal(80, title.Get("maxLength").Int())
+	tt.Equal(2, title.Get("minLength").Int())
+	tt.Equal("标题", title.Get("title").String())
+
+	email := props.Get("email").Map()
+	tt.Equal([]string{"string", "null"}, email.Get("type").Slice().String())
+	tt.Equal("email", email.Get("format").String())
+
+	status := props.Get("status").Map()
+	tt.Equal([]string{"integer", "null"}, status.Get("type").Slice().String())
+	options := status.Get("oneOf").Value().([]ztype.Map)
+	tt.Equal(int64(2), options[1].Get("const").Value())
+	tt.Equal("发布", options[1].Get("title").String())
+
+	tags := props.Get("tags").Map()
+	tt.Equal("array", tags.Get("type").Slice().String()[0])
+	tt.Equal("go", tags.Get("items").Get("oneOf").Value().([]ztype.Map)[0].Get("const").String())
+	tt.Equal(`^-?\d{1,6}(\.\d{1,2})?$`, props.Get("price").Get("pattern").String())
+
+	create := m.JSONSchemaOf("", JSONSchemaCreate)
+	props = create.Get("properties").Map()
+	tt.Equal(false, props.Has(IDKey()))
+	tt.Equal(false, props.Has(UpdatedAtKey))
+	tt.Equal(true, props.Has("code"))
+	tt.Equal(true, props.Has("secret"))
+	tt.Equal(1, props.Get("status").Get("default").Int())
+	required := create.Get("required").Slice().String()
+	tt.Equal(true, zarray.Contains(required, "title"))
+	tt.Equal(false, zarray.Contains(required, "status"))
+
+	update := m.JSONSchemaOf("", JSONSchemaUpdate)
+	props = update.Get("properties").Map()
+	tt.Equal(false, props.Has("code"))
+	tt.Equal("string", props.Get("email").Get("type").String())
+	tt.Equal(false, update.Has("required"))
+
+	components := m.OpenAPIComponents("")
+	tt.Equal(true, components.Has("JsonArticlesRead"))
+	tt.Equal(true, components.Has("JsonArticlesUpdate"))
+}
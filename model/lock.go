@@ -0,0 +1,66 @@
+package model
+
+import (
+	"github.com/zlsgo/zdb/driver"
+)
+
+// LockMode 行锁模式
+type LockMode uint8
+
+const (
+	// LockNone 不加锁
+	LockNone LockMode = iota
+	// LockForUpdate 排他锁 SELECT ... FOR UPDATE
+	LockForUpdate
+	// LockForShare 共享锁 SELECT ... FOR SHARE
+	LockForShare
+)
+
+// LockWait 行锁冲突时的等待策略
+type LockWait uint8
+
+const (
+	// LockWaitDefault 等待锁释放
+	LockWaitDefault LockWait = iota
+	// LockSkipLocked 跳过已被锁定的行
+	LockSkipLocked
+	// LockNoWait 行已被锁定时立即返回错误
+	LockNoWait
+)
+
+// lockClause 按数据库方言生成行锁子句，SQLite 以库级锁实现事务隔离，返回空字符串
+func lockClause(typ driver.Typ, mode LockMode, wait LockWait) string {
+	if mode == LockNone {
+		return ""
+	}
+
+	var clause string
+	switch typ {
+	case driver.SQLite:
+		return ""
+	case driver.MySQL:
+		if mode == LockForShare {
+			clause = "LOCK IN SHARE MODE"
+			if wait != LockWaitDefault {
+				// MySQL 8 起才支持 FOR SHARE 搭配 SKIP LOCKED / NOWAIT
+				clause = "FOR SHARE"
+			}
+		} else {
+			clause = "FOR UPDATE"
+		}
+	default:
+		if mode == LockForShare {
+			clause = "FOR SHARE"
+		} else {
+			clause = "FOR UPDATE"
+		}
+	}
+
+	switch wait {
+	case LockSkipLocked:
+		clause += " SKIP LOCKED"
+	case LockNoWait:
+		clause += " NOWAIT"
+	}
+	return clause
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/zdb/driver"
+)
+
+func TestLockClause(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	tt.Equal("", lockClause(driver.MySQL, LockNone, LockSkipLocked))
+	tt.Equal("", lockClause(driver.SQLite, LockForUpdate, LockNoWait))
+	tt.Equal("FOR UPDATE", lockClause(driver.MySQL, LockForUpdate, LockWaitDefault))
+	tt.Equal("LOCK IN SHARE MODE", lockClause(driver.MySQL, LockForShare, LockWaitDefault))
+	tt.Equal("FOR SHARE NOWAIT", lockClause(driver.MySQL, LockForShare, LockNoWait))
+	tt.Equal("FOR UPDATE SKIP LOCKED", lockClause(driver.PostgreSQL, LockForUpdate, LockSkipLocked))
+	tt.Equal("FOR SHARE", lockClause(driver.PostgreSQL, LockForShare, LockWaitDefault))
+}
+
+func TestQueryLock(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	_, m := newTestDB(t, "lock_test")
+
+	repo := m.Model().Repository()
+	_, err := repo.Insert(ztype.Map{"name": "LockUser", "email": "lock@test.com", "age": 20, "status": 1})
+	tt.NoError(err)
+
+	_, err = repo.Query().ForUpdate().Find()
+	tt.Equal(ErrLockOutsideTransaction, err)
+	_, err = repo.Query().ForShare().NoWait().First()
+	tt.Equal(ErrLockOutsideTransaction, err)
+
+	err = repo.Tx(func(txRepo *Repository[ztype.Map, QueryFilter, ztype.Map, ztype.Map]) error {
+		user, err := txRepo.Query().Where("name", "LockUser").ForUpdate().SkipLocked().First()
+		if err != nil {
+			return err
+		}
+		tt.Equal("lock@test.com", user.Get("email").String())
+
+		count, err := txRepo.Query().ForUpdate().Count()
+		if err != nil {
+			return err
+		}
+		tt.Equal(uint64(1), count)
+
+		_, err = txRepo.Query().ForUpdate().Pages(1, 10)
+		tt.Equal(ErrLockNotSupported, err)
+		return nil
+	})
+	tt.NoError(err)
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	"github.com/sohaha/zlsgo/zdi"
 	"github.com/sohaha/zlsgo/znet"
 	"github.com/sohaha/zlsgo/zutil"
@@ -17,9 +19,15 @@ type (
 		// Timestamps 注入创建/更新时间
 		Timestamps bool `z:"timestamps,omitempty"`
 		// CryptID 加密 ID
-		CryptID          bool          `z:"crypt_id,omitempty"`
+		CryptID bool `z:"crypt_id,omitempty"`
+		// Audit 记录变更历史
+		Audit bool `z:"audit,omitempty"`
+		// Outbox 变更事件写入发件箱
+		Outbox           bool          `z:"outbox,omitempty"`
 		SoftDeleteIsTime bool          `z:"soft_delete_is_time,omitempty"`
 		OldColumn        DealOldColumn `z:"old_column,omitempty"`
+		// Tenancy 多租户模式
+		Tenancy schema.TenancyMode `z:"tenancy,omitempty"`
 	}
 	Options struct {
 		// SetStorageer 手动设置数据库
@@ -30,12 +38,22 @@ type (
 		SchemaMiddleware func() []znet.Handler
 		// Prefix 模型前缀
 		Prefix string
-		// SchemaDir 模型定义目录
+		// SchemaDir 模型定义目录，支持 JSON、YAML 与 TOML 文件
 		SchemaDir string
+		// SchemaEnv 模型文件中 env 覆盖配置使用的环境
+		SchemaEnv string
+		// SchemaWatch 大于 0 时按该间隔检查 SchemaDir 变更并热加载模型
+		SchemaWatch time.Duration
 		// SchemaApi schema api 路径
 		SchemaApi string
+		// SchemaRepository 开启运行时模型管理，返回模型定义与版本的持久化方式
+		SchemaRepository func(ss *Schemas) (SchemaRepository, error)
+		// SchemaManageMiddleware 模型管理接口的鉴权中间件，未设置时不注册管理接口
+		SchemaManageMiddleware func() []znet.Handler
 		// Schemas 定义模型
 		Schemas schema.Schemas
+		// Hooks 注册模型集合钩子
+		Hooks func(h *Hooks)
 		// SchemaOptions 模型选项
 		SchemaOptions
 	}
@@ -61,6 +79,13 @@ func New(o ...func(*Options)) (m *Module) {
 			module:     m,
 			middleware: opt.SchemaMiddleware,
 		})
+		if opt.SchemaRepository != nil && opt.SchemaManageMiddleware != nil {
+			m.Service.Controllers = append(m.Service.Controllers, &schemaManageController{
+				Path:       opt.SchemaApi,
+				module:     m,
+				middleware: opt.SchemaManageMiddleware,
+			})
+		}
 	}
 
 	return m
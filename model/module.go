@@ -11,6 +11,8 @@ type (
 		service.ModuleLifeCycle
 		schemas *Schemas
 		stores  *Stores
+		watcher *SchemaWatcher
+		manager *SchemaManager
 		Options Options
 	}
 )
@@ -32,6 +34,25 @@ func (m *Module) String() string {
 	return "[]"
 }
 
+// Stop 停止模型目录热加载
+func (m *Module) Stop() error {
+	if m.watcher != nil {
+		m.watcher.Stop()
+		m.watcher = nil
+	}
+	return nil
+}
+
+// SchemaWatcher 模型目录热加载实例，未开启 SchemaWatch 时为 nil
+func (m *Module) SchemaWatcher() *SchemaWatcher {
+	return m.watcher
+}
+
+// SchemaManager 运行时模型管理，未配置 SchemaRepository 时为 nil
+func (m *Module) SchemaManager() *SchemaManager {
+	return m.manager
+}
+
 func (m *Module) Stores() *Stores {
 	return m.stores
 }
@@ -102,6 +102,11 @@ func (o *Store) Count(filter QueryFilter, fn ...func(*CondOptions)) (uint64, err
 	return Count(o, filter, fn...)
 }
 
+// Sum 对字段求和，定点数字段不经过浮点运算
+func (o *Store) Sum(field string, filter QueryFilter, fn ...func(*CondOptions)) (Decimal, error) {
+	return Sum(o, field, filter, fn...)
+}
+
 // Exists 检查记录是否存在
 func (o *Store) Exists(filter QueryFilter, fn ...func(*CondOptions)) (bool, error) {
 	total, err := Count(o, filter, fn...)
@@ -125,12 +130,29 @@ func (o *Store) FindOne(filter QueryFilter, fn ...func(*CondOptions)) (ztype.Map
 
 // FindOneByID 根据 ID 查询单条记录
 func (o *Store) FindOneByID(id any, fn ...func(*CondOptions)) (ztype.Map, error) {
-	return FindOne[ztype.Map](o, ID(id), fn...)
+	filter, err := o.schema.idFilter(id)
+	if err != nil {
+		return nil, err
+	}
+	return FindOne[ztype.Map](o, filter, fn...)
+}
+
+// FindOneByKey 根据主键值（支持复合主键）查询单条记录
+func (o *Store) FindOneByKey(key ztype.Map, fn ...func(*CondOptions)) (ztype.Map, error) {
+	filter, err := o.schema.KeyFilter(key)
+	if err != nil {
+		return nil, err
+	}
+	return FindOne[ztype.Map](o, Filter(filter), fn...)
 }
 
 // Pages 分页查询记录
 func (o *Store) Pages(page, pagesize int, filter QueryFilter, fn ...func(*CondOptions)) (*PageData, error) {
-	return pages(o.schema, page, pagesize, getFilter(o.schema, filter), true, fn...)
+	f, err := getFilter(o.schema, filter)
+	if err != nil {
+		return nil, err
+	}
+	return pages(o.schema, page, pagesize, f, true, fn...)
 }
 
 // Update 更新符合条件的记录
@@ -145,7 +167,20 @@ func (o *Store) UpdateMany(filter QueryFilter, data any, fn ...func(*CondOptions
 
 // UpdateByID 根据 ID 更新记录
 func (o *Store) UpdateByID(id any, data any, fn ...func(*CondOptions)) (total int64, err error) {
-	return Update(o.schema, ID(id), data, fn...)
+	filter, err := o.schema.idFilter(id)
+	if err != nil {
+		return 0, err
+	}
+	return Update(o.schema, filter, data, fn...)
+}
+
+// UpdateByKey 根据主键值（支持复合主键）更新记录
+func (o *Store) UpdateByKey(key ztype.Map, data any, fn ...func(*CondOptions)) (total int64, err error) {
+	filter, err := o.schema.KeyFilter(key)
+	if err != nil {
+		return 0, err
+	}
+	return Update(o.schema, Filter(filter), data, fn...)
 }
 
 // Delete 删除符合条件的记录
@@ -160,7 +195,44 @@ func (o *Store) DeleteMany(filter QueryFilter, fn ...func(*CondOptions)) (total
 
 // DeleteByID 根据 ID 删除记录
 func (o *Store) DeleteByID(id any, fn ...func(*CondOptions)) (total int64, err error) {
-	return Delete(o.schema, ID(id), fn...)
+	filter, err := o.schema.idFilter(id)
+	if err != nil {
+		return 0, err
+	}
+	return Delete(o.schema, filter, fn...)
+}
+
+// DeleteByKey 根据主键值（支持复合主键）删除记录
+func (o *Store) DeleteByKey(key ztype.Map, fn ...func(*CondOptions)) (total int64, err error) {
+	filter, err := o.schema.KeyFilter(key)
+	if err != nil {
+		return 0, err
+	}
+	return Delete(o.schema, Filter(filter), fn...)
+}
+
+// Restore 恢复软删除的记录
+func (o *Store) Restore(filter QueryFilter, fn ...func(*CondOptions)) (total int64, err error) {
+	return Restore(o.schema, filter, fn...)
+}
+
+// RestoreByID 根据 ID 恢复软删除的记录
+func (o *Store) RestoreByID(id any, fn ...func(*CondOptions)) (total int64, err error) {
+	filter, err := o.schema.idFilter(id)
+	if err != nil {
+		return 0, err
+	}
+	return Restore(o.schema, filter, fn...)
+}
+
+// AfterCommit 注册事务提交后执行的回调，不在事务中时立即执行
+func (o *Store) AfterCommit(fn func()) {
+	o.schema.afterCommit(fn)
+}
+
+// AfterRollback 注册事务回滚后执行的回调，嵌套事务回滚到保存点时同样触发
+func (o *Store) AfterRollback(fn func()) {
+	o.schema.afterRollback(fn)
 }
 
 // Repository 创建 Map 类型仓储
@@ -0,0 +1,483 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sohaha/zlsgo/zjson"
+	"github.com/sohaha/zlsgo/ztime"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+const (
+	// OutboxSchemaName 事件发件箱模型名称
+	OutboxSchemaName = "__outbox_events"
+	// OutboxOffsetSchemaName 订阅位点模型名称
+	OutboxOffsetSchemaName = "__outbox_offsets"
+)
+
+var (
+	// ErrOutboxDisabled 模型未开启事件发件箱
+	ErrOutboxDisabled = errors.New("outbox is not enabled")
+	// ErrSubscriberExists 同名订阅者已在运行
+	ErrSubscriberExists = errors.New("outbox subscriber already exists")
+)
+
+// OutboxEvent 数据变更事件
+type OutboxEvent struct {
+	Time      time.Time      `json:"time"`
+	Before    ztype.Map      `json:"before,omitempty"`
+	After     ztype.Map      `json:"after,omitempty"`
+	Schema    string         `json:"schema"`
+	RecordID  string         `json:"record_id"`
+	Operation AuditOperation `json:"operation"`
+	Tenant    string         `json:"tenant,omitempty"`
+	ID        int64          `json:"id"`
+}
+
+// SubscribeOptions 订阅选项
+type SubscribeOptions struct {
+	// Name 订阅者名称，用于保存消费位点，必须填写且同一时间只能有一个同名订阅
+	Name string
+	// Interval 轮询间隔
+	Interval time.Duration
+	// RetryDelay 首次重试间隔，之后按倍数递增
+	RetryDelay time.Duration
+	// BatchSize 每次拉取的事件数量
+	BatchSize int
+	// MaxRetries 单个事件的最大重试次数，超过后等待下一轮投递
+	MaxRetries int
+	// GapTimeout 事件 ID 出现空洞时的等待时长，空洞可能来自尚未提交的事务，超时后视为已回滚，
+	// 超时后才提交的事件不会再投递，应大于最长写事务的耗时，默认 1 分钟
+	GapTimeout time.Duration
+}
+
+// Subscription 事件订阅
+type Subscription struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wake    chan struct{}
+	done    chan struct{}
+	schema  *Schema
+	handler func(ctx context.Context, event OutboxEvent) error
+	gaps    map[int64]time.Time
+	opt     SubscribeOptions
+	cursor  int64
+	mu      sync.Mutex
+	loaded  bool
+}
+
+var (
+	subscriptions   = make(map[*Subscription]struct{})
+	subscriptionsMu sync.Mutex
+)
+
+func outboxSchemaDefine() schema.Schema {
+	f := false
+	return schema.Schema{
+		Name: OutboxSchemaName,
+		Table: schema.Table{
+			Name:    "outbox_events",
+			Comment: "事件发件箱",
+		},
+		Options: schema.Options{
+			Audit:       &f,
+			Outbox:      &f,
+			SoftDeletes: &f,
+			Timestamps:  &f,
+			CryptID:     &f,
+			Tenancy:     schema.TenancyNone,
+		},
+		Fields: map[string]schema.Field{
+			"schema":     {Type: schema.String, Size: 120, Label: "模型", Index: true},
+			"record_id":  {Type: schema.String, Size: 64, Label: "记录 ID"},
+			"operation":  {Type: schema.String, Size: 20, Label: "操作"},
+			"before":     {Type: schema.Text, Label: "变更前", Nullable: true},
+			"after":      {Type: schema.Text, Label: "变更后", Nullable: true},
+			"tenant":     {Type: schema.String, Size: 64, Label: "租户", Nullable: true},
+			"created_at": {Type: schema.Time, Label: "创建时间"},
+		},
+	}
+}
+
+func outboxOffsetSchemaDefine() schema.Schema {
+	f := false
+	return schema.Schema{
+		Name: OutboxOffsetSchemaName,
+		Table: schema.Table{
+			Name:    "outbox_offsets",
+			Comment: "事件订阅位点",
+		},
+		Options: schema.Options{
+			Audit:       &f,
+			Outbox:      &f,
+			SoftDeletes: &f,
+			Timestamps:  &f,
+			CryptID:     &f,
+			Tenancy:     schema.TenancyNone,
+		},
+		Fields: map[string]schema.Field{
+			"subscriber": {Type: schema.String, Size: 120, Label: "订阅者", Unique: true},
+			"position":   {Type: schema.Int64, Label: "位点"},
+			"updated_at": {Type: schema.Time, Label: "更新时间"},
+		},
+	}
+}
+
+// IsOutbox 是否开启事件发件箱
+func (m *Schema) IsOutbox() bool {
+	return m.define.Options.Outbox != nil && *m.define.Options.Outbox
+}
+
+func (m *Schema) relatedSchema(name string, notFound error) (*Schema, error) {
+	if m.getSchema == nil {
+		return nil, notFound
+	}
+	s, ok := m.getSchema(name)
+	if !ok {
+		return nil, notFound
+	}
+	return s, nil
+}
+
+// outbox 写入变更事件，与数据变更处于同一事务
+func (m *Schema) outbox(op AuditOperation, id any, before, after ztype.Map) error {
+	if !m.IsOutbox() {
+		return nil
+	}
+
+	ob, err := m.relatedSchema(OutboxSchemaName, ErrOutboxDisabled)
+	if err != nil {
+		return err
+	}
+
+	tenant, _ := m.Tenant()
+	data := ztype.Map{
+		"schema":     m.GetAlias(),
+		"record_id":  ztype.ToString(id),
+		"operation":  string(op),
+		"tenant":     tenant,
+		"created_at": ztime.Now(),
+	}
+	for k, v := range map[string]ztype.Map{"before": before, "after": after} {
+		if v == nil {
+			continue
+		}
+		b, err := zjson.Marshal(m.auditMaskValues(v))
+		if err != nil {
+			return err
+		}
+		data[k] = string(b)
+	}
+
+	if _, err = Insert(ob, data); err != nil {
+		return err
+	}
+
+	m.afterCommit(notifyOutbox)
+	return nil
+}
+
+// notifyOutbox 唤醒所有订阅者拉取新事件
+func notifyOutbox() {
+	subscriptionsMu.Lock()
+	defer subscriptionsMu.Unlock()
+	for s := range subscriptions {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Subscribe 订阅模型的变更事件，事件仅在事务提交后投递，并保证至少投递一次
+func Subscribe(
+	m *Schema,
+	handler func(ctx context.Context, event OutboxEvent) error,
+	opts ...func(*SubscribeOptions),
+) (*Subscription, error) {
+	if !m.IsOutbox() {
+		return nil, ErrOutboxDisabled
+	}
+	if handler == nil {
+		return nil, errors.New("handler cannot be nil")
+	}
+	if _, err := m.relatedSchema(OutboxOffsetSchemaName, ErrOutboxDisabled); err != nil {
+		return nil, err
+	}
+
+	opt := SubscribeOptions{
+		Interval:   time.Second,
+		RetryDelay: time.Second,
+		BatchSize:  100,
+		MaxRetries: 3,
+		GapTimeout: time.Minute,
+	}
+	for i := range opts {
+		opts[i](&opt)
+	}
+	if opt.Name == "" {
+		return nil, errors.New("subscriber name cannot be empty")
+	}
+	if opt.BatchSize <= 0 {
+		opt.BatchSize = 100
+	}
+	if opt.Interval <= 0 {
+		opt.Interval = time.Second
+	}
+	if opt.GapTimeout <= 0 {
+		opt.GapTimeout = time.Minute
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Subscription{
+		ctx:     ctx,
+		cancel:  cancel,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		schema:  m,
+		handler: handler,
+		gaps:    make(map[int64]time.Time),
+		opt:     opt,
+	}
+
+	subscriptionsMu.Lock()
+	for sub := range subscriptions {
+		if sub.opt.Name == opt.Name {
+			subscriptionsMu.Unlock()
+			cancel()
+			return nil, ErrSubscriberExists
+		}
+	}
+	subscriptions[s] = struct{}{}
+	subscriptionsMu.Unlock()
+
+	go s.run()
+	return s, nil
+}
+
+// Stop 停止订阅并等待投递协程退出
+func (s *Subscription) Stop() {
+	subscriptionsMu.Lock()
+	delete(subscriptions, s)
+	subscriptionsMu.Unlock()
+
+	s.cancel()
+	<-s.done
+}
+
+func (s *Subscription) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.opt.Interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			_, n, err := s.poll()
+			if err != nil {
+				modelLogger.Errorf("outbox %s deliver error: %v\n", s.opt.Name, err)
+				break
+			}
+			if n < s.opt.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// Poll 拉取并投递一批事件，返回成功投递的数量
+func (s *Subscription) Poll() (int, error) {
+	delivered, _, err := s.poll()
+	return delivered, err
+}
+
+// poll 先补投 ID 空洞中后提交的事件，再按 ID 顺序拉取新事件，返回投递数量与扫描数量
+//
+// 事件 ID 在写入时分配而非提交时，较早开始的事务可能晚于后续事件提交，
+// 因此扫描全部模型的事件以识别真正的空洞，并在 GapTimeout 内分批重复查询空洞中的 ID；
+// 保存的位点不超过最早的空洞，重启后从空洞处重新投递
+func (s *Subscription) poll() (delivered, scanned int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ob, err := s.schema.relatedSchema(OutboxSchemaName, ErrOutboxDisabled)
+	if err != nil {
+		return 0, 0, err
+	}
+	offsets, err := s.schema.relatedSchema(OutboxOffsetSchemaName, ErrOutboxDisabled)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	position := int64(0)
+	row, err := FindOne[ztype.Map](offsets.Model(), Filter{"subscriber": s.opt.Name})
+	exists := err == nil
+	if err != nil && !errors.Is(err, ErrNoRecord) {
+		return 0, 0, err
+	}
+	if exists {
+		position = row.Get("position").Int64()
+	}
+	if !s.loaded || s.cursor < position {
+		s.cursor = position
+		s.loaded = true
+	}
+
+	save := func() error {
+		safe := s.safePosition()
+		if exists && safe == position {
+			return nil
+		}
+		data := ztype.Map{"position": safe, "updated_at": ztime.Now()}
+		var err error
+		if exists {
+			_, err = Update(offsets, Filter{"subscriber": s.opt.Name}, data)
+		} else {
+			data["subscriber"] = s.opt.Name
+			_, err = Insert(offsets, data)
+			exists = err == nil
+		}
+		if err == nil {
+			position = safe
+		}
+		return err
+	}
+
+	alias := s.schema.GetAlias()
+	order := func(co *CondOptions) {
+		co.OrderBy = []OrderByItem{{Field: idKey, Direction: "ASC"}}
+	}
+
+	if len(s.gaps) > 0 {
+		ids := make([]int64, 0, len(s.gaps))
+		for id := range s.gaps {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for start := 0; start < len(ids); start += s.opt.BatchSize {
+			end := start + s.opt.BatchSize
+			if end > len(ids) {
+				end = len(ids)
+			}
+			batch := make([]any, 0, end-start)
+			for _, id := range ids[start:end] {
+				batch = append(batch, id)
+			}
+			rows, err := FindMaps(ob.Model(), In(idKey, batch), order)
+			if err != nil {
+				return delivered, 0, err
+			}
+			for _, row := range rows {
+				event := outboxEventFromRow(row)
+				if event.Schema == alias {
+					if err = s.deliver(event); err != nil {
+						return delivered, 0, err
+					}
+					delivered++
+				}
+				delete(s.gaps, event.ID)
+				if err = save(); err != nil {
+					return delivered, 0, err
+				}
+			}
+		}
+
+		now := time.Now()
+		for id, t := range s.gaps {
+			if now.Sub(t) >= s.opt.GapTimeout {
+				delete(s.gaps, id)
+			}
+		}
+	}
+
+	rows, err := FindMaps(ob.Model(), Filter{idKey + " >": s.cursor}, func(co *CondOptions) {
+		order(co)
+		co.Limit = s.opt.BatchSize
+	})
+	if err != nil {
+		return delivered, 0, err
+	}
+
+	now := time.Now()
+	for _, row := range rows {
+		event := outboxEventFromRow(row)
+		for id := s.cursor + 1; id < event.ID; id++ {
+			s.gaps[id] = now
+		}
+		if event.Schema != alias {
+			s.cursor = event.ID
+			continue
+		}
+		if err = s.deliver(event); err != nil {
+			return delivered, len(rows), err
+		}
+		delivered++
+		s.cursor = event.ID
+		if err = save(); err != nil {
+			return delivered, len(rows), err
+		}
+	}
+
+	return delivered, len(rows), save()
+}
+
+// safePosition 可保存的位点，不超过最早的未确认空洞
+func (s *Subscription) safePosition() int64 {
+	safe := s.cursor
+	for id := range s.gaps {
+		if id-1 < safe {
+			safe = id - 1
+		}
+	}
+	return safe
+}
+
+func (s *Subscription) deliver(event OutboxEvent) (err error) {
+	delay := s.opt.RetryDelay
+	for attempt := 0; ; attempt++ {
+		err = s.handler(s.ctx, event)
+		if err == nil || attempt >= s.opt.MaxRetries {
+			return
+		}
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func outboxEventFromRow(row ztype.Map) OutboxEvent {
+	event := OutboxEvent{
+		ID:        row.Get(idKey).Int64(),
+		Schema:    row.Get("schema").String(),
+		RecordID:  row.Get("record_id").String(),
+		Operation: AuditOperation(row.Get("operation").String()),
+		Tenant:    row.Get("tenant").String(),
+	}
+	if t, ok := row["created_at"].(time.Time); ok {
+		event.Time = t
+	} else if t, err := ztime.Parse(row.Get("created_at").String()); err == nil {
+		event.Time = t
+	}
+	if v := row.Get("before").String(); v != "" {
+		event.Before = zjson.Parse(v).Map()
+	}
+	if v := row.Get("after").String(); v != "" {
+		event.After = zjson.Parse(v).Map()
+	}
+	return event
+}
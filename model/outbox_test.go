@@ -0,0 +1,274 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztime"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func newOutboxTestSchema(t *testing.T) *Schema {
+	b := true
+	s := schema.Schema{
+		Name:  "outbox_orders",
+		Table: schema.Table{Name: "outbox_orders"},
+		Options: schema.Options{
+			Outbox: &b,
+		},
+		Fields: map[string]schema.Field{
+			"title":  {Type: "string", Label: "Title"},
+			"secret": {Type: "string", Label: "Secret", Options: schema.FieldOption{Crypt: "md5"}},
+		},
+	}
+	_, schemas := newTestSchemas(t, s)
+	return schemas.MustGet("outbox_orders")
+}
+
+func waitOutboxEvent(t *testing.T, ch chan OutboxEvent) OutboxEvent {
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(3 * time.Second):
+		t.Fatal("wait outbox event timeout")
+	}
+	return OutboxEvent{}
+}
+
+func TestOutboxSubscribe(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	m := newOutboxTestSchema(t)
+
+	events := make(chan OutboxEvent, 10)
+	failed := false
+	sub, err := Subscribe(m, func(ctx context.Context, e OutboxEvent) error {
+		if !failed {
+			failed = true
+			return errors.New("temporary error")
+		}
+		events <- e
+		return nil
+	}, func(o *SubscribeOptions) {
+		o.Name = "orders"
+		o.Interval = 50 * time.Millisecond
+		o.RetryDelay = 10 * time.Millisecond
+	})
+	tt.NoError(err)
+
+	store := m.Model()
+	id, err := store.Insert(ztype.Map{"title": "a", "secret": "s"})
+	tt.NoError(err)
+
+	e := waitOutboxEvent(t, events)
+	tt.Equal(AuditInsert, e.Operation)
+	tt.Equal("outbox_orders", e.Schema)
+	tt.Equal(ztype.ToString(id), e.RecordID)
+	tt.Equal("a", e.After.Get("title").String())
+	tt.Equal(auditMask, e.After.Get("secret").String())
+	tt.Equal(true, failed)
+
+	_, err = store.UpdateByID(id, ztype.Map{"title": "b"})
+	tt.NoError(err)
+	e = waitOutboxEvent(t, events)
+	tt.Equal(AuditUpdate, e.Operation)
+	tt.Equal("a", e.Before.Get("title").String())
+	tt.Equal("b", e.After.Get("title").String())
+
+	_, err = store.DeleteByID(id)
+	tt.NoError(err)
+	e = waitOutboxEvent(t, events)
+	tt.Equal(AuditDelete, e.Operation)
+	tt.Equal("b", e.Before.Get("title").String())
+
+	sub.Stop()
+
+	sub, err = Subscribe(m, func(ctx context.Context, e OutboxEvent) error {
+		events <- e
+		return nil
+	}, func(o *SubscribeOptions) {
+		o.Name = "orders"
+	})
+	tt.NoError(err)
+	n, err := sub.Poll()
+	tt.NoError(err)
+	tt.Equal(0, n)
+	sub.Stop()
+}
+
+func TestOutboxLateCommit(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	m := newOutboxTestSchema(t)
+	ob, err := m.relatedSchema(OutboxSchemaName, ErrOutboxDisabled)
+	tt.NoError(err)
+
+	// 模拟先分配 ID 的事务晚于后续事件提交
+	insertEvent := func(id int64, name string) {
+		_, err := ob.Storage.Insert(ob.GetTableName(), ztype.Map{
+			idKey:        id,
+			"schema":     name,
+			"record_id":  ztype.ToString(id),
+			"operation":  string(AuditInsert),
+			"created_at": ztime.Now(),
+		})
+		tt.NoError(err)
+	}
+	insertEvent(3, "outbox_orders")
+	insertEvent(4, "other_orders")
+
+	events := make(chan OutboxEvent, 10)
+	sub, err := Subscribe(m, func(ctx context.Context, e OutboxEvent) error {
+		events <- e
+		return nil
+	}, func(o *SubscribeOptions) {
+		o.Name = "late"
+		o.Interval = time.Hour
+	})
+	tt.NoError(err)
+	defer sub.Stop()
+
+	tt.Equal(int64(3), waitOutboxEvent(t, events).ID)
+
+	insertEvent(1, "outbox_orders")
+	insertEvent(2, "other_orders")
+	_, err = sub.Poll()
+	tt.NoError(err)
+	tt.Equal(int64(1), waitOutboxEvent(t, events).ID)
+
+	_, err = sub.Poll()
+	tt.NoError(err)
+	tt.Equal(0, len(events))
+
+	offsets, err := m.relatedSchema(OutboxOffsetSchemaName, ErrOutboxDisabled)
+	tt.NoError(err)
+	row, err := FindOne[ztype.Map](offsets.Model(), Filter{"subscriber": "late"})
+	tt.NoError(err)
+	tt.Equal(int64(4), row.Get("position").Int64())
+}
+
+func TestOutboxTx(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	m := newOutboxTestSchema(t)
+	repo := m.Model().Repository()
+
+	rollback := errors.New("rollback")
+	err := repo.Tx(func(txRepo *Repository[ztype.Map, QueryFilter, ztype.Map, ztype.Map]) error {
+		if _, err := txRepo.Insert(ztype.Map{"title": "discard"}); err != nil {
+			return err
+		}
+		return rollback
+	})
+	tt.Equal(rollback, err)
+
+	err = repo.Tx(func(txRepo *Repository[ztype.Map, QueryFilter, ztype.Map, ztype.Map]) error {
+		_, err := txRepo.Insert(ztype.Map{"title": "keep"})
+		return err
+	})
+	tt.NoError(err)
+
+	var got []OutboxEvent
+	sub, err := Subscribe(m, func(ctx context.Context, e OutboxEvent) error {
+		got = append(got, e)
+		return nil
+	}, func(o *SubscribeOptions) {
+		o.Name = "tx"
+		o.Interval = time.Hour
+	})
+	tt.NoError(err)
+	sub.Stop()
+
+	tt.Equal(1, len(got))
+	tt.Equal("keep", got[0].After.Get("title").String())
+
+	_, err = Subscribe(newAuditTestSchema(t, false), func(ctx context.Context, e OutboxEvent) error {
+		return nil
+	})
+	tt.Equal(ErrOutboxDisabled, err)
+}
+
+func TestOutboxSubscriberName(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	m := newOutboxTestSchema(t)
+	handler := func(ctx context.Context, e OutboxEvent) error { return nil }
+
+	_, err := Subscribe(m, handler)
+	tt.EqualTrue(err != nil)
+
+	sub, err := Subscribe(m, handler, func(o *SubscribeOptions) {
+		o.Name = "dup"
+		o.Interval = time.Hour
+	})
+	tt.NoError(err)
+
+	// 同名订阅共享位点，不允许同时运行
+	_, err = Subscribe(m, handler, func(o *SubscribeOptions) {
+		o.Name = "dup"
+	})
+	tt.Equal(ErrSubscriberExists, err)
+
+	sub.Stop()
+	sub, err = Subscribe(m, handler, func(o *SubscribeOptions) {
+		o.Name = "dup"
+		o.Interval = time.Hour
+	})
+	tt.NoError(err)
+	sub.Stop()
+}
+
+func TestOutboxGapTimeout(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	m := newOutboxTestSchema(t)
+	ob, err := m.relatedSchema(OutboxSchemaName, ErrOutboxDisabled)
+	tt.NoError(err)
+
+	insertEvent := func(id int64) {
+		_, err := ob.Storage.Insert(ob.GetTableName(), ztype.Map{
+			idKey:        id,
+			"schema":     "outbox_orders",
+			"record_id":  ztype.ToString(id),
+			"operation":  string(AuditInsert),
+			"created_at": ztime.Now(),
+		})
+		tt.NoError(err)
+	}
+	insertEvent(10)
+
+	events := make(chan OutboxEvent, 20)
+	sub, err := Subscribe(m, func(ctx context.Context, e OutboxEvent) error {
+		events <- e
+		return nil
+	}, func(o *SubscribeOptions) {
+		o.Name = "gap"
+		o.Interval = time.Hour
+		o.BatchSize = 2
+		o.GapTimeout = 200 * time.Millisecond
+	})
+	tt.NoError(err)
+	defer sub.Stop()
+
+	tt.Equal(int64(10), waitOutboxEvent(t, events).ID)
+
+	// 空洞数量超过 BatchSize 时仍全部跟踪
+	insertEvent(8)
+	_, err = sub.Poll()
+	tt.NoError(err)
+	tt.Equal(int64(8), waitOutboxEvent(t, events).ID)
+
+	// 超过 GapTimeout 后才提交的事件不再投递，位点越过空洞
+	time.Sleep(300 * time.Millisecond)
+	_, err = sub.Poll()
+	tt.NoError(err)
+	insertEvent(1)
+	_, err = sub.Poll()
+	tt.NoError(err)
+	tt.Equal(0, len(events))
+
+	offsets, err := m.relatedSchema(OutboxOffsetSchemaName, ErrOutboxDisabled)
+	tt.NoError(err)
+	row, err := FindOne[ztype.Map](offsets.Model(), Filter{"subscriber": "gap"})
+	tt.NoError(err)
+	tt.Equal(int64(10), row.Get("position").Int64())
+}
@@ -22,6 +22,9 @@ func perfect(alias string, s *Schema, o *SchemaOptions) (err error) {
 
 	s.readOnlyKeys = make([]string, 0, 4)
 	s.cryptKeys = make(map[string]CryptProcess, 2)
+	s.blindIndexes = make(map[string]string)
+	s.policies = make(map[string]fieldPolicy)
+	s.timeFields = make(map[string]string)
 	s.afterProcess = make(map[string][]afterProcess, 4)
 	s.beforeProcess = make(map[string][]beforeProcess, 4)
 
@@ -32,9 +35,17 @@ func perfect(alias string, s *Schema, o *SchemaOptions) (err error) {
 	}
 
 	_ = perfectOptions(s, o)
+	if err = s.checkPrimaryKey(); err != nil {
+		return
+	}
 
 	if !isNotFields {
-		s.inlayFields = []string{idKey}
+		s.inlayFields = make([]string, 0, 4)
+		if s.IsCompositeKey() {
+			s.readOnlyKeys = append(s.readOnlyKeys, s.PrimaryKeys()...)
+		} else {
+			s.inlayFields = append(s.inlayFields, idKey)
+		}
 
 		if *s.define.Options.Timestamps {
 			if zarray.Contains(s.fields, CreatedAtKey) {
@@ -44,12 +55,8 @@ func perfect(alias string, s *Schema, o *SchemaOptions) (err error) {
 				return errors.New(UpdatedAtKey + " is a reserved field")
 			}
 
-			after, err := s.GetAfterProcess([]string{"date|Y-m-d H:i:s"})
-			if err != nil {
-				return err
-			}
-			s.afterProcess[CreatedAtKey] = after
-			s.afterProcess[UpdatedAtKey] = after
+			s.timeFields[CreatedAtKey] = s.timeFormat(nil)
+			s.timeFields[UpdatedAtKey] = s.timeFormat(nil)
 			s.inlayFields = append(s.inlayFields, CreatedAtKey, UpdatedAtKey)
 		}
 
@@ -68,9 +75,19 @@ func perfect(alias string, s *Schema, o *SchemaOptions) (err error) {
 			s.inlayFields = append(s.inlayFields, DeletedAtKey)
 		}
 
+		if s.Tenancy() == schema.TenancyColumn {
+			if zarray.Contains(s.fields, TenantIDKey) {
+				return errors.New(TenantIDKey + " is a reserved field")
+			}
+			s.inlayFields = append(s.inlayFields, TenantIDKey)
+			s.readOnlyKeys = append(s.readOnlyKeys, TenantIDKey)
+		}
+
 		capacity := 1 + len(s.fields) + len(s.inlayFields)
 		s.fullFields = make([]string, 0, capacity)
-		s.fullFields = append(s.fullFields, idKey)
+		if !s.IsCompositeKey() {
+			s.fullFields = append(s.fullFields, idKey)
+		}
 		s.fullFields = append(s.fullFields, s.fields...)
 		s.fullFields = zarray.Unique(append(s.fullFields, s.inlayFields...))
 
@@ -105,11 +122,11 @@ func perfect(alias string, s *Schema, o *SchemaOptions) (err error) {
 				}
 				parentKeys := v.ForeignKey
 				if len(parentKeys) == 0 {
-					parentKeys = []string{idKey}
+					parentKeys = s.PrimaryKeys()
 				}
 				relatedKeys := v.SchemaKey
 				if len(relatedKeys) == 0 {
-					relatedKeys = []string{idKey}
+					relatedKeys = relatedPrimaryKeys(s, v.Schema)
 				}
 				if len(v.PivotKeys.Foreign) != len(parentKeys) {
 					return errors.New("Pivot foreign key length mismatch")
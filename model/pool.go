@@ -33,5 +33,7 @@ func releaseCondOptions(opts *CondOptions) {
 	opts.Join = nil
 	opts.Limit = 0
 	opts.Offset = 0
+	opts.Lock = LockNone
+	opts.LockWait = LockWaitDefault
 	condOptionsPool.Put(opts)
 }
@@ -0,0 +1,271 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sohaha/zlsgo/ztype"
+	mSchema "github.com/zlsgo/app_module/model/schema"
+	"github.com/zlsgo/zdb/schema"
+)
+
+var (
+	// ErrInvalidSnowflakeNode 无效的雪花算法节点 ID
+	ErrInvalidSnowflakeNode = errors.New("snowflake node must be between 0 and 1023")
+	// ErrInvalidKey 主键值不完整
+	ErrInvalidKey = errors.New("primary key values are incomplete")
+)
+
+// PrimaryKeyType 返回模型的主键生成策略，复合主键不生成主键值
+func (m *Schema) PrimaryKeyType() mSchema.PrimaryKeyType {
+	if pk := m.define.Options.PrimaryKey; pk != nil && len(pk.Fields) == 0 {
+		switch pk.Type {
+		case mSchema.PrimaryKeyUUID, mSchema.PrimaryKeyULID, mSchema.PrimaryKeySnowflake:
+			return pk.Type
+		}
+	}
+	return mSchema.PrimaryKeyAutoInc
+}
+
+// PrimaryKeys 返回主键字段列表
+func (m *Schema) PrimaryKeys() []string {
+	if pk := m.define.Options.PrimaryKey; pk != nil && len(pk.Fields) > 0 {
+		return pk.Fields
+	}
+	return []string{idKey}
+}
+
+// IsCompositeKey 是否以声明的字段作为主键
+func (m *Schema) IsCompositeKey() bool {
+	pk := m.define.Options.PrimaryKey
+	return pk != nil && len(pk.Fields) > 0
+}
+
+// KeyFilter 根据主键值生成查询条件，缺少任一主键字段时返回 ErrInvalidKey
+func (m *Schema) KeyFilter(key ztype.Map) (ztype.Map, error) {
+	keys := m.PrimaryKeys()
+	filter := make(ztype.Map, len(keys))
+	for _, k := range keys {
+		v, ok := key[k]
+		if !ok || v == nil {
+			return nil, ErrInvalidKey
+		}
+		filter[k] = v
+	}
+	return filter, nil
+}
+
+// idFilter 根据 id 生成查询条件，复合主键模型没有 id 列，返回 ErrInvalidKey 避免条件被忽略后作用于全表
+func (m *Schema) idFilter(id any) (QueryFilter, error) {
+	if m.IsCompositeKey() {
+		return nil, ErrInvalidKey
+	}
+	return ID(id), nil
+}
+
+// idsFilter 根据 id 列表生成查询条件，复合主键模型返回 ErrInvalidKey
+func (m *Schema) idsFilter(ids []any) (QueryFilter, error) {
+	if m.IsCompositeKey() {
+		return nil, ErrInvalidKey
+	}
+	return In(idKey, ids), nil
+}
+
+// rowKey 返回记录的主键值，复合主键返回字段值映射
+func (m *Schema) rowKey(row ztype.Map) any {
+	if !m.IsCompositeKey() {
+		return row[idKey]
+	}
+	keys := m.PrimaryKeys()
+	key := make(ztype.Map, len(keys))
+	for _, k := range keys {
+		key[k] = row[k]
+	}
+	return key
+}
+
+// relatedPrimaryKeys 关联模型的主键字段，关联模型尚未注册时默认为 id
+func relatedPrimaryKeys(m *Schema, alias string) []string {
+	if alias == m.alias {
+		return m.PrimaryKeys()
+	}
+	if m.getSchema != nil {
+		if rs, ok := m.getSchema(alias); ok && rs != nil {
+			return rs.PrimaryKeys()
+		}
+	}
+	return []string{idKey}
+}
+
+// checkPrimaryKey 校验主键生成策略与复合主键声明
+func (m *Schema) checkPrimaryKey() error {
+	if pk := m.define.Options.PrimaryKey; pk != nil {
+		switch pk.Type {
+		case "", mSchema.PrimaryKeyAutoInc, mSchema.PrimaryKeyUUID, mSchema.PrimaryKeyULID, mSchema.PrimaryKeySnowflake:
+		default:
+			return fmt.Errorf("%w: unknown primary key type %q", ErrInvalidPrimaryKey, pk.Type)
+		}
+	}
+	if !m.IsCompositeKey() {
+		return nil
+	}
+	for _, name := range m.PrimaryKeys() {
+		f, ok := m.define.Fields[name]
+		if !ok {
+			return errors.New("primary key field " + name + " is not defined")
+		}
+		if f.Nullable {
+			return errors.New("primary key field " + name + " cannot be nullable")
+		}
+	}
+	if m.IsAudit() || m.IsOutbox() {
+		return errors.New("audit and outbox require a single id primary key")
+	}
+	return nil
+}
+
+// primaryKeyField 主键字段定义
+func (m *Schema) primaryKeyField() *mSchema.Field {
+	f := &mSchema.Field{
+		Type:     schema.Int,
+		Nullable: false,
+		Label:    "ID",
+		Options: mSchema.FieldOption{
+			ReadOnly: true,
+		},
+	}
+	switch m.PrimaryKeyType() {
+	case mSchema.PrimaryKeyUUID:
+		f.Type, f.Size = schema.String, 36
+	case mSchema.PrimaryKeyULID:
+		f.Type, f.Size = schema.String, 26
+	case mSchema.PrimaryKeySnowflake:
+		f.Type = schema.Int64
+	}
+	return f
+}
+
+// isStringPrimaryKey 主键是否为字符串类型
+func (m *Schema) isStringPrimaryKey() bool {
+	t := m.PrimaryKeyType()
+	return t == mSchema.PrimaryKeyUUID || t == mSchema.PrimaryKeyULID
+}
+
+// primaryKeyValue 为非自增主键生成 ID，调用方显式传入的 ID 原样保留
+func (m *Schema) primaryKeyValue(id any) (any, bool) {
+	t := m.PrimaryKeyType()
+	if t == mSchema.PrimaryKeyAutoInc || m.IsCompositeKey() {
+		return nil, false
+	}
+	if id != nil && ztype.ToString(id) != "" {
+		if t == mSchema.PrimaryKeySnowflake {
+			return ztype.ToInt64(id), true
+		}
+		return ztype.ToString(id), true
+	}
+
+	switch t {
+	case mSchema.PrimaryKeyUUID:
+		return NewUUID(), true
+	case mSchema.PrimaryKeyULID:
+		return NewULID(), true
+	default:
+		return NextSnowflakeID(), true
+	}
+}
+
+// NewUUID 生成 UUID v4
+func NewUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
+
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID 生成 ULID，前 48 位为毫秒时间戳
+func NewULID() string {
+	var b [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	b[0], b[1], b[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+	b[3], b[4], b[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+	_, _ = rand.Read(b[6:])
+
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockfordBase32[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+const (
+	snowflakeEpoch    = int64(1704067200000) // 2024-01-01 00:00:00 UTC
+	snowflakeNodeBits = 10
+	snowflakeSeqBits  = 12
+	snowflakeSeqMask  = int64(-1) ^ (int64(-1) << snowflakeSeqBits)
+)
+
+var snowflake = struct {
+	node int64
+	last int64
+	seq  int64
+	mu   sync.Mutex
+}{}
+
+// SetSnowflakeNode 设置雪花算法节点 ID，多实例部署时每个实例应不同
+func SetSnowflakeNode(node int64) error {
+	if node < 0 || node >= 1<<snowflakeNodeBits {
+		return ErrInvalidSnowflakeNode
+	}
+	snowflake.mu.Lock()
+	snowflake.node = node
+	snowflake.mu.Unlock()
+	return nil
+}
+
+// NextSnowflakeID 生成雪花算法 ID
+func NextSnowflakeID() int64 {
+	snowflake.mu.Lock()
+	defer snowflake.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	if now < snowflake.last {
+		now = snowflake.last
+	}
+	if now == snowflake.last {
+		snowflake.seq = (snowflake.seq + 1) & snowflakeSeqMask
+		if snowflake.seq == 0 {
+			for now <= snowflake.last {
+				time.Sleep(100 * time.Microsecond)
+				now = time.Now().UnixMilli()
+			}
+		}
+	} else {
+		snowflake.seq = 0
+	}
+	snowflake.last = now
+
+	return (now-snowflakeEpoch)<<(snowflakeNodeBits+snowflakeSeqBits) |
+		snowflake.node<<snowflakeSeqBits | snowflake.seq
+}
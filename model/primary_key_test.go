@@ -0,0 +1,247 @@
+package model
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func TestPrimaryKeyGenerators(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	uuid := NewUUID()
+	tt.Equal(true, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(uuid))
+	tt.Equal(false, uuid == NewUUID())
+
+	ulid := NewULID()
+	tt.Equal(26, len(ulid))
+	tt.Equal(true, regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`).MatchString(ulid))
+
+	prev := NextSnowflakeID()
+	for i := 0; i < 5000; i++ {
+		id := NextSnowflakeID()
+		tt.Equal(true, id > prev)
+		prev = id
+	}
+
+	tt.Equal(ErrInvalidSnowflakeNode, SetSnowflakeNode(1024))
+	tt.NoError(SetSnowflakeNode(0))
+}
+
+func TestPrimaryKeyUUID(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	b := true
+	users := schema.Schema{
+		Name:    "pk_users",
+		Table:   schema.Table{Name: "pk_users"},
+		Options: schema.Options{CryptID: &b, PrimaryKey: &schema.PrimaryKey{Type: schema.PrimaryKeyUUID}},
+		Fields: map[string]schema.Field{
+			"name": {Type: "string", Label: "Name"},
+		},
+	}
+	roles := schema.Schema{
+		Name:    "pk_roles",
+		Table:   schema.Table{Name: "pk_roles"},
+		Options: schema.Options{PrimaryKey: &schema.PrimaryKey{Type: schema.PrimaryKeyULID}},
+		Fields: map[string]schema.Field{
+			"name": {Type: "string", Label: "Name"},
+		},
+	}
+	relation := schema.Relation{
+		Type:       schema.RelationManyToMany,
+		Schema:     "pk_roles",
+		ForeignKey: []string{IDKey()},
+		SchemaKey:  []string{IDKey()},
+		PivotKeys:  schema.PivotKeys{Foreign: []string{"user_id"}, Related: []string{"role_id"}},
+	}
+	users.Relations = map[string]schema.Relation{"roles": relation}
+
+	db, schemas := newTestSchemas(t, users, roles)
+	m := schemas.MustGet("pk_users")
+	tt.Equal(schema.PrimaryKeyUUID, m.PrimaryKeyType())
+	tt.Equal(false, *m.GetDefine().Options.CryptID)
+
+	id, err := m.Model().Insert(ztype.Map{"name": "a"})
+	tt.NoError(err)
+	tt.Equal(36, len(ztype.ToString(id)))
+
+	ids, err := m.Model().InsertMany(ztype.Maps{{"name": "b"}, {"name": "c"}})
+	tt.NoError(err)
+	tt.Equal(2, len(ids))
+
+	row, err := m.Model().FindOne(ID(ids[1]))
+	tt.NoError(err)
+	tt.Equal("c", row.Get("name").String())
+
+	fixed, err := m.Model().Insert(ztype.Map{IDKey(): "00000000-0000-4000-8000-000000000001", "name": "d"})
+	tt.NoError(err)
+	tt.Equal("00000000-0000-4000-8000-000000000001", fixed)
+
+	_, err = m.Model().UpdateByID(id, ztype.Map{"name": "a2"})
+	tt.NoError(err)
+	row, err = m.Model().FindOneByID(id)
+	tt.NoError(err)
+	tt.Equal("a2", row.Get("name").String())
+
+	rid, err := schemas.MustGet("pk_roles").Model().Insert(ztype.Map{"name": "admin"})
+	tt.NoError(err)
+	tt.Equal(26, len(ztype.ToString(rid)))
+
+	pm := NewPivotManager(m)
+	tt.NoError(pm.SyncPivotSchema(&relation))
+	pivotTable, err := pm.GetPivotTableName(&relation)
+	tt.NoError(err)
+	_, err = db.Exec("INSERT INTO "+pivotTable+" (user_id, role_id) VALUES (?, ?)", id, rid)
+	tt.NoError(err)
+
+	rows, err := m.Model().Repository().Query().WhereID(id).WithRelation("roles.name").Find()
+	tt.NoError(err)
+	tt.Equal(1, len(rows))
+	roleRows, _ := rows[0].Get("roles").Value().(ztype.Maps)
+	tt.Equal(1, len(roleRows))
+	tt.Equal("admin", roleRows[0].Get("name").String())
+}
+
+func TestPrimaryKeySnowflake(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	s := schema.Schema{
+		Name:    "pk_events",
+		Table:   schema.Table{Name: "pk_events"},
+		Options: schema.Options{PrimaryKey: &schema.PrimaryKey{Type: schema.PrimaryKeySnowflake}},
+		Fields: map[string]schema.Field{
+			"name": {Type: "string", Label: "Name"},
+		},
+	}
+	_, schemas := newTestSchemas(t, s)
+	m := schemas.MustGet("pk_events")
+
+	id, err := m.Model().Insert(ztype.Map{"name": "a"})
+	tt.NoError(err)
+	tt.Equal(true, ztype.ToInt64(id) > 1<<22)
+
+	row, err := m.Model().FindOneByID(id)
+	tt.NoError(err)
+	tt.Equal(ztype.ToInt64(id), row.Get(IDKey()).Int64())
+}
+
+func TestCompositeKey(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	tt.Equal("CREATE TABLE t (a int, b varchar(10) COMMENT 'x)', PRIMARY KEY (a, b)) ENGINE=InnoDB",
+		withPrimaryKeyConstraint("CREATE TABLE t (a int, b varchar(10) COMMENT 'x)') ENGINE=InnoDB", []string{"a", "b"}))
+
+	accounts := schema.Schema{
+		Name:    "pk_accounts",
+		Table:   schema.Table{Name: "pk_accounts"},
+		Options: schema.Options{PrimaryKey: &schema.PrimaryKey{Fields: []string{"org", "uname"}}},
+		Fields: map[string]schema.Field{
+			"org":   {Type: "string", Label: "Org", Size: 32},
+			"uname": {Type: "string", Label: "Name", Size: 32},
+			"nick":  {Type: "string", Label: "Nick", Nullable: true},
+		},
+	}
+	roles := schema.Schema{
+		Name:  "pk_account_roles",
+		Table: schema.Table{Name: "pk_account_roles"},
+		Fields: map[string]schema.Field{
+			"name": {Type: "string", Label: "Name"},
+		},
+	}
+	relation := schema.Relation{
+		Type:      schema.RelationManyToMany,
+		Schema:    "pk_account_roles",
+		PivotKeys: schema.PivotKeys{Foreign: []string{"account_org", "account_uname"}, Related: []string{"role_id"}},
+	}
+	accounts.Relations = map[string]schema.Relation{"roles": relation}
+
+	db, schemas := newTestSchemas(t, accounts, roles)
+	m := schemas.MustGet("pk_accounts")
+	tt.Equal([]string{"org", "uname"}, m.PrimaryKeys())
+	tt.Equal(false, zarray.Contains(m.GetFields(), IDKey()))
+
+	store := m.Model()
+	key, err := store.Insert(ztype.Map{"org": "zls", "uname": "a", "nick": "A"})
+	tt.NoError(err)
+	tt.Equal(ztype.Map{"org": "zls", "uname": "a"}, key)
+	_, err = store.Insert(ztype.Map{"org": "zls", "uname": "a"})
+	tt.Equal(true, err != nil)
+
+	keys, err := store.InsertMany(ztype.Maps{{"org": "zls", "uname": "b"}, {"org": "go", "uname": "a"}})
+	tt.NoError(err)
+	tt.Equal(ztype.Map{"org": "go", "uname": "a"}, keys[1])
+
+	_, err = store.FindOneByKey(ztype.Map{"org": "zls"})
+	tt.Equal(ErrInvalidKey, err)
+
+	total, err := store.UpdateByKey(ztype.Map{"org": "zls", "uname": "a"}, ztype.Map{"nick": "AA", "uname": "ignored"})
+	tt.NoError(err)
+	tt.Equal(int64(1), total)
+	row, err := store.FindOneByKey(ztype.Map{"org": "zls", "uname": "a"})
+	tt.NoError(err)
+	tt.Equal("AA", row.Get("nick").String())
+
+	rid, err := schemas.MustGet("pk_account_roles").Model().Insert(ztype.Map{"name": "admin"})
+	tt.NoError(err)
+	pm := NewPivotManager(m)
+	tt.NoError(pm.SyncPivotSchema(&relation))
+	pivotTable, err := pm.GetPivotTableName(&relation)
+	tt.NoError(err)
+	_, err = db.Exec("INSERT INTO "+pivotTable+" (account_org, account_uname, role_id) VALUES (?, ?, ?)", "zls", "a", rid)
+	tt.NoError(err)
+	_, err = db.Exec("INSERT INTO "+pivotTable+" (account_org, account_uname, role_id) VALUES (?, ?, ?)", "zls", "a", rid)
+	tt.Equal(true, err != nil)
+
+	rows, err := store.Repository().Query().Where("org", "zls").OrderBy("uname").WithRelation("roles.name").Find()
+	tt.NoError(err)
+	tt.Equal(2, len(rows))
+	roleRows, _ := rows[0].Get("roles").Value().(ztype.Maps)
+	tt.Equal(1, len(roleRows))
+	tt.Equal("admin", roleRows[0].Get("name").String())
+
+	total, err = store.DeleteByKey(ztype.Map{"org": "zls", "uname": "b"})
+	tt.NoError(err)
+	tt.Equal(int64(1), total)
+	count, err := store.Count(Filter{})
+	tt.NoError(err)
+	tt.Equal(uint64(2), count)
+
+	// 复合主键模型没有 id 列，按 id 操作返回错误且不影响任何记录
+	_, err = store.DeleteByID("zls")
+	tt.Equal(ErrInvalidKey, err)
+	_, err = store.UpdateByID("zls", ztype.Map{"nick": "x"})
+	tt.Equal(ErrInvalidKey, err)
+	_, err = store.FindOneByID("zls")
+	tt.Equal(ErrInvalidKey, err)
+	_, err = store.Repository().DeleteByIDs([]any{"zls"})
+	tt.Equal(ErrInvalidKey, err)
+	_, err = store.Repository().Query().WhereID("zls").Delete()
+	tt.Equal(ErrInvalidKey, err)
+	count, err = store.Count(Filter{})
+	tt.NoError(err)
+	tt.Equal(uint64(2), count)
+}
+
+func TestPrimaryKeyUnknownType(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	_, schemas := newTestSchemas(t)
+	// 未知的主键策略不能静默回退为自增主键
+	_, err := schemas.Reg("pk_unknown", schema.Schema{
+		Name:    "pk_unknown",
+		Table:   schema.Table{Name: "pk_unknown"},
+		Options: schema.Options{PrimaryKey: &schema.PrimaryKey{Type: "uuidv7"}},
+		Fields: map[string]schema.Field{
+			"name": {Type: "string", Label: "Name"},
+		},
+	}, false)
+	tt.EqualTrue(errors.Is(err, ErrInvalidPrimaryKey))
+	_, ok := schemas.Get("pk_unknown")
+	tt.Equal(false, ok)
+}
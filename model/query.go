@@ -21,6 +21,9 @@ type Query[T any, F any, C any, U any] struct {
 	limit     int
 	offset    int
 	relations []string
+	err       error
+	lock      LockMode
+	lockWait  LockWait
 }
 
 const (
@@ -83,10 +86,15 @@ func (q *Query[T, F, C, U]) WhereFilter(filter F) *Query[T, F, C, U] {
 
 // WhereID 添加 ID 条件
 func (q *Query[T, F, C, U]) WhereID(id any) *Query[T, F, C, U] {
-	return q.appendFilter(ID(id))
+	filter, err := q.repo.store.schema.idFilter(id)
+	if err != nil {
+		q.err = err
+		return q
+	}
+	return q.appendFilter(filter)
 }
 
-// WhereIn 添加 IN 条件
+// WhereIn 添加 IN 条件，values 可以是子查询
 func (q *Query[T, F, C, U]) WhereIn(field string, values any) *Query[T, F, C, U] {
 	return q.appendFilter(In(field, values))
 }
@@ -136,6 +144,36 @@ func (q *Query[T, F, C, U]) WhereNotNull(field string) *Query[T, F, C, U] {
 	return q.appendFilter(IsNotNull(field))
 }
 
+// WhereNotIn 添加 NOT IN 条件，values 可以是子查询
+func (q *Query[T, F, C, U]) WhereNotIn(field string, values any) *Query[T, F, C, U] {
+	return q.appendFilter(NotIn(field, values))
+}
+
+// WhereExists 添加 EXISTS 子查询条件
+func (q *Query[T, F, C, U]) WhereExists(query SubQuery) *Query[T, F, C, U] {
+	return q.appendFilter(Exists(query))
+}
+
+// WhereNotExists 添加 NOT EXISTS 子查询条件
+func (q *Query[T, F, C, U]) WhereNotExists(query SubQuery) *Query[T, F, C, U] {
+	return q.appendFilter(NotExists(query))
+}
+
+// WhereColumn 添加字段与字段相等的条件
+func (q *Query[T, F, C, U]) WhereColumn(a, b string) *Query[T, F, C, U] {
+	return q.appendFilter(ColEq(a, b))
+}
+
+// WhereRaw 添加参数化的原生 SQL 条件
+func (q *Query[T, F, C, U]) WhereRaw(expr string, args ...any) *Query[T, F, C, U] {
+	return q.appendFilter(Raw(expr, args...))
+}
+
+// WhereExpr 添加任意过滤器条件，用于组合子查询、字段比较等表达式
+func (q *Query[T, F, C, U]) WhereExpr(filters ...QueryFilter) *Query[T, F, C, U] {
+	return q.appendFilter(And(filters...))
+}
+
 // OrWhere adds an OR condition that groups the provided filters.
 // Note: This creates "AND (filter1 OR filter2 OR ...)" pattern.
 // For a pure OR without preceding AND, use repo.Find(Or(...)) when F is QueryFilter.
@@ -150,6 +188,16 @@ func (q *Query[T, F, C, U]) OrWhere(filters ...F) *Query[T, F, C, U] {
 	return q.appendFilter(Or(orFilters...))
 }
 
+// Scope 应用命名作用域
+func (q *Query[T, F, C, U]) Scope(names ...string) *Query[T, F, C, U] {
+	return q.appendFilter(Scope(names...))
+}
+
+// WithoutScope 排除全局作用域，不传名称时排除全部
+func (q *Query[T, F, C, U]) WithoutScope(names ...string) *Query[T, F, C, U] {
+	return q.appendFilter(WithoutScope(names...))
+}
+
 // Select 设置查询字段
 func (q *Query[T, F, C, U]) Select(fields ...string) *Query[T, F, C, U] {
 	q.fields = fields
@@ -196,6 +244,35 @@ func (q *Query[T, F, C, U]) WithRelation(names ...string) *Query[T, F, C, U] {
 	return q
 }
 
+// ForUpdate 对查询到的行加排他锁，必须在事务中使用
+func (q *Query[T, F, C, U]) ForUpdate() *Query[T, F, C, U] {
+	q.lock = LockForUpdate
+	return q
+}
+
+// ForShare 对查询到的行加共享锁，必须在事务中使用
+func (q *Query[T, F, C, U]) ForShare() *Query[T, F, C, U] {
+	q.lock = LockForShare
+	return q
+}
+
+// SkipLocked 跳过已被其他事务锁定的行，常用于任务领取
+func (q *Query[T, F, C, U]) SkipLocked() *Query[T, F, C, U] {
+	q.lockWait = LockSkipLocked
+	return q
+}
+
+// NoWait 行已被锁定时立即返回错误而不等待
+func (q *Query[T, F, C, U]) NoWait() *Query[T, F, C, U] {
+	q.lockWait = LockNoWait
+	return q
+}
+
+// subQuery 作为子查询使用时的模型、条件与字段
+func (q *Query[T, F, C, U]) subQuery() (*Schema, QueryFilter, []string) {
+	return q.repo.store.schema, q.filter, q.fields
+}
+
 // buildCondOptions 构建查询条件选项
 func (q *Query[T, F, C, U]) buildCondOptions() func(*CondOptions) {
 	return func(opts *CondOptions) {
@@ -217,11 +294,18 @@ func (q *Query[T, F, C, U]) buildCondOptions() func(*CondOptions) {
 		if len(q.relations) > 0 {
 			opts.Relations = append(opts.Relations[:0], q.relations...)
 		}
+		if q.lock != LockNone {
+			opts.Lock = q.lock
+			opts.LockWait = q.lockWait
+		}
 	}
 }
 
 // Find 执行查询并返回多条记录
 func (q *Query[T, F, C, U]) Find() ([]T, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
 	return q.repo.find(q.filter, q.buildCondOptions())
 }
 
@@ -247,25 +331,40 @@ func (q *Query[T, F, C, U]) First() (T, error) {
 
 // Count 统计符合条件的记录数量
 func (q *Query[T, F, C, U]) Count() (uint64, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
 	return q.repo.store.Count(q.filter, q.buildCondOptions())
 }
 
 // Exists 检查符合条件的记录是否存在
 func (q *Query[T, F, C, U]) Exists() (bool, error) {
+	if q.err != nil {
+		return false, q.err
+	}
 	return q.repo.store.Exists(q.filter, q.buildCondOptions())
 }
 
 // Pages 分页查询记录
 func (q *Query[T, F, C, U]) Pages(page, pagesize int) (*RepositoryPageData[T], error) {
+	if q.err != nil {
+		return nil, q.err
+	}
 	return q.repo.pages(page, pagesize, q.filter, q.buildCondOptions())
 }
 
 // Update 更新符合条件的记录
 func (q *Query[T, F, C, U]) Update(data U) (int64, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
 	return q.repo.store.Update(q.filter, data, q.buildCondOptions())
 }
 
 // Delete 删除符合条件的记录
 func (q *Query[T, F, C, U]) Delete() (int64, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
 	return q.repo.store.Delete(q.filter, q.buildCondOptions())
 }
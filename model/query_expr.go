@@ -0,0 +1,274 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/zdb/builder"
+)
+
+// SubQuery 可作为子查询使用的查询构建器
+type SubQuery interface {
+	subQuery() (m *Schema, filter QueryFilter, fields []string)
+}
+
+type condBuilder func(*builder.BuildCond) (string, error)
+
+// exprFilter 需要结合模型校验字段后才能生成的条件
+type exprFilter struct {
+	build func(m *Schema) (condBuilder, error)
+}
+
+func (f exprFilter) ToMap() ztype.Map {
+	return f.appendToMap(make(ztype.Map, 1))
+}
+
+func (f exprFilter) appendToMap(dst ztype.Map) ztype.Map {
+	if dst == nil {
+		dst = make(ztype.Map, 1)
+	}
+	id := atomic.AddUint64(&condCounter, 1)
+	dst[placeHolder+strconv.FormatUint(id, 10)] = f
+	return dst
+}
+
+// Exists 子查询存在记录
+func Exists(query SubQuery) QueryFilter {
+	return existsFilter("EXISTS", query)
+}
+
+// NotExists 子查询不存在记录
+func NotExists(query SubQuery) QueryFilter {
+	return existsFilter("NOT EXISTS", query)
+}
+
+// ColEq 字段与字段相等，支持 表名.字段 引用外层查询的字段
+func ColEq(a, b string) QueryFilter {
+	return colCompare(a, "=", b)
+}
+
+// ColNe 字段与字段不相等
+func ColNe(a, b string) QueryFilter {
+	return colCompare(a, "<>", b)
+}
+
+// ColGt 字段大于另一字段
+func ColGt(a, b string) QueryFilter {
+	return colCompare(a, ">", b)
+}
+
+// ColGe 字段大于等于另一字段
+func ColGe(a, b string) QueryFilter {
+	return colCompare(a, ">=", b)
+}
+
+// ColLt 字段小于另一字段
+func ColLt(a, b string) QueryFilter {
+	return colCompare(a, "<", b)
+}
+
+// ColLe 字段小于等于另一字段
+func ColLe(a, b string) QueryFilter {
+	return colCompare(a, "<=", b)
+}
+
+// Raw 原生 SQL 条件片段，? 为参数占位符，参数为子查询时展开为 (SELECT ...)
+func Raw(expr string, args ...any) QueryFilter {
+	return exprFilter{build: func(m *Schema) (condBuilder, error) {
+		parts := strings.Split(expr, "?")
+		if len(parts)-1 != len(args) {
+			return nil, fmt.Errorf("%w: %d placeholders but %d args", ErrInvalidExpr, len(parts)-1, len(args))
+		}
+
+		subs := make(map[int]condBuilder)
+		for i := range args {
+			if sq, ok := args[i].(SubQuery); ok {
+				sub, err := buildSubQuery(sq, true)
+				if err != nil {
+					return nil, err
+				}
+				subs[i] = sub
+			}
+		}
+
+		return func(c *builder.BuildCond) (string, error) {
+			var b strings.Builder
+			for i := range parts {
+				b.WriteString(parts[i])
+				if i >= len(args) {
+					continue
+				}
+				if sub, ok := subs[i]; ok {
+					sql, err := sub(c)
+					if err != nil {
+						return "", err
+					}
+					b.WriteString("(" + sql + ")")
+					continue
+				}
+				b.WriteString(c.Var(args[i]))
+			}
+			return "(" + b.String() + ")", nil
+		}, nil
+	}}
+}
+
+func subQueryIn(field, op string, query SubQuery) QueryFilter {
+	return exprFilter{build: func(m *Schema) (condBuilder, error) {
+		if err := m.checkColumn(field); err != nil {
+			return nil, err
+		}
+		sub, err := buildSubQuery(query, true)
+		if err != nil {
+			return nil, err
+		}
+		return func(c *builder.BuildCond) (string, error) {
+			sql, err := sub(c)
+			if err != nil {
+				return "", err
+			}
+			return field + " " + op + " (" + sql + ")", nil
+		}, nil
+	}}
+}
+
+func existsFilter(op string, query SubQuery) QueryFilter {
+	return exprFilter{build: func(m *Schema) (condBuilder, error) {
+		sub, err := buildSubQuery(query, false)
+		if err != nil {
+			return nil, err
+		}
+		return func(c *builder.BuildCond) (string, error) {
+			sql, err := sub(c)
+			if err != nil {
+				return "", err
+			}
+			return op + " (" + sql + ")", nil
+		}, nil
+	}}
+}
+
+func colCompare(a, op, b string) QueryFilter {
+	return exprFilter{build: func(m *Schema) (condBuilder, error) {
+		if err := m.checkColumn(a); err != nil {
+			return nil, err
+		}
+		if err := m.checkColumn(b); err != nil {
+			return nil, err
+		}
+		expr := a + " " + op + " " + b
+		return func(*builder.BuildCond) (string, error) {
+			return expr, nil
+		}, nil
+	}}
+}
+
+// buildSubQuery 生成子查询语句，条件与外层查询共用参数列表
+func buildSubQuery(query SubQuery, selectField bool) (condBuilder, error) {
+	if query == nil {
+		return nil, ErrInvalidSubQuery
+	}
+	m, filter, fields := query.subQuery()
+	s, ok := m.Storage.(*SQL)
+	if !ok {
+		return nil, fmt.Errorf("%w: requires sql storage", ErrInvalidSubQuery)
+	}
+
+	column := "1"
+	if selectField {
+		if len(fields) != 1 {
+			return nil, fmt.Errorf("%w: must select exactly one field", ErrInvalidSubQuery)
+		}
+		if err := m.checkColumn(fields[0]); err != nil {
+			return nil, err
+		}
+		column = fields[0]
+	}
+
+	filterMap, err := getFilter(m, filter)
+	if err != nil {
+		return nil, err
+	}
+	_ = m.DeCrypt(filterMap)
+	if err := m.tenantFilter(filterMap); err != nil {
+		return nil, err
+	}
+
+	table := m.GetTableName()
+	return func(c *builder.BuildCond) (string, error) {
+		exprs, err := s.parseExprs(c, filterMap)
+		if err != nil {
+			return "", err
+		}
+		sql := "SELECT " + column + " FROM " + table
+		if len(exprs) > 0 {
+			sql += " WHERE " + c.And(exprs...)
+		}
+		return sql, nil
+	}, nil
+}
+
+// resolveExprFilters 结合模型解析过滤条件中的表达式，校验失败时查询返回错误
+func resolveExprFilters(m *Schema, filter ztype.Map) ztype.Map {
+	for k, v := range filter {
+		switch val := v.(type) {
+		case exprFilter:
+			fn, err := val.build(m)
+			if err != nil {
+				fn = func(*builder.BuildCond) (string, error) {
+					return "", err
+				}
+			}
+			filter[k] = fn
+		case ztype.Map:
+			filter[k] = resolveExprFilters(m, cloneFilterMap(val))
+		case ztype.Maps:
+			maps := make(ztype.Maps, len(val))
+			for i := range val {
+				maps[i] = resolveExprFilters(m, cloneFilterMap(val[i]))
+			}
+			filter[k] = maps
+		case []ztype.Map:
+			maps := make([]ztype.Map, len(val))
+			for i := range val {
+				maps[i] = resolveExprFilters(m, cloneFilterMap(val[i]))
+			}
+			filter[k] = maps
+		}
+	}
+	return filter
+}
+
+// checkColumn 校验表达式中引用的字段，其他表的字段只校验标识符
+func (m *Schema) checkColumn(column string) error {
+	table, field, qualified := strings.Cut(column, ".")
+	if !qualified {
+		field, table = table, ""
+	}
+	if !isIdentifier(field) || (qualified && !isIdentifier(table)) {
+		return fmt.Errorf("%w: %s", ErrUnknownField, column)
+	}
+	if qualified && table != m.GetTableName() {
+		return nil
+	}
+	if field != DeletedAtKey && !m.hasFilterField(field) {
+		return fmt.Errorf("%w: %s", ErrUnknownField, column)
+	}
+	return nil
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
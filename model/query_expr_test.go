@@ -0,0 +1,105 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func TestQueryExpr(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	users := schema.Schema{
+		Name:  "expr_users",
+		Table: schema.Table{Name: "expr_users"},
+		Fields: map[string]schema.Field{
+			"name":  {Type: schema.String, Size: 50},
+			"quota": {Type: schema.Int},
+			"used":  {Type: schema.Int},
+		},
+	}
+	orders := schema.Schema{
+		Name:  "expr_orders",
+		Table: schema.Table{Name: "expr_orders"},
+		Fields: map[string]schema.Field{
+			"user_id": {Type: schema.Int},
+			"amount":  {Type: schema.Int},
+		},
+	}
+	_, schemas := newTestSchemas(t, users, orders)
+	userRepo := schemas.MustGet("expr_users").Model().Repository()
+	orderRepo := schemas.MustGet("expr_orders").Model().Repository()
+
+	ids := make([]any, 0, 3)
+	for _, u := range []ztype.Map{
+		{"name": "a", "quota": 10, "used": 10},
+		{"name": "b", "quota": 10, "used": 3},
+		{"name": "c", "quota": 5, "used": 8},
+	} {
+		id, err := userRepo.Insert(u)
+		tt.NoError(err)
+		ids = append(ids, id)
+	}
+	for _, o := range []ztype.Map{
+		{"user_id": ids[0], "amount": 100},
+		{"user_id": ids[0], "amount": 20},
+		{"user_id": ids[2], "amount": 50},
+	} {
+		_, err := orderRepo.Insert(o)
+		tt.NoError(err)
+	}
+
+	names := func(rows []ztype.Map) []string {
+		s := make([]string, 0, len(rows))
+		for _, r := range rows {
+			s = append(s, r.Get("name").String())
+		}
+		return s
+	}
+
+	rows, err := userRepo.Query().
+		WhereIn("id", orderRepo.Query().WhereGt("amount", 60).Select("user_id")).
+		Find()
+	tt.NoError(err)
+	tt.Equal([]string{"a"}, names(rows))
+
+	rows, err = userRepo.Query().WhereNotIn("id", orderRepo.Query().Select("user_id")).Find()
+	tt.NoError(err)
+	tt.Equal([]string{"b"}, names(rows))
+
+	rows, err = userRepo.Query().
+		WhereExists(orderRepo.Query().WhereColumn("user_id", "expr_users.id").WhereLt("amount", 60)).
+		OrderBy("id").
+		Find()
+	tt.NoError(err)
+	tt.Equal([]string{"a", "c"}, names(rows))
+
+	rows, err = userRepo.Query().WhereExpr(ColGe("used", "quota")).OrderBy("id").Find()
+	tt.NoError(err)
+	tt.Equal([]string{"a", "c"}, names(rows))
+
+	rows, err = userRepo.Query().WhereRaw("quota - used > ? + 1", 5).Find()
+	tt.NoError(err)
+	tt.Equal([]string{"b"}, names(rows))
+
+	rows, err = userRepo.Query().
+		WhereExpr(Or(Eq("name", "b"), Raw("id IN ?", orderRepo.Query().WhereGt("amount", 60).Select("user_id")))).
+		OrderBy("id").
+		Find()
+	tt.NoError(err)
+	tt.Equal([]string{"a", "b"}, names(rows))
+
+	_, err = userRepo.Query().WhereIn("nope", orderRepo.Query().Select("user_id")).Find()
+	tt.Equal(true, errors.Is(err, ErrUnknownField))
+	_, err = userRepo.Query().WhereIn("id", orderRepo.Query().Select("missing")).Find()
+	tt.Equal(true, errors.Is(err, ErrUnknownField))
+	_, err = userRepo.Query().WhereIn("id", orderRepo.Query()).Find()
+	tt.Equal(true, errors.Is(err, ErrInvalidSubQuery))
+	_, err = userRepo.Query().WhereColumn("used", "quota; DROP TABLE expr_users").Find()
+	tt.Equal(true, errors.Is(err, ErrUnknownField))
+	_, err = userRepo.Query().WhereRaw("quota > ?").Find()
+	tt.Equal(true, errors.Is(err, ErrInvalidExpr))
+}
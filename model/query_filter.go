@@ -116,10 +116,16 @@ func Le(field string, value any) QueryFilter {
 }
 
 func In(field string, values any) QueryFilter {
+	if query, ok := values.(SubQuery); ok {
+		return subQueryIn(field, "IN", query)
+	}
 	return conditionFilter{field: field, op: "IN", value: values}
 }
 
 func NotIn(field string, values any) QueryFilter {
+	if query, ok := values.(SubQuery); ok {
+		return subQueryIn(field, "NOT IN", query)
+	}
 	return conditionFilter{field: field, op: "NOT IN", value: values}
 }
 
@@ -256,6 +262,8 @@ func isEmptyQueryFilter(filter QueryFilter) bool {
 		return len(v.filters) == 0
 	case orFilter:
 		return len(v.filters) == 0
+	case scopeFilter:
+		return len(v.names) == 0
 	default:
 		return false
 	}
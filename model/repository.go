@@ -1,6 +1,8 @@
 package model
 
 import (
+	"context"
+
 	"github.com/sohaha/zlsgo/ztype"
 )
 
@@ -44,6 +46,14 @@ func (r *Repository[T, F, C, U]) Schema() *Schema {
 	return r.store.schema
 }
 
+// WithContext 返回绑定上下文的仓储实例
+func (r *Repository[T, F, C, U]) WithContext(ctx context.Context) *Repository[T, F, C, U] {
+	return &Repository[T, F, C, U]{
+		store:  r.store.WithContext(ctx),
+		mapper: r.mapper,
+	}
+}
+
 // find 查找多条记录
 func (r *Repository[T, F, C, U]) find(filter QueryFilter, fn ...func(*CondOptions)) ([]T, error) {
 	rows, err := r.store.Find(filter, fn...)
@@ -98,7 +108,12 @@ func (r *Repository[T, F, C, U]) FindOne(filter F, fn ...func(*CondOptions)) (T,
 
 // FindByID 根据 ID 查找记录
 func (r *Repository[T, F, C, U]) FindByID(id any, fn ...func(*CondOptions)) (T, error) {
-	return r.findOne(ID(id), fn...)
+	filter, err := r.store.schema.idFilter(id)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return r.findOne(filter, fn...)
 }
 
 // First 查找第一条记录
@@ -108,7 +123,11 @@ func (r *Repository[T, F, C, U]) First(filter F, fn ...func(*CondOptions)) (T, e
 
 // FindByIDs 根据 ID 列表查找多条记录
 func (r *Repository[T, F, C, U]) FindByIDs(ids []any, fn ...func(*CondOptions)) ([]T, error) {
-	return r.find(In(idKey, ids), fn...)
+	filter, err := r.store.schema.idsFilter(ids)
+	if err != nil {
+		return nil, err
+	}
+	return r.find(filter, fn...)
 }
 
 // All 查找所有记录
@@ -153,12 +172,16 @@ func (r *Repository[T, F, C, U]) UpdateMany(filter F, data U, fn ...func(*CondOp
 
 // UpdateByID 根据 ID 更新记录
 func (r *Repository[T, F, C, U]) UpdateByID(id any, data U, fn ...func(*CondOptions)) (int64, error) {
-	return r.store.Update(ID(id), data, fn...)
+	return r.store.UpdateByID(id, data, fn...)
 }
 
 // UpdateByIDs 根据 ID 列表批量更新记录
 func (r *Repository[T, F, C, U]) UpdateByIDs(ids []any, data U, fn ...func(*CondOptions)) (int64, error) {
-	return r.store.UpdateMany(In(idKey, ids), data, fn...)
+	filter, err := r.store.schema.idsFilter(ids)
+	if err != nil {
+		return 0, err
+	}
+	return r.store.UpdateMany(filter, data, fn...)
 }
 
 // Delete 删除符合条件的记录
@@ -173,27 +196,48 @@ func (r *Repository[T, F, C, U]) DeleteMany(filter F, fn ...func(*CondOptions))
 
 // DeleteByID 根据 ID 删除记录
 func (r *Repository[T, F, C, U]) DeleteByID(id any, fn ...func(*CondOptions)) (int64, error) {
-	return r.store.Delete(ID(id), fn...)
+	return r.store.DeleteByID(id, fn...)
 }
 
 // DeleteByIDs 根据 ID 列表批量删除记录
 func (r *Repository[T, F, C, U]) DeleteByIDs(ids []any, fn ...func(*CondOptions)) (int64, error) {
-	return r.store.DeleteMany(In(idKey, ids), fn...)
+	filter, err := r.store.schema.idsFilter(ids)
+	if err != nil {
+		return 0, err
+	}
+	return r.store.DeleteMany(filter, fn...)
 }
 
-// Tx 在事务中执行操作
-func (r *Repository[T, F, C, U]) Tx(fn func(txRepo *Repository[T, F, C, U]) error) error {
-	storage := r.store.schema.Storage
+// Restore 恢复软删除的记录
+func (r *Repository[T, F, C, U]) Restore(filter F, fn ...func(*CondOptions)) (int64, error) {
+	return r.store.Restore(Q(filter), fn...)
+}
 
-	return storage.Transaction(func(txStorage Storageer) error {
-		txSchema := cloneSchemaWithStorage(r.store.schema, txStorage)
+// RestoreByID 根据 ID 恢复软删除的记录
+func (r *Repository[T, F, C, U]) RestoreByID(id any, fn ...func(*CondOptions)) (int64, error) {
+	return r.store.RestoreByID(id, fn...)
+}
+
+// Tx 在事务中执行操作，在事务中再次调用时使用保存点，内层失败只回滚内层的变更
+func (r *Repository[T, F, C, U]) Tx(fn func(txRepo *Repository[T, F, C, U]) error, opts ...TxOption) error {
+	return transaction(r.store.schema, func(txSchema *Schema) error {
 		txStore := &Store{schema: txSchema}
 		txRepo := &Repository[T, F, C, U]{
 			store:  txStore,
 			mapper: r.mapper,
 		}
 		return fn(txRepo)
-	})
+	}, opts...)
+}
+
+// AfterCommit 注册事务提交后执行的回调
+func (r *Repository[T, F, C, U]) AfterCommit(fn func()) {
+	r.store.AfterCommit(fn)
+}
+
+// AfterRollback 注册事务回滚后执行的回调
+func (r *Repository[T, F, C, U]) AfterRollback(fn func()) {
+	r.store.AfterRollback(fn)
 }
 
 // RepositoryPageData 仓储分页数据
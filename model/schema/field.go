@@ -21,7 +21,9 @@ type (
 		After       []string        `json:"-"`
 		ValidRules  zvalid.Engine   `json:"-"`
 		// 如果是数字类型则为长度，如果是字符串类型则为最大长度
-		Size     uint64 `json:"size,omitempty"`
+		Size uint64 `json:"size,omitempty"`
+		// Scale 定点数的小数位数
+		Scale    uint64 `json:"scale,omitempty"`
 		Nullable bool   `json:"nullable,omitempty"`
 		// quoteName   string `json:"-"`
 	}
@@ -34,14 +36,32 @@ type (
 	FieldOption struct {
 		FormatTime       string      `json:"format_time,omitempty"`
 		Crypt            string      `json:"crypt,omitempty"`
+		BlindIndex       bool        `json:"blind_index,omitempty"`
 		Enum             []FieldEnum `json:"enum,omitempty"`
 		IsArray          bool        `json:"is_array,omitempty"`
 		ReadOnly         bool        `json:"readonly,omitempty"`
 		DisableMigration bool        `json:"disable_migration,omitempty"`
+		EnumCheck        bool        `json:"enum_check,omitempty"`
+		// Visibility 字段可见性策略，VisibleRoles 中的角色不受限制
+		Visibility   Visibility `json:"visibility,omitempty"`
+		Mask         string     `json:"mask,omitempty"`
+		VisibleRoles []string   `json:"visible_roles,omitempty"`
 		// Quote      bool        `json:"quote"`
 	}
 )
 
+// Visibility 字段可见性策略
+type Visibility string
+
+const (
+	// VisibilityVisible 默认可见
+	VisibilityVisible Visibility = ""
+	// VisibilityHidden 对调用方隐藏
+	VisibilityHidden Visibility = "hidden"
+	// VisibilityMasked 对调用方脱敏显示
+	VisibilityMasked Visibility = "masked"
+)
+
 func (f *Field) GetValidations() *zvalid.Engine {
 	return &f.ValidRules
 }
@@ -1,20 +1,74 @@
 package schema
 
 import (
+	"github.com/sohaha/zlsgo/ztype"
 	"github.com/zlsgo/app_module/model/hook"
 )
 
+type (
+	// Scopes 查询作用域集合
+	Scopes map[string]Scope
+	// Scope 查询作用域定义，Filter 与 Func 可同时存在
+	Scope struct {
+		Filter ztype.Map `json:"filter,omitempty"`
+		// Func 在查询条件上追加条件，仅支持代码定义
+		Func func(filter ztype.Map) `json:"-"`
+		// Global 全局作用域，默认应用于所有查询、更新与删除
+		Global bool `json:"global,omitempty"`
+	}
+)
+
+// TenancyMode 多租户模式
+type TenancyMode string
+
+const (
+	// TenancyNone 不启用多租户
+	TenancyNone TenancyMode = "none"
+	// TenancyColumn 共享表，通过 tenant_id 列隔离
+	TenancyColumn TenancyMode = "column"
+	// TenancyPrefix 每个租户独立表前缀
+	TenancyPrefix TenancyMode = "prefix"
+)
+
+// PrimaryKeyType 主键生成策略
+type PrimaryKeyType string
+
+const (
+	// PrimaryKeyAutoInc 数据库自增主键
+	PrimaryKeyAutoInc PrimaryKeyType = "autoinc"
+	// PrimaryKeyUUID 写入时生成 UUID 字符串主键
+	PrimaryKeyUUID PrimaryKeyType = "uuid"
+	// PrimaryKeyULID 写入时生成 ULID 字符串主键，按时间有序
+	PrimaryKeyULID PrimaryKeyType = "ulid"
+	// PrimaryKeySnowflake 写入时生成雪花算法整数主键
+	PrimaryKeySnowflake PrimaryKeyType = "snowflake"
+)
+
+// PrimaryKey 主键配置，Fields 不为空时使用声明的字段作为（复合）主键，不再生成 id 列
+type PrimaryKey struct {
+	Type   PrimaryKeyType `json:"type,omitempty"`
+	Fields []string       `json:"fields,omitempty"`
+}
+
 type Options struct {
-	DisabledMigrator *bool `json:"disabled_migrator,omitempty"`
-	SoftDeletes      *bool `json:"soft_deletes,omitempty"`
-	SoftDeleteIsTime *bool `json:"soft_delete_is_time,omitempty"`
-	Timestamps       *bool `json:"timestamps,omitempty"`
-	CryptID          *bool `json:"crypt_id,omitempty"`
-	Hook             func(event hook.Event, data ...any) error
-	Salt             string   `json:"crypt_salt,omitempty"`
-	LowFields        []string `json:"low_fields,omitempty"`
-	FieldsSort       []string `json:"fields_sort,omitempty"`
-	CryptLen         int      `json:"crypt_len,omitempty"`
+	DisabledMigrator *bool       `json:"disabled_migrator,omitempty"`
+	SoftDeletes      *bool       `json:"soft_deletes,omitempty"`
+	SoftDeleteIsTime *bool       `json:"soft_delete_is_time,omitempty"`
+	Timestamps       *bool       `json:"timestamps,omitempty"`
+	CryptID          *bool       `json:"crypt_id,omitempty"`
+	Audit            *bool       `json:"audit,omitempty"`
+	Outbox           *bool       `json:"outbox,omitempty"`
+	PrimaryKey       *PrimaryKey `json:"primary_key,omitempty"`
+	Scopes           Scopes      `json:"scopes,omitempty"`
+	Tenancy          TenancyMode `json:"tenancy,omitempty"`
+	Salt             string      `json:"crypt_salt,omitempty"`
+	LowFields        []string    `json:"low_fields,omitempty"`
+	FieldsSort       []string    `json:"fields_sort,omitempty"`
+	CryptLen         int         `json:"crypt_len,omitempty"`
+	// TimeFormat 时间字段默认的输出格式，"rfc3339" 输出 RFC3339
+	TimeFormat string `json:"time_format,omitempty"`
+	// Hook 模型事件回调，仅在运行时设置，不参与序列化
+	Hook func(event hook.Event, data ...any) error `json:"-"`
 }
 
 func (o *Options) SetDisabledMigrator(b bool) *Options {
@@ -56,3 +110,36 @@ func (o *Options) SetHook(h func(event hook.Event, data ...any) error) *Options
 	o.Hook = h
 	return o
 }
+
+func (o *Options) SetScope(name string, scope Scope) *Options {
+	if o.Scopes == nil {
+		o.Scopes = Scopes{}
+	}
+	o.Scopes[name] = scope
+	return o
+}
+
+func (o *Options) SetTenancy(mode TenancyMode) *Options {
+	o.Tenancy = mode
+	return o
+}
+
+func (o *Options) SetAudit(b bool) *Options {
+	o.Audit = &b
+	return o
+}
+
+func (o *Options) SetOutbox(b bool) *Options {
+	o.Outbox = &b
+	return o
+}
+
+func (o *Options) SetPrimaryKey(t PrimaryKeyType) *Options {
+	o.PrimaryKey = &PrimaryKey{Type: t}
+	return o
+}
+
+func (o *Options) SetCompositeKey(fields ...string) *Options {
+	o.PrimaryKey = &PrimaryKey{Fields: fields}
+	return o
+}
@@ -175,6 +175,7 @@ func buildFieldsFromType(t reflect.Type) Fields {
 			Label:    name,
 			Nullable: nullable,
 		}
+		applyTypeEnum(ft, &field)
 
 		parseFieldTag(fieldTag, &field)
 		fields[name] = field
@@ -245,6 +246,7 @@ func parseSchemaFromType(t reflect.Type, s *Schema, rootName string, allowName,
 			Label:    name,
 			Nullable: nullable,
 		}
+		applyTypeEnum(ft, &field)
 
 		parseFieldTag(fieldTag, &field)
 		if s.Fields == nil {
@@ -298,10 +300,46 @@ func camelToSnake(name string) string {
 	return b.String()
 }
 
+// Enumer 字段类型实现该接口时，结构体解析会读取其枚举值，`enum` tag 优先
+type Enumer interface {
+	EnumValues() []FieldEnum
+}
+
+var enumerType = reflect.TypeOf((*Enumer)(nil)).Elem()
+
+// decimalPkgPath model.Decimal 所在的包，schema 包不能引用 model 包，只能按路径匹配
+const decimalPkgPath = "github.com/zlsgo/app_module/model"
+
+// applyTypeEnum 从实现 Enumer 的字段类型读取枚举值，元素实现 Enumer 的切片视为多选
+func applyTypeEnum(t reflect.Type, f *Field) {
+	isArray := false
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		t, isArray = t.Elem(), true
+	}
+
+	var e Enumer
+	switch {
+	case t.Implements(enumerType):
+		e, _ = reflect.Zero(t).Interface().(Enumer)
+	case reflect.PointerTo(t).Implements(enumerType):
+		e, _ = reflect.New(t).Interface().(Enumer)
+	}
+	if e == nil {
+		return
+	}
+	f.Options.Enum = e.EnumValues()
+	if isArray {
+		f.Options.IsArray = true
+	}
+}
+
 func goTypeToSchemaType(t reflect.Type) schema.DataType {
 	if t == reflect.TypeOf(time.Time{}) {
 		return Time
 	}
+	if t.Name() == "Decimal" && t.PkgPath() == decimalPkgPath {
+		return Decimal
+	}
 
 	switch t.Kind() {
 	case reflect.Bool:
@@ -363,8 +401,10 @@ func parseFieldTag(tag string, f *Field) {
 		switch key {
 		case "type":
 			f.Type = schema.DataType(val)
-		case "size":
+		case "size", "precision":
 			f.Size, _ = strconv.ParseUint(val, 10, 64)
+		case "scale":
+			f.Scale, _ = strconv.ParseUint(val, 10, 64)
 		case "label":
 			f.Label = val
 		case "default":
@@ -381,16 +421,29 @@ func parseFieldTag(tag string, f *Field) {
 			f.Options.ReadOnly = parseBoolDefaultTrue(val)
 		case "crypt":
 			f.Options.Crypt = val
+		case "blind_index":
+			f.Options.BlindIndex = parseBoolDefaultTrue(val)
 		case "array":
 			f.Options.IsArray = parseBoolDefaultTrue(val)
 		case "format":
 			f.Options.FormatTime = val
 		case "enum":
 			f.Options.Enum = parseEnumList(val)
+		case "enum_check":
+			f.Options.EnumCheck = parseBoolDefaultTrue(val)
 		case "valid", "validate":
 			f.Validations = append(f.Validations, parseValidationList(val)...)
 		case "disable_migration":
 			f.Options.DisableMigration = parseBoolDefaultTrue(val)
+		case "visibility":
+			f.Options.Visibility = Visibility(val)
+		case "hidden":
+			f.Options.Visibility = VisibilityHidden
+		case "mask":
+			f.Options.Visibility = VisibilityMasked
+			f.Options.Mask = val
+		case "visible_roles":
+			f.Options.VisibleRoles = splitList(val)
 		}
 	}
 }
@@ -489,6 +542,9 @@ func applyMetaTags(s *Schema, sf reflect.StructField, allowName, allowTable bool
 		"fields_sort",
 		"crypt_salt",
 		"crypt_len",
+		"tenancy",
+		"audit",
+		"outbox",
 	} {
 		if val, ok := tag.Lookup(key); ok {
 			applyOptionKey(s, key, val)
@@ -544,6 +600,10 @@ func applyOptionKey(s *Schema, key, val string) {
 		setOptionBool(&s.Options.SoftDeleteIsTime, val)
 	case "crypt_id":
 		setOptionBool(&s.Options.CryptID, val)
+	case "audit":
+		setOptionBool(&s.Options.Audit, val)
+	case "outbox":
+		setOptionBool(&s.Options.Outbox, val)
 	case "disabled_migrator":
 		setOptionBool(&s.Options.DisabledMigrator, val)
 	case "crypt_salt":
@@ -559,6 +619,18 @@ func applyOptionKey(s *Schema, key, val string) {
 		s.Options.LowFields = splitOptionList(val)
 	case "fields_sort":
 		s.Options.FieldsSort = splitOptionList(val)
+	case "tenancy":
+		s.Options.Tenancy = TenancyMode(strings.ToLower(val))
+	case "time_format":
+		s.Options.TimeFormat = val
+	case "primary_key":
+		if val != "" {
+			s.Options.PrimaryKey = &PrimaryKey{Type: PrimaryKeyType(strings.ToLower(val))}
+		}
+	case "composite_key":
+		if fields := splitOptionList(val); len(fields) > 0 {
+			s.Options.PrimaryKey = &PrimaryKey{Fields: fields}
+		}
 	}
 }
 
@@ -894,6 +966,13 @@ func cloneOptions(o Options) Options {
 	if o.FieldsSort != nil {
 		out.FieldsSort = append([]string(nil), o.FieldsSort...)
 	}
+	if o.Scopes != nil {
+		out.Scopes = make(Scopes, len(o.Scopes))
+		for k, v := range o.Scopes {
+			v.Filter = cloneMap(v.Filter)
+			out.Scopes[k] = v
+		}
+	}
 	return out
 }
 
@@ -191,6 +191,18 @@ func TestFieldsFromStruct_NoJsonTag(t *testing.T) {
 	tt.Equal(uint64(50), fields["user_name"].Size)
 }
 
+func TestFieldsFromStruct_ForeignDecimal(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	// 非 model 包中同名的 Decimal 类型按底层类型处理
+	type Decimal string
+	type order struct {
+		Amount Decimal `json:"amount"`
+	}
+	fields := FieldsFromStruct[order]()
+	tt.Equal(String, fields["amount"].Type)
+}
+
 func TestFieldsFromStruct_Pointer(t *testing.T) {
 	tt := zlsgo.NewTest(t)
 
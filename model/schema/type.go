@@ -20,4 +20,6 @@ const (
 	JSON   = schema.JSON
 	Time   = schema.Time
 	Bytes  = schema.Bytes
+	// Decimal 定点数，Size 为总位数，Scale 为小数位数
+	Decimal schema.DataType = "decimal"
 )
@@ -1,16 +1,36 @@
 package model
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
 
 func cloneSchemaWithStorage(root *Schema, storage Storageer) *Schema {
 	if root == nil || storage == nil {
 		return root
 	}
 
+	return cloneSchemaWith(root, func(s *Schema) {
+		s.Storage = storage
+	})
+}
+
+func cloneSchemaWithContext(root *Schema, ctx context.Context) *Schema {
+	if root == nil || ctx == nil {
+		return root
+	}
+
+	return cloneSchemaWith(root, func(s *Schema) {
+		s.ctx = ctx
+	})
+}
+
+// cloneSchemaWith 克隆模型及其关联模型，并对每个克隆应用 apply
+func cloneSchemaWith(root *Schema, apply func(s *Schema)) *Schema {
 	baseGet := root.getSchema
 	if baseGet == nil {
 		clone := *root
-		clone.Storage = storage
+		apply(&clone)
 		clone.model = nil
 		return &clone
 	}
@@ -33,7 +53,7 @@ func cloneSchemaWithStorage(root *Schema, storage Storageer) *Schema {
 		}
 
 		clone := *base
-		clone.Storage = storage
+		apply(&clone)
 		clone.getSchema = get
 		clone.model = nil
 
@@ -52,7 +72,7 @@ func cloneSchemaWithStorage(root *Schema, storage Storageer) *Schema {
 	}
 
 	clone := *root
-	clone.Storage = storage
+	apply(&clone)
 	clone.getSchema = get
 	clone.model = nil
 
@@ -1,12 +1,14 @@
 package model
 
 import (
+	"errors"
+
 	"github.com/sohaha/zlsgo/zarray"
 	"github.com/sohaha/zlsgo/zdi"
+	"github.com/sohaha/zlsgo/zerror"
 	"github.com/sohaha/zlsgo/zjson"
 	"github.com/sohaha/zlsgo/znet"
 	"github.com/sohaha/zlsgo/ztype"
-	"github.com/zlsgo/app_module/model/hook"
 	"github.com/zlsgo/app_module/model/schema"
 )
 
@@ -34,7 +36,7 @@ func (m *Schema) GetExtend() ztype.Map {
 }
 
 func (m *Schema) GetTableName() string {
-	return m.tablePrefix + m.define.Table.Name
+	return m.getTablePrefix() + m.define.Table.Name
 }
 
 func (m *Schema) Migration() Migrationer {
@@ -95,14 +97,6 @@ func (m *Schema) DI() zdi.Injector {
 	return m.di
 }
 
-func (m *Schema) hook(name hook.Event, data ...any) error {
-	if m.define.Options.Hook == nil {
-		return nil
-	}
-
-	return m.define.Options.Hook(name, data...)
-}
-
 type schemaController struct {
 	module     *Module
 	middleware func() []znet.Handler
@@ -116,8 +110,46 @@ func (h *schemaController) Init(r *znet.Engine) error {
 	return nil
 }
 
+// GET 返回模型元数据，format=jsonschema 返回 JSON Schema，format=openapi 返回 OpenAPI components，
+// format=views/form/list 返回管理端视图描述
 func (h *schemaController) GET(c *znet.Context) (any, error) {
 	schemas := ztype.Map{}
+	view := c.DefaultQuery("view", "")
+
+	switch c.DefaultQuery("format", "") {
+	case "jsonschema":
+		h.module.schemas.ForEach(func(key string, m *Schema) bool {
+			schemas[key] = m.JSONSchema(view)
+			return true
+		})
+		return schemas, nil
+	case "openapi":
+		h.module.schemas.ForEach(func(key string, m *Schema) bool {
+			for name, s := range m.OpenAPIComponents(view) {
+				schemas[name] = s
+			}
+			return true
+		})
+		return ztype.Map{"components": ztype.Map{"schemas": schemas}}, nil
+	case "views":
+		h.module.schemas.ForEach(func(key string, m *Schema) bool {
+			schemas[key] = m.ViewDescriptors()
+			return true
+		})
+		return schemas, nil
+	case "form":
+		h.module.schemas.ForEach(func(key string, m *Schema) bool {
+			schemas[key] = m.FormView(view)
+			return true
+		})
+		return schemas, nil
+	case "list":
+		h.module.schemas.ForEach(func(key string, m *Schema) bool {
+			schemas[key] = m.ListView(view)
+			return true
+		})
+		return schemas, nil
+	}
 
 	h.module.schemas.ForEach(func(key string, m *Schema) bool {
 		schemas[key] = ztype.Map{
@@ -125,9 +157,129 @@ func (h *schemaController) GET(c *znet.Context) (any, error) {
 			"comment": m.GetComment(),
 			"fields":  m.GetFields(),
 			"extend":  m.GetExtend(),
+			"enums":   m.GetEnums(),
 		}
 		return true
 	})
 
 	return schemas, nil
 }
+
+// schemaManageController 运行时模型管理接口，使用独立的鉴权中间件
+type schemaManageController struct {
+	module     *Module
+	middleware func() []znet.Handler
+	Path       string
+}
+
+func (h *schemaManageController) Init(r *znet.Engine) error {
+	r.Use(h.middleware()...)
+	return nil
+}
+
+// POST 新增模型，dry_run=1 时仅返回迁移预演
+func (h *schemaManageController) POST(c *znet.Context) (any, error) {
+	sm, d, err := h.bindDefine(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if ztype.ToBool(c.DefaultQuery("dry_run", "")) {
+		if _, ok := h.module.schemas.Get(d.Name); ok {
+			return nil, schemaManageError(ErrSchemaExists)
+		}
+		plan, err := sm.Plan(d)
+		if err != nil {
+			return nil, schemaManageError(err)
+		}
+		return ztype.Map{"plan": plan}, nil
+	}
+
+	v, plan, err := sm.Create(c.Request.Context(), d)
+	if err != nil {
+		return nil, schemaManageError(err)
+	}
+	return ztype.Map{"version": v, "plan": plan}, nil
+}
+
+// NAMEPUT 修改运行时管理的模型，dry_run=1 时仅返回迁移预演
+func (h *schemaManageController) NAMEPUT(c *znet.Context) (any, error) {
+	sm, d, err := h.bindDefine(c)
+	if err != nil {
+		return nil, err
+	}
+
+	name := c.GetParam("name")
+	if ztype.ToBool(c.DefaultQuery("dry_run", "")) {
+		if !sm.Managed(name) {
+			return nil, schemaManageError(ErrSchemaNotManaged)
+		}
+		d.Name = name
+		plan, err := sm.Plan(d)
+		if err != nil {
+			return nil, schemaManageError(err)
+		}
+		return ztype.Map{"plan": plan}, nil
+	}
+
+	v, plan, err := sm.Update(c.Request.Context(), name, d)
+	if err != nil {
+		return nil, schemaManageError(err)
+	}
+	return ztype.Map{"version": v, "plan": plan}, nil
+}
+
+// NAMEDELETE 注销运行时管理的模型，数据表保留
+func (h *schemaManageController) NAMEDELETE(c *znet.Context) (any, error) {
+	sm := h.module.manager
+	if sm == nil {
+		return nil, schemaManageError(ErrSchemaManageDisabled)
+	}
+
+	v, err := sm.Delete(c.Request.Context(), c.GetParam("name"))
+	if err != nil {
+		return nil, schemaManageError(err)
+	}
+	return ztype.Map{"version": v}, nil
+}
+
+// GetVersions 返回模型定义的版本历史
+func (h *schemaManageController) GetVersions(c *znet.Context) (any, error) {
+	sm := h.module.manager
+	if sm == nil {
+		return nil, schemaManageError(ErrSchemaManageDisabled)
+	}
+
+	name := c.DefaultQuery("name", "")
+	if name == "" {
+		return nil, zerror.InvalidInput.Text("name is required")
+	}
+	return sm.Versions(name)
+}
+
+func (h *schemaManageController) bindDefine(c *znet.Context) (*SchemaManager, schema.Schema, error) {
+	var d schema.Schema
+	sm := h.module.manager
+	if sm == nil {
+		return nil, d, schemaManageError(ErrSchemaManageDisabled)
+	}
+
+	j, err := c.GetJSONs()
+	if err != nil {
+		return nil, d, zerror.InvalidInput.Text("invalid schema define")
+	}
+	if err = zjson.Unmarshal([]byte(j.String()), &d); err != nil {
+		return nil, d, zerror.InvalidInput.Text("invalid schema define")
+	}
+	return sm, d, nil
+}
+
+// schemaManageError 模型管理错误转换为接口错误
+func schemaManageError(err error) error {
+	switch {
+	case errors.Is(err, ErrSchemaManageDisabled), errors.Is(err, ErrSchemaNotManaged):
+		return zerror.WrapTag(zerror.NotFound)(err)
+	default:
+		return zerror.WrapTag(zerror.InvalidInput)(err)
+	}
+}
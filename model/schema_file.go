@@ -0,0 +1,664 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/sohaha/zlsgo/zjson"
+	"github.com/zlsgo/app_module/model/schema"
+	zschema "github.com/zlsgo/zdb/schema"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	schemaKeyExtends  = "extends"
+	schemaKeyAbstract = "abstract"
+	schemaKeyEnv      = "env"
+	schemaKeyRef      = "$ref"
+)
+
+// SchemaFileError 模型文件错误，Line 为 0 时表示无法定位到行
+type SchemaFileError struct {
+	Err  error
+	File string
+	Line int
+}
+
+// Error 返回带文件与行号的错误信息
+func (e *SchemaFileError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+	}
+	return e.File + ": " + e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *SchemaFileError) Unwrap() error {
+	return e.Err
+}
+
+var schemaFileExts = []string{".json", ".yaml", ".yml", ".toml"}
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+type schemaFile struct {
+	root     *yaml.Node
+	path     string
+	name     string
+	resolved bool
+	fragment bool
+}
+
+// schemaLoader 读取模型文件，处理片段引用、继承与环境覆盖
+type schemaLoader struct {
+	files    map[string]*schemaFile
+	origin   map[*yaml.Node]string
+	names    map[string]*schemaFile
+	dir      string
+	env      string
+	resolved map[*schemaFile]*yaml.Node
+	// digests 模型文件路径对应的规范化定义摘要，继承与引用展开后计算
+	digests map[string]string
+}
+
+// parseSchema 读取目录下的 JSON、YAML 与 TOML 模型文件，env 用于选择环境覆盖
+func parseSchema(dir string, env ...string) ([]schema.Schema, error) {
+	return newSchemaLoader(dir, env...).parse()
+}
+
+func newSchemaLoader(dir string, env ...string) *schemaLoader {
+	l := &schemaLoader{
+		dir:      dir,
+		files:    make(map[string]*schemaFile),
+		origin:   make(map[*yaml.Node]string),
+		names:    make(map[string]*schemaFile),
+		resolved: make(map[*schemaFile]*yaml.Node),
+		digests:  make(map[string]string),
+	}
+	if len(env) > 0 {
+		l.env = env[0]
+	}
+	return l
+}
+
+func (l *schemaLoader) parse() ([]schema.Schema, error) {
+	dir := l.dir
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return skipHiddenDir(dir, path, d)
+		}
+		if zarray.Contains(schemaFileExts, strings.ToLower(filepath.Ext(path))) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*schemaFile, 0, len(paths))
+	for _, path := range paths {
+		f, err := l.load(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	for _, f := range files {
+		if err = l.resolveRefs(f, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range files {
+		if f.fragment {
+			continue
+		}
+		if f.name = scalarValue(mappingValue(f.root, "name")); f.name != "" {
+			l.names[f.name] = f
+		}
+		rel, _ := filepath.Rel(dir, f.path)
+		rel = filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+		if _, ok := l.names[rel]; !ok {
+			l.names[rel] = f
+		}
+	}
+
+	schemas := make([]schema.Schema, 0, len(files))
+	for _, f := range files {
+		if f.fragment || scalarValue(mappingValue(f.root, schemaKeyAbstract)) == "true" {
+			continue
+		}
+		root, err := l.resolveExtends(f, nil)
+		if err != nil {
+			return nil, err
+		}
+		d, err := l.decode(f, root)
+		if err != nil {
+			return nil, err
+		}
+		d.SchemaPath = f.path
+		schemas = append(schemas, d)
+	}
+	return schemas, nil
+}
+
+// skipHiddenDir 跳过以 . 开头的子目录，如版本历史与版本控制目录
+func skipHiddenDir(root, path string, d fs.DirEntry) error {
+	if path != root && strings.HasPrefix(d.Name(), ".") {
+		return filepath.SkipDir
+	}
+	return nil
+}
+
+// load 读取单个文件并转换为带行号的节点树
+func (l *schemaLoader) load(path string) (*schemaFile, error) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if f, ok := l.files[path]; ok {
+		return f, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &SchemaFileError{File: path, Err: err}
+	}
+
+	var root *yaml.Node
+	if strings.ToLower(filepath.Ext(path)) == ".toml" {
+		root, err = tomlToNode(data)
+	} else {
+		var doc yaml.Node
+		if err = yaml.Unmarshal(data, &doc); err == nil && len(doc.Content) > 0 {
+			root = doc.Content[0]
+		}
+	}
+	if err != nil {
+		return nil, &SchemaFileError{File: path, Line: errorLine(err), Err: err}
+	}
+	if root == nil {
+		root = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1}
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, &SchemaFileError{File: path, Line: root.Line, Err: errors.New("schema file must be a mapping")}
+	}
+
+	l.markOrigin(root, path)
+	f := &schemaFile{path: path, root: root}
+	l.files[path] = f
+	return f, nil
+}
+
+func (l *schemaLoader) markOrigin(n *yaml.Node, path string) {
+	l.origin[n] = path
+	for _, c := range n.Content {
+		l.markOrigin(c, path)
+	}
+}
+
+// resolveRefs 展开 $ref 片段，片段中的键被所在映射中的同名键覆盖
+func (l *schemaLoader) resolveRefs(f *schemaFile, stack []string) error {
+	if f.resolved {
+		return nil
+	}
+	if zarray.Contains(stack, f.path) {
+		return &SchemaFileError{File: f.path, Err: fmt.Errorf("circular $ref: %s", strings.Join(append(stack, f.path), " -> "))}
+	}
+	root, err := l.expandRefs(f, f.root, append(stack[:len(stack):len(stack)], f.path))
+	if err != nil {
+		return err
+	}
+	f.root, f.resolved = root, true
+	return nil
+}
+
+func (l *schemaLoader) expandRefs(f *schemaFile, n *yaml.Node, stack []string) (*yaml.Node, error) {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		for i := range n.Content {
+			c, err := l.expandRefs(f, n.Content[i], stack)
+			if err != nil {
+				return nil, err
+			}
+			n.Content[i] = c
+		}
+		return n, nil
+	case yaml.MappingNode:
+	default:
+		return n, nil
+	}
+
+	var refs []*yaml.Node
+	local := &yaml.Node{Kind: yaml.MappingNode, Tag: n.Tag, Line: n.Line, Column: n.Column}
+	l.origin[local] = f.path
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if key.Value == schemaKeyRef {
+			if value.Kind == yaml.SequenceNode {
+				refs = append(refs, value.Content...)
+			} else {
+				refs = append(refs, value)
+			}
+			continue
+		}
+		value, err := l.expandRefs(f, value, stack)
+		if err != nil {
+			return nil, err
+		}
+		local.Content = append(local.Content, key, value)
+	}
+	if len(refs) == 0 {
+		return local, nil
+	}
+
+	var merged *yaml.Node
+	for _, ref := range refs {
+		target, err := l.refFile(f, ref)
+		if err != nil {
+			return nil, err
+		}
+		if err = l.resolveRefs(target, stack); err != nil {
+			return nil, err
+		}
+		target.fragment = true
+		merged = mergeNodes(merged, target.root)
+	}
+	return mergeNodes(merged, local), nil
+}
+
+// refFile 引用路径先相对当前文件查找，再相对模型目录查找
+func (l *schemaLoader) refFile(f *schemaFile, ref *yaml.Node) (*schemaFile, error) {
+	if ref.Kind != yaml.ScalarNode || ref.Value == "" {
+		return nil, &SchemaFileError{File: f.path, Line: ref.Line, Err: errors.New("$ref must be a file path")}
+	}
+	candidates := []string{filepath.Join(filepath.Dir(f.path), ref.Value), filepath.Join(l.dir, ref.Value)}
+	if filepath.IsAbs(ref.Value) {
+		candidates = []string{ref.Value}
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return l.load(path)
+		}
+	}
+	return nil, &SchemaFileError{File: f.path, Line: ref.Line, Err: fmt.Errorf("$ref file not found: %s", ref.Value)}
+}
+
+// resolveExtends 合并继承的模型与当前环境的覆盖配置
+func (l *schemaLoader) resolveExtends(f *schemaFile, stack []string) (*yaml.Node, error) {
+	if root, ok := l.resolved[f]; ok {
+		return root, nil
+	}
+	if zarray.Contains(stack, f.path) {
+		return nil, &SchemaFileError{File: f.path, Err: fmt.Errorf("circular extends: %s", strings.Join(append(stack, f.path), " -> "))}
+	}
+	stack = append(stack[:len(stack):len(stack)], f.path)
+
+	own := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: f.root.Line}
+	var (
+		parents  []*yaml.Node
+		override *yaml.Node
+	)
+	for i := 0; i+1 < len(f.root.Content); i += 2 {
+		key, value := f.root.Content[i], f.root.Content[i+1]
+		switch key.Value {
+		case schemaKeyExtends:
+			if value.Kind == yaml.SequenceNode {
+				parents = append(parents, value.Content...)
+			} else {
+				parents = append(parents, value)
+			}
+		case schemaKeyAbstract:
+		case schemaKeyEnv:
+			if value.Kind != yaml.MappingNode {
+				return nil, &SchemaFileError{File: f.path, Line: value.Line, Err: errors.New("env must be a mapping")}
+			}
+			if l.env != "" {
+				override = mappingValue(value, l.env)
+			}
+		default:
+			own.Content = append(own.Content, key, value)
+		}
+	}
+
+	var root *yaml.Node
+	for _, p := range parents {
+		base, ok := l.names[p.Value]
+		if !ok {
+			return nil, &SchemaFileError{File: f.path, Line: p.Line, Err: fmt.Errorf("extends schema not found: %s", p.Value)}
+		}
+		parent, err := l.resolveExtends(base, stack)
+		if err != nil {
+			return nil, err
+		}
+		// 继承时不复制父模型的名称与表名
+		root = mergeNodes(root, withoutKeys(parent, "name", "table"))
+	}
+	root = mergeNodes(root, own)
+	if override != nil {
+		root = mergeNodes(root, override)
+	}
+	l.resolved[f] = root
+	return root, nil
+}
+
+// decode 转换为模型定义并校验，错误定位到字段所在的文件与行
+func (l *schemaLoader) decode(f *schemaFile, root *yaml.Node) (d schema.Schema, err error) {
+	var v any
+	if err = root.Decode(&v); err != nil {
+		return d, &SchemaFileError{File: f.path, Line: errorLine(err), Err: err}
+	}
+	data, err := json.Marshal(normalizeYAMLValue(v))
+	if err != nil {
+		return d, &SchemaFileError{File: f.path, Err: err}
+	}
+	l.digests[f.path] = fmt.Sprintf("%x", sha256.Sum256(data))
+	if err = zjson.Unmarshal(data, &d); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return d, l.nodeError(f, root, typeErr.Field, err)
+		}
+		return d, &SchemaFileError{File: f.path, Err: err}
+	}
+
+	for name, field := range d.Fields {
+		if field.Type != "" && !zarray.Contains(schemaFieldTypes, field.Type) {
+			return d, l.nodeError(f, root, "fields."+name+".type", fmt.Errorf("unknown field type %q", field.Type))
+		}
+	}
+	for name, rel := range d.Relations {
+		if rel.Schema == "" {
+			return d, l.nodeError(f, root, "relations."+name, errors.New("relation schema required"))
+		}
+	}
+	return d, nil
+}
+
+var schemaFieldTypes = []zschema.DataType{
+	schema.Bool, schema.Int, schema.Int8, schema.Int16, schema.Int32, schema.Int64,
+	schema.Uint, schema.Uint8, schema.Uint16, schema.Uint32, schema.Uint64,
+	schema.Float, schema.String, schema.Text, schema.JSON, schema.Time, schema.Bytes, schema.Decimal,
+}
+
+// nodeError 按点分路径查找最近的节点，报告其来源文件与行号
+func (l *schemaLoader) nodeError(f *schemaFile, root *yaml.Node, path string, err error) error {
+	n := root
+	for _, key := range strings.Split(path, ".") {
+		var next *yaml.Node
+		switch n.Kind {
+		case yaml.MappingNode:
+			next = mappingValue(n, key)
+		case yaml.SequenceNode:
+			if i, e := strconv.Atoi(key); e == nil && i >= 0 && i < len(n.Content) {
+				next = n.Content[i]
+			}
+		}
+		if next == nil {
+			break
+		}
+		n = next
+	}
+
+	file, ok := l.origin[n]
+	if !ok {
+		file = f.path
+	}
+	return &SchemaFileError{File: file, Line: n.Line, Err: err}
+}
+
+// mergeNodes 深度合并映射，over 中的值优先，非映射值直接替换
+func mergeNodes(base, over *yaml.Node) *yaml.Node {
+	if base == nil {
+		return over
+	}
+	if over == nil {
+		return base
+	}
+	if base.Kind != yaml.MappingNode || over.Kind != yaml.MappingNode {
+		return over
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: over.Tag, Line: over.Line, Column: over.Column}
+	merged.Content = append(merged.Content, base.Content...)
+	for i := 0; i+1 < len(over.Content); i += 2 {
+		key, value := over.Content[i], over.Content[i+1]
+		replaced := false
+		for j := 0; j+1 < len(merged.Content); j += 2 {
+			if merged.Content[j].Value == key.Value {
+				merged.Content[j+1] = mergeNodes(merged.Content[j+1], value)
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged.Content = append(merged.Content, key, value)
+		}
+	}
+	return merged
+}
+
+func withoutKeys(n *yaml.Node, keys ...string) *yaml.Node {
+	out := &yaml.Node{Kind: n.Kind, Tag: n.Tag, Line: n.Line, Column: n.Column}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if !zarray.Contains(keys, n.Content[i].Value) {
+			out.Content = append(out.Content, n.Content[i], n.Content[i+1])
+		}
+	}
+	return out
+}
+
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func scalarValue(n *yaml.Node) string {
+	if n == nil || n.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return n.Value
+}
+
+// normalizeYAMLValue 将 YAML 解码结果转换为可 JSON 序列化的值
+func normalizeYAMLValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k := range val {
+			val[k] = normalizeYAMLValue(val[k])
+		}
+		return val
+	case map[any]any:
+		m := make(map[string]any, len(val))
+		for k := range val {
+			m[fmt.Sprint(k)] = normalizeYAMLValue(val[k])
+		}
+		return m
+	case []any:
+		for i := range val {
+			val[i] = normalizeYAMLValue(val[i])
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+func errorLine(err error) int {
+	var tomlErr *toml.DecodeError
+	if errors.As(err, &tomlErr) {
+		line, _ := tomlErr.Position()
+		return line
+	}
+	if m := yamlLinePattern.FindStringSubmatch(err.Error()); len(m) == 2 {
+		line, _ := strconv.Atoi(m[1])
+		return line
+	}
+	return 0
+}
+
+// tomlToNode 解析 TOML，并根据语法树为每个键补充行号
+func tomlToNode(data []byte) (*yaml.Node, error) {
+	var v map[string]any
+	if err := toml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	root := &yaml.Node{}
+	if err := root.Encode(normalizeTOMLValue(v)); err != nil {
+		return nil, err
+	}
+
+	lines := make(map[string]int)
+	p := unstable.Parser{}
+	p.Reset(data)
+	prefix := ""
+	tables := make(map[string]int)
+	for p.NextExpression() {
+		e := p.Expression()
+		switch e.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			it := e.Key()
+			line := tomlLine(&p, it.Node(), 0)
+			// 数组表的路径带上当前元素的下标，与解码后的结构一致
+			prefix = ""
+			for it.Next() {
+				if prefix != "" {
+					prefix += "."
+				}
+				prefix += string(it.Node().Data)
+				n, isArray := tables[prefix]
+				if it.IsLast() && e.Kind == unstable.ArrayTable {
+					lines[prefix] = line
+					tables[prefix] = n + 1
+					n, isArray = n+1, true
+				}
+				if isArray {
+					prefix += "." + strconv.Itoa(n-1)
+				}
+			}
+			lines[prefix] = line
+		case unstable.KeyValue:
+			tomlKeyValueLines(&p, e, prefix, lines)
+		}
+	}
+	if err := p.Error(); err != nil {
+		return nil, err
+	}
+
+	setNodeLines(root, "", lines, 1)
+	return root, nil
+}
+
+func tomlKeyValueLines(p *unstable.Parser, e *unstable.Node, prefix string, lines map[string]int) {
+	it := e.Key()
+	key := tomlKey(e.Key())
+	if prefix != "" {
+		key = prefix + "." + key
+	}
+	line := tomlLine(p, it.Node(), 0)
+	lines[key] = line
+	tomlValueLines(p, e.Value(), key, line, lines)
+}
+
+func tomlValueLines(p *unstable.Parser, v *unstable.Node, path string, line int, lines map[string]int) {
+	switch v.Kind {
+	case unstable.InlineTable:
+		it := v.Children()
+		for it.Next() {
+			tomlKeyValueLines(p, it.Node(), path, lines)
+		}
+	case unstable.Array:
+		it := v.Children()
+		for i := 0; it.Next(); i++ {
+			key := path + "." + strconv.Itoa(i)
+			l := tomlLine(p, it.Node(), line)
+			lines[key] = l
+			tomlValueLines(p, it.Node(), key, l, lines)
+		}
+	}
+}
+
+func tomlKey(it unstable.Iterator) string {
+	parts := make([]string, 0, 2)
+	for it.Next() {
+		parts = append(parts, string(it.Node().Data))
+	}
+	return strings.Join(parts, ".")
+}
+
+func tomlLine(p *unstable.Parser, n *unstable.Node, fallback int) int {
+	if n == nil || n.Raw.Length == 0 {
+		return fallback
+	}
+	return p.Shape(n.Raw).Start.Line
+}
+
+func setNodeLines(n *yaml.Node, path string, lines map[string]int, fallback int) {
+	if l, ok := lines[path]; ok {
+		fallback = l
+	}
+	n.Line = fallback
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i].Value
+			if path != "" {
+				key = path + "." + key
+			}
+			line := fallback
+			if l, ok := lines[key]; ok {
+				line = l
+			}
+			n.Content[i].Line = line
+			setNodeLines(n.Content[i+1], key, lines, line)
+		}
+	case yaml.SequenceNode:
+		for i := range n.Content {
+			setNodeLines(n.Content[i], path+"."+strconv.Itoa(i), lines, fallback)
+		}
+	}
+}
+
+// normalizeTOMLValue 将 TOML 的本地日期时间转换为字符串
+func normalizeTOMLValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k := range val {
+			val[k] = normalizeTOMLValue(val[k])
+		}
+		return val
+	case []any:
+		for i := range val {
+			val[i] = normalizeTOMLValue(val[i])
+		}
+		return val
+	case time.Time:
+		return val.Format(time.RFC3339)
+	case toml.LocalDate, toml.LocalTime, toml.LocalDateTime:
+		return fmt.Sprint(val)
+	default:
+		return v
+	}
+}
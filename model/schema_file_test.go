@@ -0,0 +1,157 @@
+package model
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func writeSchemaFile(t *testing.T, dir, name, body string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.TrimLeft(body, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseSchemaFiles(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	dir := t.TempDir()
+
+	writeSchemaFile(t, dir, "fields/audit.yaml", `
+created_by: {type: int, label: 创建人}
+`)
+	writeSchemaFile(t, dir, "base.yaml", `
+name: base
+abstract: true
+options:
+  timestamps: true
+fields:
+  $ref: fields/audit.yaml
+  title: {type: string, size: 50, label: 标题}
+env:
+  prod:
+    options: {crypt_id: true}
+`)
+	writeSchemaFile(t, dir, "post.yml", `
+name: post
+extends: base
+table: {name: posts}
+fields:
+  title: {size: 100}
+  body: {type: text}
+`)
+	writeSchemaFile(t, dir, "tag.toml", `
+name = "tag"
+extends = "base"
+
+[fields.label]
+type = "string"
+size = 20
+
+[[fields.label.validations]]
+method = "minLength"
+args = 1
+`)
+	writeSchemaFile(t, dir, "user.json", `{"name": "user", "fields": {"$ref": "fields/audit.yaml", "email": {"type": "string"}}}`)
+
+	list, err := parseSchema(dir, "prod")
+	tt.NoError(err)
+	defines := make(map[string]schema.Schema, len(list))
+	for _, d := range list {
+		defines[d.Name] = d
+	}
+	tt.Equal(3, len(defines))
+
+	post := defines["post"]
+	tt.Equal("posts", post.Table.Name)
+	tt.Equal(uint64(100), post.Fields["title"].Size)
+	tt.Equal("标题", post.Fields["title"].Label)
+	tt.Equal(schema.Text, post.Fields["body"].Type)
+	tt.Equal("创建人", post.Fields["created_by"].Label)
+	tt.Equal(true, *post.Options.Timestamps)
+	tt.Equal(true, *post.Options.CryptID)
+	tt.Equal(filepath.Join(dir, "post.yml"), post.SchemaPath)
+
+	tag := defines["tag"]
+	tt.Equal("", tag.Table.Name)
+	tt.Equal(uint64(20), tag.Fields["label"].Size)
+	tt.Equal("minLength", tag.Fields["label"].Validations[0].Method)
+	tt.Equal(3, len(tag.Fields))
+
+	tt.Equal(2, len(defines["user"].Fields))
+
+	list, err = parseSchema(dir)
+	tt.NoError(err)
+	for _, d := range list {
+		if d.Name == "post" {
+			tt.Equal(true, d.Options.CryptID == nil)
+		}
+	}
+
+	lineOf := func(err error) int {
+		var fe *SchemaFileError
+		if !errors.As(err, &fe) {
+			t.Fatalf("expected SchemaFileError, got %v", err)
+		}
+		return fe.Line
+	}
+
+	writeSchemaFile(t, dir, "bad.toml", `
+name = "bad"
+
+[fields.a]
+type = "string"
+
+[fields.b]
+type = "strnig"
+`)
+	_, err = parseSchema(dir)
+	tt.Equal(7, lineOf(err))
+	tt.Equal(true, strings.Contains(err.Error(), "bad.toml:7"))
+	tt.NoError(os.Remove(filepath.Join(dir, "bad.toml")))
+
+	writeSchemaFile(t, dir, "bad.yaml", `
+name: bad
+fields:
+  a:
+    type: string
+    size: abc
+`)
+	_, err = parseSchema(dir)
+	tt.Equal(5, lineOf(err))
+
+	writeSchemaFile(t, dir, "bad.yaml", `
+name: bad
+extends: missing
+`)
+	_, err = parseSchema(dir)
+	tt.Equal(2, lineOf(err))
+
+	writeSchemaFile(t, dir, "bad.yaml", `
+name: bad
+fields:
+  $ref: fields/missing.yaml
+`)
+	_, err = parseSchema(dir)
+	tt.Equal(3, lineOf(err))
+
+	writeSchemaFile(t, dir, "bad.yaml", `
+name: bad
+extends: bad2
+`)
+	writeSchemaFile(t, dir, "bad2.yaml", `
+name: bad2
+extends: bad
+`)
+	_, err = parseSchema(dir)
+	tt.Equal(true, strings.Contains(err.Error(), "circular extends"))
+}
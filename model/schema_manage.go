@@ -0,0 +1,539 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/sohaha/zlsgo/zjson"
+	"github.com/sohaha/zlsgo/ztime"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+// SchemaVersionSchemaName 模型定义版本模型名称
+const SchemaVersionSchemaName = "__schemas"
+
+// SchemaAction 模型定义变更类型
+type SchemaAction string
+
+const (
+	SchemaCreate SchemaAction = "create"
+	SchemaUpdate SchemaAction = "update"
+	SchemaDelete SchemaAction = "delete"
+)
+
+// SchemaVersion 模型定义版本，删除版本记录删除前的定义
+type SchemaVersion struct {
+	Time     time.Time     `json:"time"`
+	Define   schema.Schema `json:"define"`
+	Name     string        `json:"name"`
+	Action   SchemaAction  `json:"action"`
+	Operator string        `json:"operator,omitempty"`
+	Version  int           `json:"version"`
+}
+
+// SchemaRepository 运行时管理的模型定义持久化
+type SchemaRepository interface {
+	// Load 返回当前生效的定义
+	Load() ([]schema.Schema, error)
+	// Save 保存新版本并写入版本号，删除版本同时移除当前定义
+	Save(v *SchemaVersion) error
+	// Versions 返回模型的全部版本（按版本升序）
+	Versions(name string) ([]SchemaVersion, error)
+}
+
+var (
+	// ErrSchemaManageDisabled 未配置模型定义持久化
+	ErrSchemaManageDisabled = errors.New("schema management is not enabled")
+	// ErrSchemaNotManaged 模型不是运行时管理的模型
+	ErrSchemaNotManaged = errors.New("schema is not managed at runtime")
+	// ErrSchemaExists 模型已存在
+	ErrSchemaExists = errors.New("schema already exists")
+)
+
+var schemaNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// SchemaManager 运行时模型管理，变更依次经过校验、迁移预演、注册迁移与版本记录
+type SchemaManager struct {
+	schemas  *Schemas
+	repo     SchemaRepository
+	defines  map[string]schema.Schema
+	onChange func(name string, m *Schema)
+	mu       sync.Mutex
+}
+
+// NewSchemaManager 加载持久化的定义并注册，onChange 在模型注册或删除（m 为 nil）后回调
+func NewSchemaManager(ss *Schemas, repo SchemaRepository, onChange ...func(name string, m *Schema)) (*SchemaManager, error) {
+	if repo == nil {
+		return nil, ErrSchemaManageDisabled
+	}
+	defines, err := repo.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	sm := &SchemaManager{
+		schemas: ss,
+		repo:    repo,
+		defines: make(map[string]schema.Schema, len(defines)),
+	}
+	if len(onChange) > 0 {
+		sm.onChange = onChange[0]
+	}
+
+	for i := range defines {
+		d := defines[i]
+		// 同一文件已通过 SchemaDir 注册时不再重复注册
+		if m, ok := ss.Get(d.Name); ok && d.SchemaPath != "" && m.GetDefine().SchemaPath == d.SchemaPath {
+			sm.defines[d.Name] = d
+			continue
+		}
+		m, err := ss.Reg(d.Name, d, true)
+		if err != nil {
+			return nil, err
+		}
+		sm.defines[d.Name] = d
+		sm.changed(d.Name, m)
+	}
+	return sm, nil
+}
+
+// Managed 是否为运行时管理的模型
+func (sm *SchemaManager) Managed(name string) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	_, ok := sm.defines[name]
+	return ok
+}
+
+// Plan 校验定义并预演迁移
+func (sm *SchemaManager) Plan(d schema.Schema) ([]MigrationStep, error) {
+	if err := checkSchemaDefine(d); err != nil {
+		return nil, err
+	}
+	if err := sm.checkTable(d); err != nil {
+		return nil, err
+	}
+	return sm.schemas.Plan(d.Name, d)
+}
+
+// Create 新增模型，返回记录的版本与执行的迁移语句；
+// 迁移先于版本记录执行，版本记录失败时撤销注册，但已执行的 DDL 不会回滚
+func (sm *SchemaManager) Create(ctx context.Context, d schema.Schema) (*SchemaVersion, []MigrationStep, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if _, ok := sm.schemas.Get(d.Name); ok {
+		return nil, nil, ErrSchemaExists
+	}
+	plan, err := sm.Plan(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m, err := sm.schemas.Reg(d.Name, d, false)
+	if err != nil {
+		sm.schemas.unregister(d.Name)
+		return nil, nil, err
+	}
+
+	v := &SchemaVersion{Name: d.Name, Action: SchemaCreate, Define: d, Operator: ActorFromContext(ctx)}
+	if err = sm.repo.Save(v); err != nil {
+		sm.schemas.unregister(d.Name)
+		return nil, nil, err
+	}
+
+	sm.defines[d.Name] = d
+	sm.changed(d.Name, m)
+	return v, plan, nil
+}
+
+// Update 修改运行时管理的模型，注册或迁移失败时保持原定义；
+// 版本记录失败时恢复原定义，但已执行的 DDL 不会回滚
+func (sm *SchemaManager) Update(ctx context.Context, name string, d schema.Schema) (*SchemaVersion, []MigrationStep, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	prev, err := sm.get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	d.Name = name
+	d.SchemaPath = sm.defines[name].SchemaPath
+	plan, err := sm.Plan(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m, err := sm.schemas.Reg(name, d, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := &SchemaVersion{Name: name, Action: SchemaUpdate, Define: d, Operator: ActorFromContext(ctx)}
+	if err = sm.repo.Save(v); err != nil {
+		sm.schemas.publish(name, prev)
+		return nil, nil, err
+	}
+
+	sm.defines[name] = d
+	sm.changed(name, m)
+	return v, plan, nil
+}
+
+// Delete 注销运行时管理的模型，数据表与数据保留
+func (sm *SchemaManager) Delete(ctx context.Context, name string) (*SchemaVersion, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if _, err := sm.get(name); err != nil {
+		return nil, err
+	}
+
+	v := &SchemaVersion{Name: name, Action: SchemaDelete, Define: sm.defines[name], Operator: ActorFromContext(ctx)}
+	if err := sm.repo.Save(v); err != nil {
+		return nil, err
+	}
+
+	sm.schemas.unregister(name)
+	delete(sm.defines, name)
+	sm.changed(name, nil)
+	return v, nil
+}
+
+// Versions 模型定义的版本历史
+func (sm *SchemaManager) Versions(name string) ([]SchemaVersion, error) {
+	if !schemaNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid schema name %q", name)
+	}
+	return sm.repo.Versions(name)
+}
+
+func (sm *SchemaManager) get(name string) (*Schema, error) {
+	if _, ok := sm.defines[name]; !ok {
+		return nil, ErrSchemaNotManaged
+	}
+	m, ok := sm.schemas.Get(name)
+	if !ok {
+		return nil, ErrSchemaNotManaged
+	}
+	return m, nil
+}
+
+func (sm *SchemaManager) changed(name string, m *Schema) {
+	if sm.onChange != nil {
+		sm.onChange(name, m)
+	}
+}
+
+// checkTable 校验表名，不允许使用其他模型或内置模型的数据表
+func (sm *SchemaManager) checkTable(d schema.Schema) error {
+	table := schemaTableName(d.Name, d)
+	if !schemaNamePattern.MatchString(table) {
+		return fmt.Errorf("invalid table name %q", table)
+	}
+
+	for _, define := range []func() schema.Schema{auditSchemaDefine, outboxSchemaDefine, outboxOffsetSchemaDefine, schemaVersionDefine} {
+		if strings.EqualFold(define().Table.Name, table) {
+			return fmt.Errorf("table %s is reserved", table)
+		}
+	}
+
+	var err error
+	sm.schemas.ForEach(func(alias string, m *Schema) bool {
+		if alias != d.Name && strings.EqualFold(m.GetDefine().Table.Name, table) {
+			err = fmt.Errorf("table %s is already used by schema %s", table, alias)
+			return false
+		}
+		return true
+	})
+	return err
+}
+
+// checkSchemaDefine 校验接口提交的模型定义
+func checkSchemaDefine(d schema.Schema) error {
+	if !schemaNamePattern.MatchString(d.Name) {
+		return fmt.Errorf("invalid schema name %q", d.Name)
+	}
+	if len(d.Fields) == 0 {
+		return errors.New("schema fields can not be empty")
+	}
+	for name, field := range d.Fields {
+		if !schemaNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid field name %q", name)
+		}
+		if field.Type != "" && !zarray.Contains(schemaFieldTypes, field.Type) {
+			return fmt.Errorf("field %s: unknown field type %q", name, field.Type)
+		}
+	}
+	for name, rel := range d.Relations {
+		if rel.Schema == "" {
+			return fmt.Errorf("relation %s: relation schema required", name)
+		}
+	}
+	return nil
+}
+
+// unregister 从集合中移除模型
+func (ss *Schemas) unregister(alias string) {
+	ss.mu.Lock()
+	ss.data.Delete(alias)
+	delete(ss.cacheGet, alias)
+	if ss.models != nil {
+		ss.models.items.Delete(alias)
+	}
+	ss.mu.Unlock()
+}
+
+type tableSchemaRepository struct {
+	model *Schema
+}
+
+// NewTableSchemaRepository 将模型定义与版本保存到 schemas 表
+func NewTableSchemaRepository(ss *Schemas) (SchemaRepository, error) {
+	m, ok := ss.Get(SchemaVersionSchemaName)
+	if !ok {
+		var err error
+		if m, err = ss.Reg(SchemaVersionSchemaName, schemaVersionDefine(), false); err != nil {
+			return nil, err
+		}
+	}
+	return &tableSchemaRepository{model: m}, nil
+}
+
+func schemaVersionDefine() schema.Schema {
+	f := false
+	return schema.Schema{
+		Name: SchemaVersionSchemaName,
+		Table: schema.Table{
+			Name:    "schemas",
+			Comment: "模型定义",
+		},
+		Options: schema.Options{
+			Audit:       &f,
+			SoftDeletes: &f,
+			Timestamps:  &f,
+			CryptID:     &f,
+			Tenancy:     schema.TenancyNone,
+		},
+		Fields: map[string]schema.Field{
+			"name":        {Type: schema.String, Size: 120, Label: "模型", Index: true},
+			"version":     {Type: schema.Int, Label: "版本"},
+			"action":      {Type: schema.String, Size: 20, Label: "操作"},
+			"define":      {Type: schema.Text, Label: "定义"},
+			"operator":    {Type: schema.String, Size: 120, Label: "操作人", Nullable: true},
+			"recorded_at": {Type: schema.Time, Label: "变更时间"},
+		},
+	}
+}
+
+func (r *tableSchemaRepository) Load() ([]schema.Schema, error) {
+	rows, err := FindMaps(r.model.Model(), Filter{}, func(co *CondOptions) {
+		co.OrderBy = []OrderByItem{{Field: "version", Direction: "ASC"}}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]SchemaVersion, len(rows))
+	for _, row := range rows {
+		v, err := r.version(row)
+		if err != nil {
+			return nil, err
+		}
+		latest[v.Name] = v
+	}
+
+	names := zarray.Keys(latest)
+	sort.Strings(names)
+	defines := make([]schema.Schema, 0, len(names))
+	for _, name := range names {
+		if v := latest[name]; v.Action != SchemaDelete {
+			defines = append(defines, v.Define)
+		}
+	}
+	return defines, nil
+}
+
+func (r *tableSchemaRepository) Save(v *SchemaVersion) error {
+	define, err := json.Marshal(v.Define)
+	if err != nil {
+		return err
+	}
+
+	last, err := FindCols[int](r.model.Model(), "version", Filter{"name": v.Name}, func(co *CondOptions) {
+		co.OrderBy = []OrderByItem{{Field: "version", Direction: "DESC"}}
+		co.Limit = 1
+	})
+	if err != nil {
+		return err
+	}
+	v.Version = 1
+	if len(last) > 0 {
+		v.Version = last[0] + 1
+	}
+	if v.Time.IsZero() {
+		v.Time = ztime.Time()
+	}
+
+	_, err = Insert(r.model, ztype.Map{
+		"name":        v.Name,
+		"version":     v.Version,
+		"action":      string(v.Action),
+		"define":      string(define),
+		"operator":    v.Operator,
+		"recorded_at": ztime.FormatTime(v.Time),
+	})
+	return err
+}
+
+func (r *tableSchemaRepository) Versions(name string) ([]SchemaVersion, error) {
+	rows, err := FindMaps(r.model.Model(), Filter{"name": name}, func(co *CondOptions) {
+		co.OrderBy = []OrderByItem{{Field: "version", Direction: "ASC"}}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]SchemaVersion, 0, len(rows))
+	for _, row := range rows {
+		v, err := r.version(row)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+func (r *tableSchemaRepository) version(row ztype.Map) (SchemaVersion, error) {
+	v := SchemaVersion{
+		Name:     row.Get("name").String(),
+		Version:  row.Get("version").Int(),
+		Action:   SchemaAction(row.Get("action").String()),
+		Operator: row.Get("operator").String(),
+	}
+	if t, ok := row["recorded_at"].(time.Time); ok {
+		v.Time = t
+	} else if t, err := ztime.Parse(row.Get("recorded_at").String()); err == nil {
+		v.Time = t
+	}
+	if err := zjson.Unmarshal([]byte(row.Get("define").String()), &v.Define); err != nil {
+		return v, fmt.Errorf("schema %s version %d: %w", v.Name, v.Version, err)
+	}
+	return v, nil
+}
+
+// schemaHistoryDir 目录存储的版本历史子目录，加载模型文件时跳过
+const schemaHistoryDir = ".history"
+
+type dirSchemaRepository struct {
+	dir string
+	env string
+}
+
+// NewDirSchemaRepository 将模型定义保存为目录中的 JSON 文件，版本历史保存在 .history 子目录
+func NewDirSchemaRepository(dir string, env ...string) SchemaRepository {
+	r := &dirSchemaRepository{dir: dir}
+	if len(env) > 0 {
+		r.env = env[0]
+	}
+	return r
+}
+
+func (r *dirSchemaRepository) Load() ([]schema.Schema, error) {
+	if _, err := os.Stat(r.dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return parseSchema(r.dir, r.env)
+}
+
+func (r *dirSchemaRepository) Save(v *SchemaVersion) error {
+	path := filepath.Join(r.dir, v.Name+".json")
+	if v.Define.SchemaPath != "" {
+		path = v.Define.SchemaPath
+	}
+	// 其他格式的文件可能包含继承、引用与环境覆盖，写回会丢失这些结构
+	if strings.ToLower(filepath.Ext(path)) != ".json" {
+		return fmt.Errorf("schema %s is defined in %s, only json files can be changed", v.Name, path)
+	}
+
+	history := filepath.Join(r.dir, schemaHistoryDir, v.Name)
+	versions, err := r.Versions(v.Name)
+	if err != nil {
+		return err
+	}
+	v.Version = len(versions) + 1
+	if v.Time.IsZero() {
+		v.Time = ztime.Time()
+	}
+
+	record, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(history, 0o755); err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(history, strconv.Itoa(v.Version)+".json"), record, 0o644); err != nil {
+		return err
+	}
+
+	if v.Action == SchemaDelete {
+		if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	define, err := json.MarshalIndent(v.Define, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, define, 0o644)
+}
+
+func (r *dirSchemaRepository) Versions(name string) ([]SchemaVersion, error) {
+	// 名称会拼接为路径，需拒绝 ../ 等越出目录的名称
+	if !schemaNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid schema name %q", name)
+	}
+	entries, err := os.ReadDir(filepath.Join(r.dir, schemaHistoryDir, name))
+	if os.IsNotExist(err) {
+		return []SchemaVersion{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]SchemaVersion, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(r.dir, schemaHistoryDir, name, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var v SchemaVersion
+		if err = zjson.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+	return versions, nil
+}
@@ -0,0 +1,179 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func TestSchemaManager(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	_, ss := newTestSchemas(t)
+
+	repo, err := NewTableSchemaRepository(ss)
+	tt.NoError(err)
+
+	changed := make(map[string]*Schema)
+	sm, err := NewSchemaManager(ss, repo, func(name string, m *Schema) { changed[name] = m })
+	tt.NoError(err)
+
+	define := schema.Schema{
+		Name: "managed_posts",
+		Fields: map[string]schema.Field{
+			"title": {Type: schema.String, Size: 50},
+		},
+	}
+
+	_, err = sm.Plan(schema.Schema{Name: "__hidden", Fields: define.Fields})
+	tt.Equal(true, err != nil)
+	_, err = sm.Plan(schema.Schema{Name: "bad", Fields: map[string]schema.Field{"title": {Type: "integer"}}})
+	tt.Equal(true, err != nil)
+
+	// 不允许使用非法表名或已被其他模型、内置模型占用的表
+	for _, d := range []schema.Schema{
+		{Name: "bad_table", Table: schema.Table{Name: "posts; drop"}, Fields: define.Fields},
+		{Name: "schemas", Fields: define.Fields},
+		{Name: "steal_versions", Table: schema.Table{Name: "schemas"}, Fields: define.Fields},
+		{Name: "steal_audit", Table: schema.Table{Name: "audit_histories"}, Fields: define.Fields},
+	} {
+		_, err = sm.Plan(d)
+		tt.Equal(true, err != nil)
+	}
+
+	plan, err := sm.Plan(define)
+	tt.NoError(err)
+	tt.Equal(true, len(plan) > 0)
+	tt.Equal(true, strings.Contains(strings.ToUpper(plan[0].SQL), "CREATE TABLE"))
+	_, ok := ss.Get("managed_posts")
+	tt.Equal(false, ok)
+
+	ctx := WithActor(context.Background(), "admin")
+	v, _, err := sm.Create(ctx, define)
+	tt.NoError(err)
+	tt.Equal(1, v.Version)
+	tt.Equal("admin", v.Operator)
+	tt.Equal(true, changed["managed_posts"] != nil)
+
+	_, _, err = sm.Create(ctx, define)
+	tt.Equal(true, errors.Is(err, ErrSchemaExists))
+	_, _, err = sm.Create(ctx, schema.Schema{Name: "managed_posts_copy", Table: schema.Table{Name: "managed_posts"}, Fields: define.Fields})
+	tt.Equal(true, err != nil)
+	_, ok = ss.Get("managed_posts_copy")
+	tt.Equal(false, ok)
+
+	_, err = ss.MustGet("managed_posts").Model().Insert(ztype.Map{"title": "hello"})
+	tt.NoError(err)
+
+	define.Fields = map[string]schema.Field{
+		"title": {Type: schema.String, Size: 50},
+		"views": {Type: schema.Int, Nullable: true},
+	}
+	v, plan, err = sm.Update(ctx, "managed_posts", define)
+	tt.NoError(err)
+	tt.Equal(2, v.Version)
+	tt.Equal(true, len(plan) > 0)
+	m := ss.MustGet("managed_posts")
+	_, ok = m.GetField("views")
+	tt.Equal(true, ok)
+
+	// 注册失败时保持原定义且不记录版本
+	f := true
+	_, _, err = sm.Update(ctx, "managed_posts", schema.Schema{
+		Options: schema.Options{Timestamps: &f},
+		Fields: map[string]schema.Field{
+			"title":      {Type: schema.String, Size: 50},
+			"created_at": {Type: schema.Time},
+		},
+	})
+	tt.Equal(true, err != nil)
+	tt.Equal(m, ss.MustGet("managed_posts"))
+
+	_, _, err = sm.Update(ctx, "unknown_posts", define)
+	tt.Equal(true, errors.Is(err, ErrSchemaNotManaged))
+
+	v, err = sm.Delete(ctx, "managed_posts")
+	tt.NoError(err)
+	tt.Equal(SchemaDelete, v.Action)
+	_, ok = ss.Get("managed_posts")
+	tt.Equal(false, ok)
+	tt.Equal(true, changed["managed_posts"] == nil)
+
+	versions, err := sm.Versions("managed_posts")
+	tt.NoError(err)
+	tt.Equal(3, len(versions))
+	tt.Equal(SchemaCreate, versions[0].Action)
+	tt.Equal(SchemaUpdate, versions[1].Action)
+	_, ok = versions[1].Define.Fields["views"]
+	tt.Equal(true, ok)
+
+	// 删除后数据表保留，重新创建可继续使用原数据
+	_, _, err = sm.Create(ctx, define)
+	tt.NoError(err)
+	count, err := ss.MustGet("managed_posts").Model().Count(Filter{})
+	tt.NoError(err)
+	tt.Equal(uint64(1), count)
+
+	defines, err := repo.Load()
+	tt.NoError(err)
+	tt.Equal(1, len(defines))
+	tt.Equal("managed_posts", defines[0].Name)
+}
+
+func TestDirSchemaRepository(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	dir := t.TempDir()
+	writeSchemaFile(t, dir, "dir_tags.yaml", `
+name: dir_tags
+fields:
+  label: {type: string, size: 20}
+`)
+
+	defines, err := parseSchema(dir)
+	tt.NoError(err)
+	_, ss := newTestSchemas(t, defines...)
+
+	sm, err := NewSchemaManager(ss, NewDirSchemaRepository(dir))
+	tt.NoError(err)
+	tt.Equal(true, sm.Managed("dir_tags"))
+
+	_, _, err = sm.Update(context.Background(), "dir_tags", defines[0])
+	tt.Equal(true, err != nil)
+
+	_, _, err = sm.Create(context.Background(), schema.Schema{
+		Name:   "dir_notes",
+		Fields: map[string]schema.Field{"body": {Type: schema.Text}},
+	})
+	tt.NoError(err)
+
+	_, err = os.Stat(filepath.Join(dir, "dir_notes.json"))
+	tt.NoError(err)
+	_, err = os.Stat(filepath.Join(dir, schemaHistoryDir, "dir_notes", "1.json"))
+	tt.NoError(err)
+
+	defines, err = parseSchema(dir)
+	tt.NoError(err)
+	tt.Equal(2, len(defines))
+
+	_, err = sm.Delete(context.Background(), "dir_notes")
+	tt.NoError(err)
+	_, err = os.Stat(filepath.Join(dir, "dir_notes.json"))
+	tt.Equal(true, os.IsNotExist(err))
+
+	versions, err := sm.Versions("dir_notes")
+	tt.NoError(err)
+	tt.Equal(2, len(versions))
+	tt.Equal(SchemaDelete, versions[1].Action)
+
+	// 名称拼接为路径前需校验，不能读取目录外的文件
+	_, err = sm.Versions("../" + schemaHistoryDir)
+	tt.EqualTrue(err != nil)
+	_, err = NewDirSchemaRepository(dir).Versions("../../etc")
+	tt.EqualTrue(err != nil)
+}
@@ -0,0 +1,233 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/zlsgo/app_module/model/hook"
+)
+
+// SchemaWatchOptions 模型目录热加载配置
+type SchemaWatchOptions struct {
+	// OnReload 模型重新注册后回调，可用于同步外部持有的 Store
+	OnReload func(name string, m *Schema)
+	// Env 模型文件中 env 覆盖配置使用的环境
+	Env string
+	// Interval 检查文件变更的间隔，默认 1 秒
+	Interval time.Duration
+}
+
+// SchemaWatcher 轮询模型目录，定义变化的模型通过 Reg 强制重新注册
+type SchemaWatcher struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+	schemas *Schemas
+	digests map[string]string
+	stamp   string
+	dir     string
+	opt     SchemaWatchOptions
+	mu      sync.Mutex
+}
+
+// WatchDir 监听模型目录，启动时目录中的定义视为已注册，之后仅重新注册内容变化的模型
+func (ss *Schemas) WatchDir(dir string, opts ...func(*SchemaWatchOptions)) (*SchemaWatcher, error) {
+	opt := SchemaWatchOptions{Interval: time.Second}
+	for i := range opts {
+		opts[i](&opt)
+	}
+	if opt.Interval <= 0 {
+		opt.Interval = time.Second
+	}
+
+	stamp, err := schemaDirStamp(dir)
+	if err != nil {
+		return nil, err
+	}
+	l := newSchemaLoader(dir, opt.Env)
+	defines, err := l.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &SchemaWatcher{
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		schemas: ss,
+		digests: make(map[string]string, len(defines)),
+		stamp:   stamp,
+		dir:     dir,
+		opt:     opt,
+	}
+	for i := range defines {
+		w.digests[defines[i].Name] = l.digests[defines[i].SchemaPath]
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// Stop 停止监听并等待轮询协程退出
+func (w *SchemaWatcher) Stop() {
+	w.cancel()
+	<-w.done
+}
+
+// Reload 立即重新读取模型目录，返回重新注册的模型；
+// 任一文件无法解析时不做任何变更，单个模型注册或迁移失败时该模型保持原定义
+func (w *SchemaWatcher) Reload() ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if stamp, err := schemaDirStamp(w.dir); err == nil {
+		w.stamp = stamp
+	}
+	return w.reload()
+}
+
+func (w *SchemaWatcher) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.opt.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stamp, err := schemaDirStamp(w.dir)
+		if err != nil {
+			modelLogger.Errorf("schema watch %s error: %v\n", w.dir, err)
+			continue
+		}
+
+		w.mu.Lock()
+		// 无效文件只报告一次，修改后再重新尝试
+		if stamp != w.stamp {
+			w.stamp = stamp
+			if names, err := w.reload(); err != nil {
+				modelLogger.Errorf("schema reload %s error: %v\n", w.dir, err)
+			} else if len(names) > 0 {
+				modelLogger.Infof("schema reload %s: %s\n", w.dir, strings.Join(names, ", "))
+			}
+		}
+		w.mu.Unlock()
+	}
+}
+
+func (w *SchemaWatcher) reload() ([]string, error) {
+	l := newSchemaLoader(w.dir, w.opt.Env)
+	defines, err := l.parse()
+	if err == nil {
+		for i := range defines {
+			if defines[i].Name == "" {
+				err = errors.New("models name can not be empty, schema path: " + defines[i].SchemaPath)
+				break
+			}
+		}
+	}
+	if err != nil {
+		w.schemas.emit(nil, hook.EventSchemaReloadFailed, w.dir, err)
+		return nil, err
+	}
+
+	var (
+		names []string
+		errs  []error
+	)
+	for i := range defines {
+		d := defines[i]
+		digest := l.digests[d.SchemaPath]
+		if w.digests[d.Name] == digest {
+			continue
+		}
+
+		m, err := w.schemas.Reg(d.Name, d, true)
+		if err != nil {
+			errs = append(errs, err)
+			w.schemas.emit(nil, hook.EventSchemaReloadFailed, d.SchemaPath, err)
+			continue
+		}
+
+		w.digests[d.Name] = digest
+		names = append(names, d.Name)
+		if w.opt.OnReload != nil {
+			w.opt.OnReload(d.Name, m)
+		}
+		w.schemas.emit(m, hook.EventSchemaReload, d.Name, d.SchemaPath)
+	}
+
+	// 文件删除不会注销模型，避免正在使用的数据表失去定义
+	exists := make(map[string]struct{}, len(defines))
+	for i := range defines {
+		exists[defines[i].Name] = struct{}{}
+	}
+	for name := range w.digests {
+		if _, ok := exists[name]; !ok {
+			modelLogger.Warnf("schema %s file removed, keep the registered definition\n", name)
+			delete(w.digests, name)
+		}
+	}
+
+	return names, errors.Join(errs...)
+}
+
+// schemaDirStamp 目录内模型文件的路径、大小与修改时间指纹
+func schemaDirStamp(dir string) (string, error) {
+	var stamps []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return skipHiddenDir(dir, path, d)
+		}
+		if !zarray.Contains(schemaFileExts, strings.ToLower(filepath.Ext(path))) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		stamps = append(stamps, path+"|"+strconv.FormatInt(info.Size(), 10)+"|"+strconv.FormatInt(info.ModTime().UnixNano(), 10))
+		return nil
+	})
+	sort.Strings(stamps)
+	return strings.Join(stamps, "\n"), err
+}
+
+// emit 触发模型集合事件，m 不为空时同时触发该模型的钩子；处理函数的错误仅记录日志
+func (ss *Schemas) emit(m *Schema, event hook.Event, data ...any) {
+	if m != nil {
+		if err := m.hook(event, data...); err != nil {
+			modelLogger.Errorf("%s hook error: %v\n", event, err)
+		}
+		return
+	}
+
+	var handlers []hookHandler
+	for _, h := range []*Hooks{globalHooks, ss.hooks} {
+		handlers = append(handlers, h.get(event)...)
+	}
+	sort.SliceStable(handlers, func(i, j int) bool {
+		return handlers[i].priority < handlers[j].priority
+	})
+	for i := range handlers {
+		if err := handlers[i].fn(context.Background(), data...); err != nil {
+			modelLogger.Errorf("%s hook error: %v\n", event, err)
+		}
+	}
+}
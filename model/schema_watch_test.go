@@ -0,0 +1,129 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztype"
+)
+
+func TestSchemaWatchReload(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	dir := t.TempDir()
+	writeSchemaFile(t, dir, "watch_users.yaml", `
+name: watch_users
+fields:
+  name: {type: string, size: 20}
+`)
+	writeSchemaFile(t, dir, "watch_posts.yaml", `
+name: watch_posts
+fields:
+  title: {type: string, size: 20}
+`)
+
+	defines, err := parseSchema(dir)
+	tt.NoError(err)
+	_, ss := newTestSchemas(t, defines...)
+
+	var reloaded, failed []string
+	ss.Hooks().OnSchemaReload(func(_ context.Context, name, _ string) error {
+		reloaded = append(reloaded, name)
+		return nil
+	})
+	ss.Hooks().OnSchemaReloadFailed(func(_ context.Context, path string, _ error) error {
+		failed = append(failed, path)
+		return nil
+	})
+
+	old := ss.MustGet("watch_users")
+	old.Hooks().OnBeforeInsert(func(_ context.Context, data *ztype.Map) error {
+		(*data)["name"] = "hooked"
+		return nil
+	})
+	oldStore := old.Model()
+	_, err = oldStore.Insert(ztype.Map{"name": "a"})
+	tt.NoError(err)
+
+	synced := make(map[string]*Schema)
+	w, err := ss.WatchDir(dir, func(o *SchemaWatchOptions) {
+		o.Interval = time.Hour
+		o.OnReload = func(name string, m *Schema) { synced[name] = m }
+	})
+	tt.NoError(err)
+	defer w.Stop()
+
+	names, err := w.Reload()
+	tt.NoError(err)
+	tt.Equal(0, len(names))
+
+	writeSchemaFile(t, dir, "watch_users.yaml", `
+name: watch_users
+fields:
+  name: {type: string, size: 20}
+  age: {type: int, nullable: true}
+`)
+	names, err = w.Reload()
+	tt.NoError(err)
+	tt.Equal([]string{"watch_users"}, names)
+	tt.Equal([]string{"watch_users"}, reloaded)
+
+	m := ss.MustGet("watch_users")
+	tt.Equal(true, m != old)
+	tt.Equal(m, synced["watch_users"])
+	_, ok := m.GetField("age")
+	tt.Equal(true, ok)
+	_, ok = old.GetField("age")
+	tt.Equal(false, ok)
+
+	_, err = m.Model().Insert(ztype.Map{"name": "b", "age": 18})
+	tt.NoError(err)
+	row, err := m.Model().FindOne(Filter{"age": 18})
+	tt.NoError(err)
+	tt.Equal("hooked", row.Get("name").String())
+
+	// 旧 Store 仍可使用原定义完成查询
+	count, err := oldStore.Count(Filter{})
+	tt.NoError(err)
+	tt.Equal(uint64(2), count)
+
+	writeSchemaFile(t, dir, "watch_users.yaml", `
+name: watch_users
+fields:
+  name: {type: string, size: 20}
+  age: {type: integer}
+`)
+	_, err = w.Reload()
+	tt.Equal(true, err != nil)
+	tt.Equal([]string{dir}, failed)
+	tt.Equal(m, ss.MustGet("watch_users"))
+	tt.Equal(1, len(reloaded))
+
+	// 解析通过但注册失败时保留原定义
+	writeSchemaFile(t, dir, "watch_users.yaml", `
+name: watch_users
+options: {timestamps: true}
+fields:
+  name: {type: string, size: 20}
+  created_at: {type: time}
+`)
+	_, err = w.Reload()
+	tt.Equal(true, err != nil)
+	tt.Equal(2, len(failed))
+	tt.Equal(true, strings.HasSuffix(failed[1], "watch_users.yaml"))
+	tt.Equal(m, ss.MustGet("watch_users"))
+
+	posts := ss.MustGet("watch_posts")
+	writeSchemaFile(t, dir, "watch_users.yaml", `
+name: watch_users
+fields:
+  name: {type: string, size: 20}
+  age: {type: int, nullable: true}
+`)
+	names, err = w.Reload()
+	tt.NoError(err)
+	tt.Equal(0, len(names))
+	tt.Equal(posts, ss.MustGet("watch_posts"))
+}
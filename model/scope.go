@@ -0,0 +1,188 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+// SoftDeleteScope 内置软删除全局作用域名称
+const SoftDeleteScope = "soft_delete"
+
+const (
+	scopeKey        = placeHolder + "scope"
+	withoutScopeKey = placeHolder + "without_scope"
+)
+
+type scopeFilter struct {
+	names   []string
+	without bool
+}
+
+func (f scopeFilter) ToMap() ztype.Map {
+	return f.appendToMap(make(ztype.Map, 1))
+}
+
+func (f scopeFilter) appendToMap(dst ztype.Map) ztype.Map {
+	if dst == nil {
+		dst = make(ztype.Map, 1)
+	}
+	key := scopeKey
+	if f.without {
+		key = withoutScopeKey
+	}
+	names, _ := dst[key].([]string)
+	dst[key] = append(append(make([]string, 0, len(names)+len(f.names)), names...), f.names...)
+	return dst
+}
+
+// Scope 应用指定的命名作用域
+func Scope(names ...string) QueryFilter {
+	return scopeFilter{names: names}
+}
+
+// WithoutScope 排除指定的全局作用域，不传名称时排除全部全局作用域
+func WithoutScope(names ...string) QueryFilter {
+	if len(names) == 0 {
+		names = []string{"*"}
+	}
+	return scopeFilter{names: names, without: true}
+}
+
+// SetScope 设置作用域
+func (m *Schema) SetScope(name string, scope schema.Scope) {
+	m.define.Options.SetScope(name, scope)
+}
+
+// Scopes 获取作用域定义
+func (m *Schema) Scopes() schema.Scopes {
+	return m.define.Options.Scopes
+}
+
+// popScopeNames 取出并移除过滤条件中的作用域标记
+func popScopeNames(filter ztype.Map, key string) []string {
+	v, ok := filter[key]
+	if !ok {
+		return nil
+	}
+	delete(filter, key)
+	names, _ := v.([]string)
+	return names
+}
+
+// popNestedScopes 处理 $OR / $AND 等嵌套条件中的作用域标记：命名作用域合并到所在分支，
+// 排除标记返回给调用方在顶层处理，因为全局作用域作用于整个查询
+func popNestedScopes(m *Schema, filter ztype.Map) (without []string, err error) {
+	pop := func(sub ztype.Map) error {
+		names := popScopeNames(sub, scopeKey)
+		without = append(without, popScopeNames(sub, withoutScopeKey)...)
+		nested, err := popNestedScopes(m, sub)
+		if err != nil {
+			return err
+		}
+		without = append(without, nested...)
+		return applyNamedScopes(m, sub, names)
+	}
+	for _, v := range filter {
+		switch val := v.(type) {
+		case ztype.Map:
+			err = pop(val)
+		case ztype.Maps:
+			for i := range val {
+				if err = pop(val[i]); err != nil {
+					break
+				}
+			}
+		case []ztype.Map:
+			for i := range val {
+				if err = pop(val[i]); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+func scopeExcluded(without []string, name string) bool {
+	return zarray.Contains(without, "*") || zarray.Contains(without, name)
+}
+
+// applyScopes 合并命名作用域与未排除的全局作用域，全局作用域与已有条件同时生效
+func applyScopes(m *Schema, filter ztype.Map, names, without []string) error {
+	if err := applyNamedScopes(m, filter, names); err != nil {
+		return err
+	}
+	for _, name := range without {
+		if _, ok := m.define.Options.Scopes[name]; !ok && name != "*" && name != SoftDeleteScope {
+			return fmt.Errorf("%w: %s", ErrUnknownScope, name)
+		}
+	}
+
+	for name, scope := range m.define.Options.Scopes {
+		if !scope.Global || zarray.Contains(names, name) || scopeExcluded(without, name) {
+			continue
+		}
+		andScope(filter, scope)
+	}
+	return nil
+}
+
+// applyNamedScopes 合并指定的命名作用域，已存在的条件优先
+func applyNamedScopes(m *Schema, filter ztype.Map, names []string) error {
+	scopes := m.define.Options.Scopes
+	for _, name := range names {
+		scope, ok := scopes[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownScope, name)
+		}
+		mergeScope(filter, scope)
+	}
+	return nil
+}
+
+func mergeScope(filter ztype.Map, scope schema.Scope) {
+	for k, v := range scope.Filter {
+		if _, ok := filter[k]; !ok {
+			filter[k] = v
+		}
+	}
+	if scope.Func != nil {
+		scope.Func(filter)
+	}
+}
+
+// andScope 以 AND 追加全局作用域条件，与已有条件冲突的字段放入嵌套的 $AND 中，避免被调用方条件覆盖
+func andScope(filter ztype.Map, scope schema.Scope) {
+	conds := make(ztype.Map, len(scope.Filter))
+	for k, v := range scope.Filter {
+		conds[k] = v
+	}
+	if scope.Func != nil {
+		scope.Func(conds)
+	}
+	for k, v := range conds {
+		andCondition(filter, k, v)
+	}
+}
+
+func andCondition(filter ztype.Map, k string, v any) {
+	if _, ok := filter[k]; !ok {
+		filter[k] = v
+		return
+	}
+	sub, ok := filter[placeHolderAND].(ztype.Map)
+	if !ok {
+		sub = ztype.Map{}
+		if prev, exists := filter[placeHolderAND]; exists {
+			sub = ztype.ToMap(prev)
+		}
+		filter[placeHolderAND] = sub
+	}
+	andCondition(sub, k, v)
+}
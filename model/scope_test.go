@@ -0,0 +1,174 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func newScopeTestSchema(t *testing.T) *Schema {
+	b := true
+	s := schema.Schema{
+		Name: "scope_posts",
+		Table: schema.Table{
+			Name: "scope_posts",
+		},
+		Options: schema.Options{
+			SoftDeletes: &b,
+			Scopes: schema.Scopes{
+				"published": {Filter: ztype.Map{"status": 1}},
+				"visible":   {Filter: ztype.Map{"hidden": false}, Global: true},
+			},
+		},
+		Fields: map[string]schema.Field{
+			"title":  {Type: "string", Label: "Title"},
+			"status": {Type: "int", Label: "Status", Default: "0"},
+			"hidden": {Type: "bool", Label: "Hidden", Default: "false"},
+		},
+	}
+	_, schemas := newTestSchemas(t, s)
+	return schemas.MustGet("scope_posts")
+}
+
+func TestGetFilterScopes(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	m := newScopeTestSchema(t)
+
+	f, err := getFilter(m, Filter{})
+	tt.NoError(err)
+	tt.Equal(false, f["hidden"])
+	_, ok := f["status"]
+	tt.Equal(false, ok)
+	_, ok = f[DeletedAtKey]
+	tt.Equal(true, ok)
+
+	f, err = getFilter(m, And(Eq("title", "a"), Scope("published")))
+	tt.NoError(err)
+	tt.Equal(1, f["status"])
+	tt.Equal("a", f["title"])
+	_, ok = f[scopeKey]
+	tt.Equal(false, ok)
+
+	f, err = getFilter(m, And(Eq("status", 2), Scope("published")))
+	tt.NoError(err)
+	tt.Equal(2, f["status"])
+
+	f, err = getFilter(m, WithoutScope("visible"))
+	tt.NoError(err)
+	_, ok = f["hidden"]
+	tt.Equal(false, ok)
+	_, ok = f[DeletedAtKey]
+	tt.Equal(true, ok)
+
+	f, err = getFilter(m, WithoutScope(SoftDeleteScope))
+	tt.NoError(err)
+	_, ok = f[DeletedAtKey]
+	tt.Equal(false, ok)
+	tt.Equal(false, f["hidden"])
+
+	f, err = getFilter(m, WithoutScope())
+	tt.NoError(err)
+	tt.Equal(0, len(f))
+
+	// Or 中的命名作用域作用于所在分支，排除标记作用于整个查询
+	f, err = getFilter(m, Or(Scope("published"), Eq("title", "a")))
+	tt.NoError(err)
+	branches := f[placeHolderOR].([]ztype.Map)
+	tt.Equal(2, len(branches))
+	tt.Equal(1, branches[0]["status"])
+	_, ok = branches[0][scopeKey]
+	tt.Equal(false, ok)
+	tt.Equal(false, f["hidden"])
+
+	f, err = getFilter(m, Or(Eq("title", "a"), And(Eq("title", "b"), WithoutScope("visible"))))
+	tt.NoError(err)
+	_, ok = f["hidden"]
+	tt.Equal(false, ok)
+	for _, branch := range f[placeHolderOR].([]ztype.Map) {
+		_, ok = branch[withoutScopeKey]
+		tt.Equal(false, ok)
+	}
+}
+
+func TestScopeFunc(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	m := newScopeTestSchema(t)
+
+	m.SetScope("titled", schema.Scope{Func: func(filter ztype.Map) {
+		filter["title !="] = ""
+	}})
+	f, err := getFilter(m, Scope("titled"))
+	tt.NoError(err)
+	_, ok := f["title !="]
+	tt.Equal(true, ok)
+
+	_, err = getFilter(m, Scope("missing"))
+	tt.EqualTrue(errors.Is(err, ErrUnknownScope))
+	_, err = getFilter(m, Or(Eq("title", "a"), Scope("missing")))
+	tt.EqualTrue(errors.Is(err, ErrUnknownScope))
+	_, err = getFilter(m, WithoutScope("missing"))
+	tt.EqualTrue(errors.Is(err, ErrUnknownScope))
+}
+
+func TestGlobalScopeNotOverridden(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	m := newScopeTestSchema(t)
+
+	// 调用方条件与全局作用域同时生效
+	f, err := getFilter(m, Filter{"hidden": true})
+	tt.NoError(err)
+	tt.Equal(true, f["hidden"])
+	tt.Equal(false, f[placeHolderAND].(ztype.Map)["hidden"])
+
+	repo := m.Model().Repository()
+	_, err = repo.Insert(ztype.Map{"title": "a", "hidden": true})
+	tt.NoError(err)
+	rows, err := repo.Query().Where("hidden", true).Find()
+	tt.NoError(err)
+	tt.Equal(0, len(rows))
+	rows, err = repo.Query().Where("hidden", true).WithoutScope("visible").Find()
+	tt.NoError(err)
+	tt.Equal(1, len(rows))
+}
+
+func TestQueryScopes(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	m := newScopeTestSchema(t)
+	repo := m.Model().Repository()
+
+	for _, data := range []ztype.Map{
+		{"title": "a", "status": 1},
+		{"title": "b", "status": 0},
+		{"title": "c", "status": 1, "hidden": true},
+	} {
+		_, err := repo.Insert(data)
+		tt.NoError(err)
+	}
+
+	rows, err := repo.Query().Find()
+	tt.NoError(err)
+	tt.Equal(2, len(rows))
+
+	rows, err = repo.Query().Scope("published").Find()
+	tt.NoError(err)
+	tt.Equal(1, len(rows))
+	tt.Equal("a", rows[0].Get("title").String())
+
+	rows, err = repo.Query().Scope("published").WithoutScope("visible").Find()
+	tt.NoError(err)
+	tt.Equal(2, len(rows))
+
+	_, err = repo.Query().Where("title", "a").Delete()
+	tt.NoError(err)
+
+	total, err := repo.Query().Count()
+	tt.NoError(err)
+	tt.Equal(uint64(1), total)
+
+	total, err = repo.Query().WithoutScope(SoftDeleteScope).Count()
+	tt.NoError(err)
+	tt.Equal(uint64(2), total)
+}
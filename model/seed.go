@@ -0,0 +1,250 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/sohaha/zlsgo/ztype"
+)
+
+// Seeder 命名的数据填充器
+type Seeder struct {
+	// Generate 动态生成数据，可通过 SeedContext 引用依赖填充器写入的记录
+	Generate func(c *SeedContext) ([]ztype.Map, error)
+	// FakeRow 调整生成的假数据，i 为行序号
+	FakeRow func(c *SeedContext, i int, row ztype.Map)
+	Name    string
+	// Schema 写入的模型别名
+	Schema string
+	// DependsOn 需要先执行的填充器
+	DependsOn []string
+	// Envs 生效的环境，为空时所有环境生效
+	Envs []string
+	// Key 自然键字段，记录已存在时更新而不是重复插入
+	Key []string
+	// Rows 固定数据
+	Rows []ztype.Map
+	// Fake 按字段类型生成的假数据行数，同名填充器每次生成的数据相同
+	Fake int
+}
+
+// SeedReport 单个填充器的执行结果
+type SeedReport struct {
+	Name     string `json:"name"`
+	Inserted int    `json:"inserted"`
+	Updated  int    `json:"updated"`
+}
+
+// SeedContext 填充器执行上下文
+type SeedContext struct {
+	Schemas *Schemas
+	Rand    *rand.Rand
+	ids     map[string][]any
+	Env     string
+}
+
+// Store 获取模型存储
+func (c *SeedContext) Store(alias string) *Store {
+	return c.Schemas.MustGet(alias).Model()
+}
+
+// IDs 已执行的填充器写入或更新的记录主键
+func (c *SeedContext) IDs(seeder string) []any {
+	return c.ids[seeder]
+}
+
+// Seeds 填充器集合
+type Seeds struct {
+	schemas *Schemas
+	items   map[string]*Seeder
+	names   []string
+	mu      sync.RWMutex
+}
+
+// NewSeeds 创建填充器集合
+func NewSeeds(ss *Schemas) *Seeds {
+	return &Seeds{schemas: ss, items: make(map[string]*Seeder)}
+}
+
+// Add 注册填充器，名称不能重复
+func (s *Seeds) Add(seeders ...Seeder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range seeders {
+		seeder := seeders[i]
+		if seeder.Name == "" {
+			return fmt.Errorf("%w: seeder name can not be empty", ErrInvalidSeeder)
+		}
+		if _, ok := s.items[seeder.Name]; ok {
+			return fmt.Errorf("%w: seeder %s has been registered", ErrInvalidSeeder, seeder.Name)
+		}
+		if _, ok := s.schemas.Get(seeder.Schema); !ok {
+			return fmt.Errorf("%w: schema %s not found", ErrInvalidSeeder, seeder.Schema)
+		}
+		s.items[seeder.Name] = &seeder
+		s.names = append(s.names, seeder.Name)
+	}
+	return nil
+}
+
+// Run 执行当前环境的填充器，names 为空时执行全部，指定的填充器会先执行其依赖
+func (s *Seeds) Run(env string, names ...string) ([]SeedReport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(names) == 0 {
+		names = s.names
+	}
+	order, err := s.resolve(names)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &SeedContext{Schemas: s.schemas, Env: env, ids: make(map[string][]any, len(order))}
+	reports := make([]SeedReport, 0, len(order))
+	for _, seeder := range order {
+		if len(seeder.Envs) > 0 && !zarray.Contains(seeder.Envs, env) {
+			continue
+		}
+		report, err := runSeeder(c, seeder)
+		if err != nil {
+			return reports, fmt.Errorf("seeder %s: %w", seeder.Name, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// resolve 按依赖关系排序，同级保持注册顺序
+func (s *Seeds) resolve(names []string) ([]*Seeder, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(s.items))
+	order := make([]*Seeder, 0, len(s.items))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		seeder, ok := s.items[name]
+		if !ok {
+			return fmt.Errorf("%w: seeder %s not found", ErrInvalidSeeder, name)
+		}
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w: %v", ErrSeedCycle, append(path, name))
+		}
+		state[name] = visiting
+		for _, dep := range seeder.DependsOn {
+			if err := visit(dep, append(path[:len(path):len(path)], name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		order = append(order, seeder)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// runSeeder 在事务中写入单个填充器的数据
+func runSeeder(c *SeedContext, seeder *Seeder) (report SeedReport, err error) {
+	report.Name = seeder.Name
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(seeder.Name))
+	c.Rand = rand.New(rand.NewSource(int64(h.Sum64())))
+
+	rows := make([]ztype.Map, 0, len(seeder.Rows)+seeder.Fake)
+	for i := range seeder.Rows {
+		rows = append(rows, cloneFilterMap(seeder.Rows[i]))
+	}
+	if seeder.Generate != nil {
+		generated, err := seeder.Generate(c)
+		if err != nil {
+			return report, err
+		}
+		rows = append(rows, generated...)
+	}
+	m := c.Schemas.MustGet(seeder.Schema)
+	for i := 0; i < seeder.Fake; i++ {
+		row := FakeRow(m, c.Rand, i)
+		if seeder.FakeRow != nil {
+			seeder.FakeRow(c, i, row)
+		}
+		rows = append(rows, row)
+	}
+
+	ids := make([]any, 0, len(rows))
+	err = transaction(m, func(tx *Schema) error {
+		store := tx.Model()
+		for i := range rows {
+			id, updated, err := seedRow(store, seeder.Key, rows[i])
+			if err != nil {
+				return fmt.Errorf("row %d: %w", i+1, err)
+			}
+			if updated {
+				report.Updated++
+			} else {
+				report.Inserted++
+			}
+			if id != nil {
+				ids = append(ids, id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return SeedReport{Name: seeder.Name}, err
+	}
+	c.ids[seeder.Name] = ids
+	return report, nil
+}
+
+// seedRow 按自然键插入或更新单行
+func seedRow(store *Store, key []string, row ztype.Map) (id any, updated bool, err error) {
+	if len(key) > 0 {
+		filter := make(Filter, len(key))
+		for _, k := range key {
+			v, ok := row[k]
+			if !ok {
+				return nil, false, fmt.Errorf("%w: missing key %s", ErrInvalidData, k)
+			}
+			filter[k] = v
+		}
+
+		exist, err := store.FindOne(filter, func(co *CondOptions) {
+			co.Fields = store.schema.PrimaryKeys()
+		})
+		if err == nil {
+			data := cloneFilterMap(row)
+			for _, k := range key {
+				delete(data, k)
+			}
+			if len(data) > 0 {
+				if _, err = store.UpdateMany(filter, data); err != nil {
+					return nil, false, err
+				}
+			}
+			return exist[idKey], true, nil
+		}
+		if !errors.Is(err, ErrNoRecord) {
+			return nil, false, err
+		}
+	}
+
+	id, err = store.Insert(row)
+	return id, false, err
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sohaha/zlsgo/ztype"
+	mSchema "github.com/zlsgo/app_module/model/schema"
+	"github.com/zlsgo/zdb/schema"
+)
+
+var fakeWords = [...]string{
+	"alpha", "bravo", "cedar", "delta", "ember", "falcon", "garnet", "harbor",
+	"island", "jade", "kernel", "lotus", "maple", "nova", "orbit", "pixel",
+	"quartz", "river", "summit", "tango", "umber", "velvet", "willow", "zephyr",
+}
+
+// FakeRow 按字段类型生成一行假数据，seq 用于区分行以避免唯一字段冲突
+func FakeRow(m *Schema, r *rand.Rand, seq int) ztype.Map {
+	names := make([]string, 0, len(m.define.Fields))
+	for name := range m.define.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	row := make(ztype.Map, len(names))
+	for _, name := range names {
+		f := m.define.Fields[name]
+		row[name] = fakeValue(name, &f, r, seq)
+	}
+	return row
+}
+
+func fakeValue(name string, f *mSchema.Field, r *rand.Rand, seq int) any {
+	if len(f.Options.Enum) > 0 {
+		v := f.Options.Enum[r.Intn(len(f.Options.Enum))].Value
+		if f.Options.IsArray {
+			return []string{v}
+		}
+		return v
+	}
+
+	switch f.Type {
+	case schema.Bool:
+		return r.Intn(2) == 1
+	case schema.Int8:
+		return r.Intn(128)
+	case schema.Uint8:
+		return r.Intn(256)
+	case schema.Int16, schema.Uint16:
+		return r.Intn(32768)
+	case schema.Int, schema.Int32, schema.Int64, schema.Uint, schema.Uint32, schema.Uint64:
+		return r.Intn(10000)
+	case schema.Float:
+		return float64(r.Intn(100000)) / 100
+	case mSchema.Decimal:
+		return fakeDecimal(f, r)
+	case schema.Time:
+		return time.Now().Add(-time.Duration(r.Int63n(int64(365 * 24 * time.Hour)))).Truncate(time.Second)
+	case schema.JSON:
+		if f.Options.IsArray {
+			return "[]"
+		}
+		return "{}"
+	case schema.Bytes:
+		b := make([]byte, 16)
+		_, _ = r.Read(b)
+		return b
+	case schema.Text:
+		words := make([]string, 8+r.Intn(8))
+		for i := range words {
+			words[i] = fakeWords[r.Intn(len(fakeWords))]
+		}
+		return strings.Join(words, " ")
+	default:
+		return fakeString(name, f, r, seq)
+	}
+}
+
+// fakeString 根据字段名推断常见格式，结果不超过字段长度
+func fakeString(name string, f *mSchema.Field, r *rand.Rand, seq int) string {
+	word := fakeWords[r.Intn(len(fakeWords))]
+	suffix := strconv.Itoa(seq + 1)
+
+	var v string
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "email"):
+		v = word + suffix + "@example.com"
+	case strings.Contains(lower, "phone") || strings.Contains(lower, "mobile"):
+		v = fmt.Sprintf("13%09d", seq+1)
+	case strings.Contains(lower, "url") || strings.Contains(lower, "link"):
+		v = "https://example.com/" + word + "/" + suffix
+	default:
+		v = word + "_" + suffix
+	}
+
+	size := int(f.Size)
+	if size == 0 || size >= len(v) {
+		return v
+	}
+	// 优先保留序号部分
+	if len(suffix) >= size {
+		return suffix[len(suffix)-size:]
+	}
+	return word[:min(len(word), size-len(suffix)-1)] + "_" + suffix
+}
+
+func fakeDecimal(f *mSchema.Field, r *rand.Rand) string {
+	precision, scale := int(f.Size), int(f.Scale)
+	if precision == 0 {
+		precision, scale = defaultDecimalPrecision, defaultDecimalScale
+	}
+	digits := min(precision-scale, 6)
+	v := "0"
+	if digits > 0 {
+		v = strconv.Itoa(r.Intn(pow10(digits)))
+	}
+	if scale > 0 {
+		frac := strconv.Itoa(r.Intn(pow10(min(scale, 6))))
+		v += "." + strings.Repeat("0", min(scale, 6)-len(frac)) + frac
+	}
+	return v
+}
+
+func pow10(n int) int {
+	v := 1
+	for i := 0; i < n; i++ {
+		v *= 10
+	}
+	return v
+}
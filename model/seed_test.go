@@ -0,0 +1,171 @@
+package model
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func seedTestSchemas(t *testing.T) *Schemas {
+	users := schema.Schema{
+		Name:  "seed_users",
+		Table: schema.Table{Name: "seed_users"},
+		Fields: map[string]schema.Field{
+			"email":  {Type: schema.String, Size: 30, Unique: true},
+			"name":   {Type: schema.String, Size: 6},
+			"age":    {Type: schema.Int8},
+			"active": {Type: schema.Bool},
+			"role": {Type: schema.String, Size: 10, Options: schema.FieldOption{
+				Enum: []schema.FieldEnum{{Value: "admin"}, {Value: "user"}},
+			}},
+			"balance": {Type: schema.Decimal, Size: 8, Scale: 2},
+			"born":    {Type: schema.Time, Nullable: true},
+		},
+	}
+	posts := schema.Schema{
+		Name:  "seed_posts",
+		Table: schema.Table{Name: "seed_posts"},
+		Fields: map[string]schema.Field{
+			"user_id": {Type: schema.Int},
+			"title":   {Type: schema.String, Size: 50},
+		},
+	}
+	_, ss := newTestSchemas(t, users, posts)
+	return ss
+}
+
+func TestSeeds(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	ss := seedTestSchemas(t)
+	seeds := NewSeeds(ss)
+
+	err := seeds.Add(
+		Seeder{
+			Name:      "posts",
+			Schema:    "seed_posts",
+			DependsOn: []string{"admins", "users"},
+			Generate: func(c *SeedContext) ([]ztype.Map, error) {
+				rows := make([]ztype.Map, 0)
+				for _, id := range c.IDs("users") {
+					rows = append(rows, ztype.Map{"user_id": id, "title": "hello"})
+				}
+				return rows, nil
+			},
+		},
+		Seeder{
+			Name:   "admins",
+			Schema: "seed_users",
+			Key:    []string{"email"},
+			Rows:   []ztype.Map{{"email": "root@example.com", "name": "root", "role": "admin"}},
+		},
+		Seeder{
+			Name:   "users",
+			Schema: "seed_users",
+			Key:    []string{"email"},
+			Envs:   []string{"dev", "test"},
+			Fake:   5,
+		},
+	)
+	tt.NoError(err)
+
+	reports, err := seeds.Run("prod")
+	tt.NoError(err)
+	tt.Equal(2, len(reports))
+	tt.Equal("admins", reports[0].Name)
+	tt.Equal("posts", reports[1].Name)
+	tt.Equal(0, reports[1].Inserted)
+
+	reports, err = seeds.Run("dev", "posts")
+	tt.NoError(err)
+	tt.Equal([]SeedReport{
+		{Name: "admins", Updated: 1},
+		{Name: "users", Inserted: 5},
+		{Name: "posts", Inserted: 5},
+	}, reports)
+
+	reports, err = seeds.Run("dev", "users")
+	tt.NoError(err)
+	tt.Equal([]SeedReport{{Name: "users", Updated: 5}}, reports)
+
+	users := ss.MustGet("seed_users").Model()
+	count, err := users.Count(Filter{})
+	tt.NoError(err)
+	tt.Equal(uint64(6), count)
+	count, err = ss.MustGet("seed_posts").Model().Count(Filter{})
+	tt.NoError(err)
+	tt.Equal(uint64(5), count)
+
+	tt.Equal(true, seeds.Add(Seeder{Name: "users", Schema: "seed_users"}) != nil)
+	tt.Equal(true, errors.Is(seeds.Add(Seeder{Name: "x", Schema: "missing"}), ErrInvalidSeeder))
+
+	cycle := NewSeeds(ss)
+	tt.NoError(cycle.Add(
+		Seeder{Name: "a", Schema: "seed_users", DependsOn: []string{"b"}},
+		Seeder{Name: "b", Schema: "seed_users", DependsOn: []string{"a"}},
+	))
+	_, err = cycle.Run("dev")
+	tt.Equal(true, errors.Is(err, ErrSeedCycle))
+}
+
+func TestFakeRow(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	ss := seedTestSchemas(t)
+	m := ss.MustGet("seed_users")
+
+	a := FakeRow(m, rand.New(rand.NewSource(1)), 0)
+	b := FakeRow(m, rand.New(rand.NewSource(1)), 0)
+	tt.Equal(a, b)
+	tt.Equal(true, len(a.Get("name").String()) <= 6)
+	tt.Equal(true, a.Get("role").String() == "admin" || a.Get("role").String() == "user")
+
+	for i := 0; i < 20; i++ {
+		_, err := m.Model().Insert(FakeRow(m, rand.New(rand.NewSource(int64(i))), i))
+		tt.NoError(err)
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	ss := seedTestSchemas(t)
+
+	dir := t.TempDir()
+	yml := filepath.Join(dir, "users.yaml")
+	tt.NoError(os.WriteFile(yml, []byte(`
+seed_users:
+  - id: 10
+    email: a@example.com
+    name: a
+    role: admin
+seed_posts:
+  - user_id: 10
+    title: first
+`), 0o644))
+	js := filepath.Join(dir, "posts.json")
+	tt.NoError(os.WriteFile(js, []byte(`{"seed_posts": [{"user_id": 10, "title": "second"}]}`), 0o644))
+
+	posts := ss.MustGet("seed_posts").Model()
+	t.Run("load", func(t *testing.T) {
+		LoadFixtures(t, ss, nil, yml, js)
+
+		count, err := posts.Count(Filter{"user_id": 10})
+		tt.NoError(err)
+		tt.Equal(uint64(2), count)
+		row, err := ss.MustGet("seed_users").Model().FindOneByID(10)
+		tt.NoError(err)
+		tt.Equal("a", row.Get("name").String())
+	})
+
+	count, err := posts.Count(Filter{})
+	tt.NoError(err)
+	tt.Equal(uint64(0), count)
+
+	f := NewFixtures(ss)
+	tt.Equal(true, f.Parse([]byte(`missing: [{a: 1}]`)) != nil)
+	tt.Equal(true, f.Parse([]byte(`- a`)) != nil)
+}
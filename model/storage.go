@@ -49,6 +49,9 @@ type CondOptions struct {
 	Join      []StorageJoin
 	Limit     int
 	Offset    int
+	// 行锁，仅在事务中生效
+	Lock     LockMode
+	LockWait LockWait
 }
 
 // InsertOptions 插入选项
@@ -70,6 +73,30 @@ type Storageer interface {
 	Update(table string, data ztype.Map, filter ztype.Map, fn ...func(*CondOptions)) (int64, error)
 }
 
+// TransactionStater 可感知事务状态的存储
+type TransactionStater interface {
+	InTransaction() bool
+}
+
+// TransactionOptioner 支持事务选项的存储
+type TransactionOptioner interface {
+	TransactionWithOptions(opts TxOptions, run func(s Storageer) error) error
+}
+
+// BulkUpdater 支持以单条语句为多行写入不同值的存储
+type BulkUpdater interface {
+	// BulkUpdate 按 keyField 更新多行，每行包含 keyField 与需要更新的字段，仅更新同时满足 filter（作用域、软删除与租户等条件）的行
+	BulkUpdate(table, keyField string, rows ztype.Maps, filter ztype.Map) error
+}
+
+// inTransaction 判断存储是否处于事务中
+func inTransaction(s Storageer) bool {
+	if t, ok := s.(TransactionStater); ok {
+		return t.InTransaction()
+	}
+	return false
+}
+
 // PageInfo 分页信息
 type PageInfo struct {
 	zdb.Pages
@@ -80,3 +107,15 @@ type Migrationer interface {
 	HasTable() bool
 	GetFields() (ztype.Map, error)
 }
+
+// MigrationPlanner 支持预演迁移的存储
+type MigrationPlanner interface {
+	// Plan 返回自动迁移将执行的语句，不修改数据库
+	Plan(deleteColumn ...DealOldColumn) ([]MigrationStep, error)
+}
+
+// MigrationStep 迁移语句
+type MigrationStep struct {
+	SQL    string        `json:"sql"`
+	Values []interface{} `json:"values,omitempty"`
+}
@@ -1,15 +1,20 @@
 package model
 
 import (
+	"database/sql"
+	"strconv"
 	"strings"
 
 	"github.com/sohaha/zlsgo/ztype"
 	"github.com/zlsgo/zdb"
+	"github.com/zlsgo/zdb/driver"
 )
 
 type SQL struct {
 	db      *zdb.DB
 	Options SQLOptions
+	inTx    bool
+	depth   int
 }
 
 type SQLOptions struct {
@@ -51,14 +56,122 @@ func (s *SQL) Migration(model *Schema) Migrationer {
 }
 
 func (s *SQL) Transaction(run func(s Storageer) error) (err error) {
+	return s.TransactionWithOptions(TxOptions{}, run)
+}
+
+// TransactionWithOptions 按选项开启事务，已处于事务中时使用保存点且忽略选项
+func (s *SQL) TransactionWithOptions(opts TxOptions, run func(s Storageer) error) (err error) {
+	if s.inTx {
+		return s.savepoint(run)
+	}
 	return s.db.Transaction(func(db *zdb.DB) (err error) {
-		return run(&SQL{
+		tx := &SQL{
 			db:      db,
 			Options: s.Options,
-		})
+			inTx:    true,
+		}
+		reset, err := tx.applyTxOptions(opts)
+		if err != nil {
+			return err
+		}
+		if reset != nil {
+			defer reset()
+		}
+		return run(tx)
 	})
 }
 
+// savepoint 嵌套事务，失败时只回滚到保存点
+func (s *SQL) savepoint(run func(s Storageer) error) (err error) {
+	depth := s.depth + 1
+	name := "sp_" + strconv.Itoa(depth)
+	if _, err = s.db.Exec("SAVEPOINT " + name); err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = s.db.Exec("ROLLBACK TO SAVEPOINT " + name)
+			panic(p)
+		}
+		if err != nil {
+			_, _ = s.db.Exec("ROLLBACK TO SAVEPOINT " + name)
+		}
+		_, rerr := s.db.Exec("RELEASE SAVEPOINT " + name)
+		if err == nil {
+			err = rerr
+		}
+	}()
+
+	return run(&SQL{
+		db:      s.db,
+		Options: s.Options,
+		inTx:    true,
+		depth:   depth,
+	})
+}
+
+// applyTxOptions 在事务开始时设置隔离级别与只读，返回事务结束前需要执行的还原操作
+func (s *SQL) applyTxOptions(opts TxOptions) (func(), error) {
+	if opts == (TxOptions{}) {
+		return nil, nil
+	}
+
+	switch s.db.GetDriver().Value() {
+	case driver.SQLite:
+		// SQLite 的事务总是可串行化的
+		if opts.Isolation != sql.LevelDefault && opts.Isolation != sql.LevelSerializable {
+			return nil, ErrTxOptionsNotSupported
+		}
+		if !opts.ReadOnly {
+			return nil, nil
+		}
+		if _, err := s.db.Exec("PRAGMA query_only = ON"); err != nil {
+			return nil, err
+		}
+		return func() {
+			_, _ = s.db.Exec("PRAGMA query_only = OFF")
+		}, nil
+	case driver.PostgreSQL, driver.MySQL:
+		stmt, err := setTransactionStatement(opts)
+		if err != nil {
+			return nil, err
+		}
+		_, err = s.db.Exec(stmt)
+		return nil, err
+	default:
+		return nil, ErrTxOptionsNotSupported
+	}
+}
+
+// setTransactionStatement 生成事务特性语句，需作为事务中的第一条语句执行
+func setTransactionStatement(opts TxOptions) (string, error) {
+	modes := make([]string, 0, 2)
+	if opts.Isolation != sql.LevelDefault {
+		level, ok := isolationLevels[opts.Isolation]
+		if !ok {
+			return "", ErrTxOptionsNotSupported
+		}
+		modes = append(modes, "ISOLATION LEVEL "+level)
+	}
+	if opts.ReadOnly {
+		modes = append(modes, "READ ONLY")
+	}
+	return "SET TRANSACTION " + strings.Join(modes, ", "), nil
+}
+
+var isolationLevels = map[sql.IsolationLevel]string{
+	sql.LevelReadUncommitted: "READ UNCOMMITTED",
+	sql.LevelReadCommitted:   "READ COMMITTED",
+	sql.LevelRepeatableRead:  "REPEATABLE READ",
+	sql.LevelSerializable:    "SERIALIZABLE",
+}
+
+// InTransaction 是否处于事务中
+func (s *SQL) InTransaction() bool {
+	return s.inTx
+}
+
 func sqlOrderBy(orderBy []OrderByItem, fieldPrefix string) (o []string) {
 	l := len(orderBy)
 	if l == 0 {
@@ -3,13 +3,17 @@ package model
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/sohaha/zlsgo/zarray"
 	"github.com/sohaha/zlsgo/zstring"
 	"github.com/sohaha/zlsgo/ztype"
 	"github.com/sohaha/zlsgo/zutil"
 	"github.com/zlsgo/zdb"
 	"github.com/zlsgo/zdb/builder"
+	"github.com/zlsgo/zdb/driver"
 )
 
 const (
@@ -209,6 +213,100 @@ func (s *SQL) Delete(table string, filter ztype.Map, fn ...func(*CondOptions)) (
 	})
 }
 
+// BulkUpdate 生成 UPDATE ... SET col = CASE key WHEN ... END WHERE key IN (...) AND key IN (SELECT key ... WHERE filter)，未提供的字段保持原值
+func (s *SQL) BulkUpdate(table, keyField string, rows ztype.Maps, filter ztype.Map) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	columns := make([]string, 0)
+	for _, row := range rows {
+		for k := range row {
+			if k != keyField && !zarray.Contains(columns, k) {
+				columns = append(columns, k)
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+	sort.Strings(columns)
+
+	// filter 以子查询作用于更新语句，外包一层派生表以兼容 MySQL 不允许子查询引用被更新表的限制
+	scope := builder.Query(table)
+	scope.SetDriver(s.db.GetDriver())
+	scope.Select(keyField)
+	exprs, err := s.parseExprs(scope.Cond, filter)
+	if err != nil {
+		return err
+	}
+	var scopeSQL string
+	var scopeValues []any
+	if len(exprs) > 0 {
+		scope.Where(exprs...)
+		if scopeSQL, scopeValues, err = scope.Build(); err != nil {
+			return err
+		}
+	}
+
+	postgres := s.db.GetDriver().Value() == driver.PostgreSQL
+	values := make([]any, 0, len(rows)*(len(columns)*2+1)+len(scopeValues))
+	if postgres {
+		// PostgreSQL 按序号绑定参数，子查询已占用前 len(scopeValues) 个序号
+		values = append(values, scopeValues...)
+	}
+	bind := func(v any) string {
+		values = append(values, v)
+		if postgres {
+			return "$" + strconv.Itoa(len(values))
+		}
+		return "?"
+	}
+
+	sets := make([]string, 0, len(columns))
+	for _, col := range columns {
+		var b strings.Builder
+		b.WriteString(col + " = CASE " + keyField)
+		for _, row := range rows {
+			v, ok := row[col]
+			if !ok {
+				continue
+			}
+			b.WriteString(" WHEN " + bind(row[keyField]) + " THEN " + bind(v))
+		}
+		b.WriteString(" ELSE " + col + " END")
+		sets = append(sets, b.String())
+	}
+
+	keys := make([]string, len(rows))
+	for i, row := range rows {
+		keys[i] = bind(row[keyField])
+	}
+
+	where := keyField + " IN (" + strings.Join(keys, ", ") + ")"
+	if scopeSQL != "" {
+		where += " AND " + keyField + " IN (SELECT " + keyField + " FROM (" + scopeSQL + ") AS bulk_scope)"
+		if !postgres {
+			values = append(values, scopeValues...)
+		}
+	}
+
+	_, err = s.db.Exec("UPDATE "+table+" SET "+strings.Join(sets, ", ")+" WHERE "+where, values...)
+	return err
+}
+
+// maxBindParams 单条语句允许的参数数量，SQLite 按旧版本默认值 999 计算
+func maxBindParams(s Storageer) int {
+	sqlStorage, ok := s.(*SQL)
+	if !ok {
+		return 0
+	}
+	if sqlStorage.db.GetDriver().Value() == driver.SQLite {
+		return 999
+	}
+	return 65535
+}
+
 func (s *SQL) First(table string, filter ztype.Map, fn ...func(*CondOptions)) (ztype.Map, error) {
 	rows, err := s.Find(table, filter, func(so *CondOptions) {
 		so.Limit = 1
@@ -233,7 +331,11 @@ func (s *SQL) Find(table string, filter ztype.Map, fn ...func(*CondOptions)) (zt
 			f(o)
 		}
 	}
-	items, err := s.db.Find(table, func(b *builder.SelectBuilder) error {
+	if o.Lock != LockNone && !s.inTx {
+		return nil, ErrLockOutsideTransaction
+	}
+
+	query := func(b *builder.SelectBuilder) error {
 		var fieldPrefix string
 		hasJoin := len(o.Join) > 0
 		if hasJoin {
@@ -274,7 +376,17 @@ func (s *SQL) Find(table string, filter ztype.Map, fn ...func(*CondOptions)) (zt
 		}
 
 		return nil
-	})
+	}
+
+	var (
+		items ztype.Maps
+		err   error
+	)
+	if lock := lockClause(s.db.GetDriver().Value(), o.Lock, o.LockWait); lock != "" {
+		items, err = s.findWithLock(table, lock, query)
+	} else {
+		items, err = s.db.Find(table, query)
+	}
 
 	if err != nil && err != zdb.ErrNotFound {
 		return items, err
@@ -283,6 +395,22 @@ func (s *SQL) Find(table string, filter ztype.Map, fn ...func(*CondOptions)) (zt
 	return items, nil
 }
 
+// findWithLock 在生成的查询语句末尾追加行锁子句
+func (s *SQL) findWithLock(table, lock string, query func(b *builder.SelectBuilder) error) (ztype.Maps, error) {
+	b := builder.Query(table)
+	b.SetDriver(s.db.GetDriver())
+	if err := query(b); err != nil {
+		return nil, err
+	}
+
+	sql, values, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.QueryToMaps(sql+" "+lock, values...)
+}
+
 func (s *SQL) Pages(table string, page, pagesize int, filter ztype.Map, fn ...func(*CondOptions)) (ztype.Maps, PageInfo, error) {
 	o := acquireCondOptions()
 	defer releaseCondOptions(o)
@@ -292,6 +420,13 @@ func (s *SQL) Pages(table string, page, pagesize int, filter ztype.Map, fn ...fu
 		}
 	}
 
+	if o.Lock != LockNone {
+		if !s.inTx {
+			return nil, PageInfo{}, ErrLockOutsideTransaction
+		}
+		return nil, PageInfo{}, ErrLockNotSupported
+	}
+
 	rows, p, err := s.db.Pages(table, page, pagesize, func(b *builder.SelectBuilder) error {
 		var fieldPrefix string
 		hasJoin := len(o.Join) > 0
@@ -2,6 +2,8 @@ package model
 
 import (
 	"errors"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/sohaha/zlsgo/zarray"
@@ -12,12 +14,51 @@ import (
 	mSchema "github.com/zlsgo/app_module/model/schema"
 	"github.com/zlsgo/zdb"
 	"github.com/zlsgo/zdb/builder"
+	"github.com/zlsgo/zdb/driver"
 	"github.com/zlsgo/zdb/schema"
 )
 
 type Migration struct {
 	Model *Schema
 	DB    *zdb.DB
+	// plan 不为 nil 时只记录语句不执行
+	plan *[]MigrationStep
+}
+
+var _ MigrationPlanner = (*Migration)(nil)
+
+// Plan 按自动迁移的流程生成语句但不执行，不触发迁移钩子也不写入初始数据
+func (m *Migration) Plan(oldColumn ...DealOldColumn) ([]MigrationStep, error) {
+	if m.Model.GetTableName() == "" {
+		return nil, errors.New("表名不能为空")
+	}
+
+	steps := make([]MigrationStep, 0)
+	dry := &Migration{Model: m.Model, DB: m.DB, plan: &steps}
+
+	var err error
+	if dry.HasTable() {
+		err = dry.UpdateTable(dry.DB, oldColumn...)
+	} else {
+		err = dry.CreateTable(dry.DB)
+	}
+	if err == nil {
+		err = dry.Indexs(dry.DB)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// exec 执行迁移语句，预演时仅记录
+func (m *Migration) exec(db *zdb.DB, sql string, values ...interface{}) error {
+	if m.plan != nil {
+		*m.plan = append(*m.plan, MigrationStep{SQL: sql, Values: values})
+		return nil
+	}
+	_, err := db.Exec(sql, values...)
+	return err
 }
 
 func (m *Migration) Auto(oldColumn ...DealOldColumn) (err error) {
@@ -59,6 +100,12 @@ func (m *Migration) Auto(oldColumn ...DealOldColumn) (err error) {
 }
 
 func (m *Migration) InitValue(first bool) error {
+	if m.Model.Tenancy() != mSchema.TenancyNone {
+		if _, ok := m.Model.Tenant(); !ok {
+			return nil
+		}
+	}
+
 	if !first {
 		row, err := FindOne[ztype.Map](m.Model.Model(), Filter{}, func(o *CondOptions) {
 			o.Fields = []string{"COUNT(*) AS count"}
@@ -110,7 +157,9 @@ func (m *Migration) GetFields() (ztype.Map, error) {
 func (m *Migration) UpdateTable(db *zdb.DB, oldColumn ...DealOldColumn) error {
 	modelFields := m.Model.GetDefineFields()
 	newColumns := zarray.Keys(modelFields)
-	newColumns = append(newColumns, idKey)
+	if !m.Model.IsCompositeKey() {
+		newColumns = append(newColumns, idKey)
+	}
 
 	currentColumns, err := m.GetFields()
 	if err != nil {
@@ -124,6 +173,10 @@ func (m *Migration) UpdateTable(db *zdb.DB, oldColumn ...DealOldColumn) error {
 			newColumns = append(newColumns, DeletedAtKey)
 		}
 
+		if m.Model.Tenancy() == mSchema.TenancyColumn {
+			newColumns = append(newColumns, TenantIDKey)
+		}
+
 		// if m.Model.models.Options.CreatedBy {
 		// 	newColumns = append(newColumns, CreatedByKey)
 		// }
@@ -180,8 +233,7 @@ func (m *Migration) UpdateTable(db *zdb.DB, oldColumn ...DealOldColumn) error {
 			sql, values = table.RenameColumn(v, deleteFieldPrefix+v)
 		}
 
-		_, err := db.Exec(sql, values...)
-		if err != nil {
+		if err := m.exec(db, sql, values...); err != nil {
 			return err
 		}
 	}
@@ -203,8 +255,7 @@ func (m *Migration) UpdateTable(db *zdb.DB, oldColumn ...DealOldColumn) error {
 					f.NotNull = false
 				})
 			}
-			_, err := db.Exec(sql, values...)
-			if err != nil {
+			if err := m.exec(db, sql, values...); err != nil {
 				return err
 			}
 		}
@@ -224,13 +275,23 @@ func (m *Migration) UpdateTable(db *zdb.DB, oldColumn ...DealOldColumn) error {
 	// 	}
 	// }
 
+	if m.Model.Tenancy() == mSchema.TenancyColumn && !zarray.Contains(oldColumns, TenantIDKey) {
+		sql, values := table.AddColumn(TenantIDKey, schema.String, func(f *schema.Field) {
+			f.Comment = "租户"
+			f.NotNull = false
+			f.Size = 64
+		})
+		if err := m.exec(db, sql, values...); err != nil {
+			return err
+		}
+	}
+
 	if *m.Model.define.Options.Timestamps {
 		if !zarray.Contains(oldColumns, CreatedAtKey) {
 			sql, values := table.AddColumn(CreatedAtKey, schema.Time, func(f *schema.Field) {
 				f.Comment = "更新时间"
 			})
-			_, err := db.Exec(sql, values...)
-			if err != nil {
+			if err := m.exec(db, sql, values...); err != nil {
 				return err
 			}
 		}
@@ -238,8 +299,7 @@ func (m *Migration) UpdateTable(db *zdb.DB, oldColumn ...DealOldColumn) error {
 			sql, values := table.AddColumn(UpdatedAtKey, schema.Time, func(f *schema.Field) {
 				f.Comment = "更新时间"
 			})
-			_, err := db.Exec(sql, values...)
-			if err != nil {
+			if err := m.exec(db, sql, values...); err != nil {
 				return err
 			}
 		}
@@ -277,7 +337,176 @@ func (m *Migration) UpdateTable(db *zdb.DB, oldColumn ...DealOldColumn) error {
 	// 	zlog.Warn("暂不支持修改字段类型：", updateColumns)
 	// }
 
-	return nil
+	return m.syncEnumChecks(db, modelFields, oldColumns)
+}
+
+// syncEnumChecks 已有列的枚举值或 EnumCheck 变化时删除过期约束并重建，新增列的约束在加列时生成
+func (m *Migration) syncEnumChecks(db *zdb.DB, modelFields mSchema.Fields, columns []string) error {
+	tableName := m.Model.GetTableName()
+	type change struct {
+		prefix, name, constraint string
+	}
+	changes := make([]change, 0)
+	names := zarray.Keys(modelFields)
+	sort.Strings(names)
+	for _, name := range names {
+		if !zarray.Contains(columns, name) || isDisableMigratioField(m.Model, name) {
+			continue
+		}
+		f := modelFields[name]
+		changes = append(changes, change{
+			prefix:     enumCheckPrefix(tableName, name),
+			name:       enumCheckName(tableName, name, &f),
+			constraint: enumCheckConstraint(tableName, name, &f),
+		})
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	existing, createSQL, err := m.checkConstraints(db)
+	if err != nil {
+		return err
+	}
+
+	var drops, adds []string
+	for _, c := range changes {
+		found := false
+		for _, e := range existing {
+			if len(e) != len(c.prefix)+enumCheckHashLen || !strings.HasPrefix(strings.ToLower(e), strings.ToLower(c.prefix)) {
+				continue
+			}
+			if strings.EqualFold(e, c.name) {
+				found = true
+				continue
+			}
+			drops = append(drops, e)
+		}
+		if !found && c.constraint != "" {
+			adds = append(adds, c.constraint)
+		}
+	}
+	if len(drops) == 0 && len(adds) == 0 {
+		return nil
+	}
+
+	switch db.GetDriver().Value() {
+	case driver.SQLite:
+		// SQLite 不支持修改约束，按原建表语句替换约束后重建表，索引由后续的 Indexs 重新创建
+		for _, name := range drops {
+			createSQL = withoutConstraint(createSQL, name)
+		}
+		for _, constraint := range adds {
+			createSQL = withTableConstraint(createSQL, constraint)
+		}
+		tmp := tableName + "__rebuild"
+		for _, sql := range []string{
+			"ALTER TABLE " + tableName + " RENAME TO " + tmp,
+			createSQL,
+			"INSERT INTO " + tableName + " SELECT * FROM " + tmp,
+			"DROP TABLE " + tmp,
+		} {
+			if err = m.exec(db, sql); err != nil {
+				return err
+			}
+		}
+		return nil
+	case driver.PostgreSQL, driver.MySQL:
+		drop := "ALTER TABLE " + tableName + " DROP CONSTRAINT "
+		if db.GetDriver().Value() == driver.MySQL {
+			drop = "ALTER TABLE " + tableName + " DROP CHECK "
+		}
+		for _, name := range drops {
+			if err = m.exec(db, drop+name); err != nil {
+				return err
+			}
+		}
+		for _, constraint := range adds {
+			if err = m.exec(db, "ALTER TABLE "+tableName+" ADD "+constraint); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		modelLogger.Warnf("table %s: enum check constraints can not be changed on this driver\n", tableName)
+		return nil
+	}
+}
+
+// checkConstraints 表中已有的具名 CHECK 约束，SQLite 同时返回建表语句
+func (m *Migration) checkConstraints(db *zdb.DB) (names []string, createSQL string, err error) {
+	table := m.Model.GetTableName()
+	var sql string
+	switch db.GetDriver().Value() {
+	case driver.SQLite:
+		rows, err := db.QueryToMaps("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", table)
+		if err != nil || len(rows) == 0 {
+			return nil, "", err
+		}
+		createSQL = rows[0].Get("sql").String()
+		for _, match := range constraintNamePattern.FindAllStringSubmatch(createSQL, -1) {
+			names = append(names, match[1])
+		}
+		return names, createSQL, nil
+	case driver.PostgreSQL:
+		sql = "SELECT constraint_name AS name FROM information_schema.table_constraints WHERE table_schema = current_schema() AND table_name = ? AND constraint_type = 'CHECK'"
+	case driver.MySQL:
+		sql = "SELECT constraint_name AS name FROM information_schema.table_constraints WHERE table_schema = DATABASE() AND table_name = ? AND constraint_type = 'CHECK'"
+	default:
+		return nil, "", nil
+	}
+	rows, err := db.QueryToMaps(sql, table)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, row := range rows {
+		names = append(names, row.Get("name").String())
+	}
+	return names, "", nil
+}
+
+var constraintNamePattern = regexp.MustCompile(`CONSTRAINT\s+([A-Za-z0-9_]+)\s+CHECK`)
+
+// withoutConstraint 从建表语句中移除指定名称的 CHECK 约束
+func withoutConstraint(sql, name string) string {
+	start := strings.Index(sql, "CONSTRAINT "+name+" ")
+	if start < 0 {
+		return sql
+	}
+	depth, quote := 0, byte(0)
+	for i := start; i < len(sql); i++ {
+		c := sql[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				head := strings.TrimSuffix(strings.TrimRight(sql[:start], " \t\n"), ",")
+				return head + sql[i+1:]
+			}
+		}
+	}
+	return sql
+}
+
+// columnType 字段对应的列类型，可逆加密字段保存密文需使用文本列
+func columnType(field *mSchema.Field) (schema.DataType, uint64) {
+	if isFieldCrypt(field) {
+		return schema.Text, 0
+	}
+	if field.Type == mSchema.Decimal {
+		return decimalColumnType(field), 0
+	}
+	return field.Type, field.Size
 }
 
 func (m *Migration) execAddColumn(
@@ -293,11 +522,15 @@ func (m *Migration) execAddColumn(
 		return nil
 	}
 
-	sql, values := table.AddColumn(v, field.Type, func(f *schema.Field) {
+	dataType, size := columnType(&field)
+	sql, values := table.AddColumn(v, dataType, func(f *schema.Field) {
 		f.Comment = ztype.ToString(zutil.IfVal(field.Comment != "", field.Comment, field.Label))
 		f.NotNull = !field.Nullable
-		f.Size = field.Size
+		f.Size = size
 	})
+	if check := enumCheckConstraint(m.Model.GetTableName(), v, &field); check != "" {
+		sql += " " + check
+	}
 
 	if !deleteColumn {
 		recovery := deleteFieldPrefix + v
@@ -309,8 +542,7 @@ func (m *Migration) execAddColumn(
 		}
 	}
 
-	_, err := db.Exec(sql, values...)
-	if err != nil {
+	if err := m.exec(db, sql, values...); err != nil {
 		return err
 	}
 	return nil
@@ -342,6 +574,13 @@ func (m *Migration) fillField(fields []*schema.Field) []*schema.Field {
 		}))
 	}
 
+	if m.Model.Tenancy() == mSchema.TenancyColumn {
+		fields = append(fields, schema.NewField(TenantIDKey, schema.String, func(f *schema.Field) {
+			f.Comment = "租户"
+			f.Size = 64
+		}))
+	}
+
 	// if m.Model.models.Options.CreatedBy {
 	// 	fields = append(fields, schema.NewField(CreatedByKey, schema.String, func(f *schema.Field) {
 	// 		f.Comment = "创建人 ID"
@@ -358,17 +597,20 @@ func (m *Migration) CreateTable(db *zdb.DB) error {
 	table.SetDriver(db.GetDriver())
 	modelFields := m.Model.GetDefineFields()
 	fields := make([]*schema.Field, 0, len(modelFields))
-	fields = append(fields, m.getPrimaryKey())
+	if !m.Model.IsCompositeKey() {
+		fields = append(fields, m.getPrimaryKey())
+	}
 	for name := range modelFields {
 		if isDisableMigratioField(m.Model, name) {
 			continue
 		}
 
 		field := modelFields[name]
-		f := schema.NewField(name, field.Type, func(f *schema.Field) {
+		dataType, size := columnType(&field)
+		f := schema.NewField(name, dataType, func(f *schema.Field) {
 			f.Comment = ztype.ToString(zutil.IfVal(field.Comment != "", field.Comment, field.Label))
 			f.NotNull = !field.Nullable
-			f.Size = field.Size
+			f.Size = size
 		})
 		fields = append(fields, f)
 	}
@@ -398,13 +640,56 @@ func (m *Migration) CreateTable(db *zdb.DB) error {
 	if err != nil {
 		return err
 	}
+	if m.Model.IsCompositeKey() {
+		sql = withPrimaryKeyConstraint(sql, m.Model.PrimaryKeys())
+	}
+	for _, check := range enumCheckConstraints(m.Model, modelFields) {
+		sql = withTableConstraint(sql, check)
+	}
 
-	_, err = db.Exec(sql, values...)
+	return m.exec(db, sql, values...)
+}
 
-	return err
+// withPrimaryKeyConstraint 在建表语句的列定义末尾追加复合主键约束
+func withPrimaryKeyConstraint(sql string, keys []string) string {
+	return withTableConstraint(sql, "PRIMARY KEY ("+strings.Join(keys, ", ")+")")
+}
+
+// withTableConstraint 在建表语句的列定义末尾追加表约束
+func withTableConstraint(sql, constraint string) string {
+	depth, quote := 0, byte(0)
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return sql[:i] + ", " + constraint + sql[i:]
+			}
+		}
+	}
+	return sql
 }
 
 func (m *Migration) getPrimaryKey() *schema.Field {
+	if m.Model.PrimaryKeyType() != mSchema.PrimaryKeyAutoInc {
+		pk := m.Model.primaryKeyField()
+		return schema.NewField(idKey, pk.Type, func(f *schema.Field) {
+			f.Comment = "ID"
+			f.PrimaryKey = true
+			f.Size = pk.Size
+		})
+	}
 	return schema.NewField(idKey, schema.Uint, func(f *schema.Field) {
 		f.Comment = "ID"
 		f.PrimaryKey = true
@@ -442,6 +727,10 @@ func (m *Migration) Indexs(db *zdb.DB) error {
 		indexs[DeletedAtKey] = []string{DeletedAtKey}
 	}
 
+	if m.Model.Tenancy() == mSchema.TenancyColumn {
+		indexs[TenantIDKey] = []string{TenantIDKey}
+	}
+
 	for name, v := range uniques {
 		name = m.Model.GetTableName() + "__u__" + name
 		sql, values, process := table.HasIndex(name)
@@ -449,8 +738,7 @@ func (m *Migration) Indexs(db *zdb.DB) error {
 
 		if err == nil && !process(res) {
 			sql, values := table.CreateIndex(name, v, "UNIQUE")
-			_, err = db.Exec(sql, values...)
-			if err != nil {
+			if err = m.exec(db, sql, values...); err != nil {
 				return err
 			}
 		}
@@ -462,13 +750,15 @@ func (m *Migration) Indexs(db *zdb.DB) error {
 		res, err := db.QueryToMaps(sql, values...)
 		if err == nil && !process(res) {
 			sql, values := table.CreateIndex(name, v, "")
-			_, err = db.Exec(sql, values...)
-			if err != nil {
+			if err = m.exec(db, sql, values...); err != nil {
 				return err
 			}
 		}
 	}
 
+	if m.plan != nil {
+		return nil
+	}
 	if err := m.Model.hook(hook.EventMigrationIndexDone, m, db, table); err != nil {
 		return err
 	}
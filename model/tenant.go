@@ -0,0 +1,197 @@
+package model
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sohaha/zlsgo/zerror"
+	"github.com/sohaha/zlsgo/znet"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+// TenantIDKey 租户字段名
+const TenantIDKey = "tenant_id"
+
+type tenantCtxKey struct{}
+
+// WithTenant 在上下文中设置当前租户
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, tenantCtxKey{}, tenant)
+}
+
+// TenantFromContext 从上下文中获取当前租户
+func TenantFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	tenant, ok := ctx.Value(tenantCtxKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// Context 返回模型绑定的上下文
+func (m *Schema) Context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
+
+// WithContext 返回绑定上下文的模型副本，关联模型同样继承该上下文
+func (m *Schema) WithContext(ctx context.Context) *Schema {
+	return cloneSchemaWithContext(m, ctx)
+}
+
+// WithContext 返回绑定上下文的存储实例
+func (o *Store) WithContext(ctx context.Context) *Store {
+	return o.schema.WithContext(ctx).Model()
+}
+
+// Tenancy 返回模型的多租户模式
+func (m *Schema) Tenancy() schema.TenancyMode {
+	switch mode := m.define.Options.Tenancy; mode {
+	case schema.TenancyColumn, schema.TenancyPrefix:
+		return mode
+	default:
+		return schema.TenancyNone
+	}
+}
+
+// Tenant 返回当前上下文中的租户
+func (m *Schema) Tenant() (string, bool) {
+	return TenantFromContext(m.ctx)
+}
+
+// requireTenant 多租户模型必须携带租户才能操作
+func (m *Schema) requireTenant() (string, error) {
+	if m.Tenancy() == schema.TenancyNone {
+		return "", nil
+	}
+	tenant, ok := m.Tenant()
+	if !ok {
+		return "", NewModelError("tenant", m.define.Table.Name, ErrTenantRequired)
+	}
+	if m.Tenancy() == schema.TenancyPrefix && !isValidTenant(tenant) {
+		return "", NewModelError("tenant", m.define.Table.Name, ErrInvalidTenant)
+	}
+	return tenant, nil
+}
+
+// tenantFilter 为条件追加租户约束
+func (m *Schema) tenantFilter(filter ztype.Map) error {
+	tenant, err := m.requireTenant()
+	if err != nil {
+		return err
+	}
+	if m.Tenancy() == schema.TenancyColumn {
+		filter[TenantIDKey] = tenant
+	}
+	return nil
+}
+
+// getTablePrefix 返回表前缀，前缀模式下包含租户
+func (m *Schema) getTablePrefix() string {
+	if m.Tenancy() != schema.TenancyPrefix {
+		return m.tablePrefix
+	}
+	tenant, ok := m.Tenant()
+	if !ok || !isValidTenant(tenant) {
+		return m.tablePrefix
+	}
+	return m.tablePrefix + tenant + "_"
+}
+
+func isValidTenant(tenant string) bool {
+	if tenant == "" || len(tenant) > 64 {
+		return false
+	}
+	for i := 0; i < len(tenant); i++ {
+		c := tenant[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// MigrateTenant 为指定租户执行多租户模型的迁移与初始化数据
+func (ss *Schemas) MigrateTenant(tenant string) error {
+	ctx := WithTenant(context.Background(), tenant)
+	var err error
+	ss.ForEach(func(key string, m *Schema) bool {
+		if m.Tenancy() == schema.TenancyNone || *m.define.Options.DisabledMigrator || m.Storage == nil {
+			return true
+		}
+		tm := m.WithContext(ctx)
+		if _, err = tm.requireTenant(); err != nil {
+			return false
+		}
+		err = tm.Migration().Auto(ss.SchemaOption.OldColumn)
+		return err == nil
+	})
+	return err
+}
+
+// TenantFromHeader 从请求头解析租户，请求头可由客户端任意指定，
+// 仅适用于由可信网关设置该请求头的部署，否则应配合 BindAuthorizedTenant 校验用户归属
+func TenantFromHeader(name string) func(c *znet.Context) (string, error) {
+	return func(c *znet.Context) (string, error) {
+		return c.GetHeader(name), nil
+	}
+}
+
+// BindTenant 从请求中解析租户并写入请求上下文
+func BindTenant(c *znet.Context, resolve func(c *znet.Context) (string, error)) error {
+	if resolve == nil {
+		return nil
+	}
+	tenant, err := resolve(c)
+	if err != nil {
+		return zerror.WrapTag(zerror.InvalidInput)(err)
+	}
+	if tenant != "" {
+		c.Request = c.Request.WithContext(WithTenant(c.Request.Context(), tenant))
+	}
+	return nil
+}
+
+// BindAuthorizedTenant 在认证后解析租户，并由 access 校验当前用户是否可访问该租户
+func BindAuthorizedTenant(
+	c *znet.Context,
+	resolve func(c *znet.Context) (string, error),
+	access func(c *znet.Context, tenant string) error,
+) error {
+	if resolve == nil {
+		return nil
+	}
+	tenant, err := resolve(c)
+	if err != nil {
+		return zerror.WrapTag(zerror.InvalidInput)(err)
+	}
+	if tenant == "" {
+		return nil
+	}
+	if access == nil {
+		return errors.New("tenant access check is not configured")
+	}
+	if err = access(c, tenant); err != nil {
+		return zerror.WrapTag(zerror.PermissionDenied)(err)
+	}
+	c.Request = c.Request.WithContext(WithTenant(c.Request.Context(), tenant))
+	return nil
+}
+
+// TenantMiddleware 租户解析中间件，不校验请求者与租户的归属关系，仅适用于可信来源的租户标识
+func TenantMiddleware(resolve func(c *znet.Context) (string, error)) func(c *znet.Context) error {
+	return func(c *znet.Context) error {
+		if err := BindTenant(c, resolve); err != nil {
+			return err
+		}
+		c.Next()
+		return nil
+	}
+}
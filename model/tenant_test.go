@@ -0,0 +1,168 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/sohaha/zlsgo/znet"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+	"github.com/zlsgo/zdb"
+	"github.com/zlsgo/zdb/driver/sqlite3"
+)
+
+func TestTenancyColumn(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	s := schema.Schema{
+		Name:  "tenant_posts",
+		Table: schema.Table{Name: "tenant_posts"},
+		Options: schema.Options{
+			Tenancy: schema.TenancyColumn,
+		},
+		Fields: map[string]schema.Field{
+			"title": {Type: "string", Label: "Title"},
+		},
+	}
+	_, schemas := newTestSchemas(t, s)
+	m := schemas.MustGet("tenant_posts")
+	tt.Equal(true, zarray.Contains(m.GetFields(), TenantIDKey))
+
+	_, err := m.Model().Insert(ztype.Map{"title": "none"})
+	tt.Equal(true, errors.Is(err, ErrTenantRequired))
+	_, err = m.Model().Find(Filter{})
+	tt.Equal(true, errors.Is(err, ErrTenantRequired))
+
+	a := m.Model().WithContext(WithTenant(context.Background(), "a"))
+	b := m.Model().WithContext(WithTenant(context.Background(), "b"))
+
+	_, err = a.Insert(ztype.Map{"title": "a1", TenantIDKey: "b"})
+	tt.NoError(err)
+	_, err = a.Insert(ztype.Map{"title": "a2"})
+	tt.NoError(err)
+	_, err = b.Insert(ztype.Map{"title": "b1"})
+	tt.NoError(err)
+
+	rows, err := a.Find(Filter{})
+	tt.NoError(err)
+	tt.Equal(2, len(rows))
+	tt.Equal("a", rows[0].Get(TenantIDKey).String())
+
+	rows, err = b.Find(Filter{TenantIDKey: "a"})
+	tt.NoError(err)
+	tt.Equal(1, len(rows))
+	tt.Equal("b1", rows[0].Get("title").String())
+
+	total, err := b.UpdateMany(Filter{}, ztype.Map{"title": "b2", TenantIDKey: "a"})
+	tt.NoError(err)
+	tt.Equal(int64(1), total)
+
+	total, err = b.DeleteMany(Filter{"title": "a1"})
+	tt.NoError(err)
+	tt.Equal(int64(0), total)
+
+	count, err := a.Count(Filter{})
+	tt.NoError(err)
+	tt.Equal(uint64(2), count)
+
+	repo := m.Model().Repository().WithContext(WithTenant(context.Background(), "b"))
+	row, err := repo.Query().FindOne()
+	tt.NoError(err)
+	tt.Equal("b2", row.Get("title").String())
+	tt.Equal("b", row.Get(TenantIDKey).String())
+}
+
+func TestTenancyPrefix(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	db, err := zdb.New(&sqlite3.Config{
+		File:       ":memory:",
+		Memory:     true,
+		Parameters: "_pragma=busy_timeout(3000)",
+	})
+	tt.NoError(err)
+
+	schemas := NewSchemas(nil, NewSQL(db, ""), SchemaOptions{Tenancy: schema.TenancyPrefix})
+	m, err := schemas.Reg("tenant_items", schema.Schema{
+		Name:  "tenant_items",
+		Table: schema.Table{Name: "tenant_items"},
+		Fields: map[string]schema.Field{
+			"title": {Type: "string", Label: "Title"},
+		},
+	}, false)
+	tt.NoError(err)
+	tt.Equal(schema.TenancyPrefix, m.Tenancy())
+	tt.Equal(false, zarray.Contains(m.GetFields(), TenantIDKey))
+
+	tt.NoError(schemas.MigrateTenant("t1"))
+	tt.NoError(schemas.MigrateTenant("t2"))
+	tt.Equal(true, errors.Is(schemas.MigrateTenant("t-1;"), ErrInvalidTenant))
+
+	t1 := m.Model().WithContext(WithTenant(context.Background(), "t1"))
+	t2 := m.Model().WithContext(WithTenant(context.Background(), "t2"))
+	tt.Equal("t1_tenant_items", t1.Schema().GetTableName())
+
+	_, err = t1.Insert(ztype.Map{"title": "one"})
+	tt.NoError(err)
+
+	count, err := t1.Count(Filter{})
+	tt.NoError(err)
+	tt.Equal(uint64(1), count)
+
+	count, err = t2.Count(Filter{})
+	tt.NoError(err)
+	tt.Equal(uint64(0), count)
+
+	_, err = m.Model().Count(Filter{})
+	tt.Equal(true, errors.Is(err, ErrTenantRequired))
+}
+
+func TestBindAuthorizedTenant(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	access := func(c *znet.Context, tenant string) error {
+		if tenant != "acme" {
+			return errors.New("tenant not allowed")
+		}
+		return nil
+	}
+	r := znet.New()
+	r.SetMode(znet.ProdMode)
+	r.GET("/", func(c *znet.Context) {
+		if err := BindAuthorizedTenant(c, TenantFromHeader("X-Tenant-ID"), access); err != nil {
+			c.String(403, err.Error())
+			return
+		}
+		tenant, _ := TenantFromContext(c.Request.Context())
+		c.String(200, tenant)
+	})
+
+	request := func(tenant string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Tenant-ID", tenant)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("acme")
+	tt.Equal(200, w.Code)
+	tt.Equal("acme", w.Body.String())
+
+	// 用户无权访问的租户被拒绝
+	w = request("other")
+	tt.Equal(403, w.Code)
+
+	w = request("")
+	tt.Equal(200, w.Code)
+	tt.Equal("", w.Body.String())
+
+	// 未配置校验时不接受客户端指定的租户
+	tt.EqualTrue(BindAuthorizedTenant(&znet.Context{Request: httptest.NewRequest("GET", "/", nil)}, func(c *znet.Context) (string, error) {
+		return "acme", nil
+	}, nil) != nil)
+}
@@ -0,0 +1,253 @@
+package model
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sohaha/zlsgo/ztime"
+	"github.com/sohaha/zlsgo/ztype"
+	mSchema "github.com/zlsgo/app_module/model/schema"
+)
+
+// TimeFormatRFC3339 以 RFC3339 格式输出时间，适用于对外 API
+const TimeFormatRFC3339 = "rfc3339"
+
+const defaultTimeFormat = "Y-m-d H:i:s"
+
+type timeZoneCtxKey struct{}
+
+var (
+	timeZones = struct {
+		storage *time.Location
+		display *time.Location
+		format  string
+		mu      sync.RWMutex
+	}{format: defaultTimeFormat}
+
+	// zonedTimeLayouts 带时区信息的时间格式，按原时区解析
+	zonedTimeLayouts = []string{
+		time.RFC3339Nano,
+		"2006-01-02 15:04:05.999999999-07:00",
+		"2006-01-02 15:04:05.999999999 -0700 MST",
+	}
+)
+
+// SetStorageTimeZone 设置写入数据库的时区，推荐使用 time.UTC，nil 表示保持时间值自身的时区
+func SetStorageTimeZone(loc *time.Location) {
+	timeZones.mu.Lock()
+	timeZones.storage = loc
+	timeZones.mu.Unlock()
+}
+
+// StorageTimeZone 返回写入数据库的时区
+func StorageTimeZone() *time.Location {
+	timeZones.mu.RLock()
+	defer timeZones.mu.RUnlock()
+	return timeZones.storage
+}
+
+// SetDisplayTimeZone 设置查询结果默认的展示时区，上下文中的时区优先
+func SetDisplayTimeZone(loc *time.Location) {
+	timeZones.mu.Lock()
+	timeZones.display = loc
+	timeZones.mu.Unlock()
+}
+
+// DisplayTimeZone 返回默认的展示时区
+func DisplayTimeZone() *time.Location {
+	timeZones.mu.RLock()
+	defer timeZones.mu.RUnlock()
+	return timeZones.display
+}
+
+// SetTimeFormat 设置默认的时间格式，用于 DataTime 序列化与未声明格式的时间字段
+func SetTimeFormat(format string) {
+	if format == "" {
+		format = defaultTimeFormat
+	}
+	timeZones.mu.Lock()
+	timeZones.format = format
+	timeZones.mu.Unlock()
+}
+
+// TimeFormat 返回默认的时间格式
+func TimeFormat() string {
+	timeZones.mu.RLock()
+	defer timeZones.mu.RUnlock()
+	return timeZones.format
+}
+
+// WithTimeZone 在上下文中设置调用方时区，用于解析写入的时间与格式化查询结果
+func WithTimeZone(ctx context.Context, loc *time.Location) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, timeZoneCtxKey{}, loc)
+}
+
+// TimeZoneFromContext 从上下文中获取调用方时区
+func TimeZoneFromContext(ctx context.Context) (*time.Location, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	loc, ok := ctx.Value(timeZoneCtxKey{}).(*time.Location)
+	return loc, ok && loc != nil
+}
+
+// displayZone 当前调用方的展示时区
+func (m *Schema) displayZone() *time.Location {
+	if loc, ok := TimeZoneFromContext(m.ctx); ok {
+		return loc
+	}
+	return DisplayTimeZone()
+}
+
+// timeFormat 时间字段的输出格式，字段声明优先于模型声明
+func (m *Schema) timeFormat(f *mSchema.Field) string {
+	if f != nil && f.Options.FormatTime != "" {
+		return f.Options.FormatTime
+	}
+	if m.define.Options.TimeFormat != "" {
+		return m.define.Options.TimeFormat
+	}
+	return TimeFormat()
+}
+
+// formatTimeFields 将查询结果中的时间字段转换为展示时区并格式化
+func (m *Schema) formatTimeFields(row ztype.Map) error {
+	if len(m.timeFields) == 0 {
+		return nil
+	}
+	storage, loc := StorageTimeZone(), m.displayZone()
+	for name, format := range m.timeFields {
+		v, ok := row[name]
+		if !ok {
+			continue
+		}
+		if v == nil || ztype.ToString(v) == "" {
+			row[name] = ""
+			continue
+		}
+		t, err := parseTime(v, "", storage)
+		if err != nil {
+			return err
+		}
+		row[name] = formatTime(t, format, loc)
+	}
+	return nil
+}
+
+// timeFilter 将时间字段的过滤值由调用方时区转换为存储时区，未设置存储时区时不处理
+func (m *Schema) timeFilter(filter ztype.Map) {
+	storage := StorageTimeZone()
+	if storage == nil || len(m.timeFields) == 0 {
+		return
+	}
+	loc := m.displayZone()
+	convert := func(v any) any {
+		t, err := parseTime(v, "", loc)
+		if err != nil || t.IsZero() {
+			return v
+		}
+		return t.In(storage).Format(time.DateTime)
+	}
+
+	for key, v := range filter {
+		name := strings.TrimSpace(key)
+		if i := strings.IndexByte(name, ' '); i > 0 {
+			name = name[:i]
+		}
+		if _, ok := m.timeFields[name]; !ok {
+			continue
+		}
+		switch vals := v.(type) {
+		case []string:
+			nvals := make([]any, len(vals))
+			for i := range vals {
+				nvals[i] = convert(vals[i])
+			}
+			filter[key] = nvals
+		case []any:
+			nvals := make([]any, len(vals))
+			for i := range vals {
+				nvals[i] = convert(vals[i])
+			}
+			filter[key] = nvals
+		default:
+			filter[key] = convert(v)
+		}
+	}
+}
+
+// storageTime 将时间转换为存储时区
+func storageTime(t time.Time) time.Time {
+	if loc := StorageTimeZone(); loc != nil {
+		return t.In(loc)
+	}
+	return t
+}
+
+// storageNow 以存储时区格式化的当前时间
+func storageNow() string {
+	loc := StorageTimeZone()
+	if loc == nil {
+		return ztime.Now()
+	}
+	return time.Now().In(loc).Format(time.DateTime)
+}
+
+// formatTime 按格式输出时间，loc 为 nil 时保持时间值自身的时区
+func formatTime(t time.Time, format string, loc *time.Location) string {
+	if loc != nil {
+		t = t.In(loc)
+	}
+	if strings.EqualFold(format, TimeFormatRFC3339) {
+		return t.Format(time.RFC3339)
+	}
+	return ztime.FormatTime(t, format)
+}
+
+// parseTime 解析时间值，不含时区信息的字符串按 loc 解释
+func parseTime(v any, format string, loc *time.Location) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case DataTime:
+		return t.Time, nil
+	case *DataTime:
+		return t.Time, nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return ztime.Unix(ztype.ToInt64(v)), nil
+	}
+
+	s := strings.TrimSpace(ztype.ToString(v))
+	for _, layout := range zonedTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	if format != "" && !strings.EqualFold(format, TimeFormatRFC3339) {
+		if t, err := ztime.Parse(s, format); err == nil {
+			return inLocation(t, loc), nil
+		}
+	}
+	if ts, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ztime.Unix(ts), nil
+	}
+	t, err := ztime.Parse(s)
+	if err != nil {
+		return t, err
+	}
+	return inLocation(t, loc), nil
+}
+
+// inLocation 保持时间的字面值，将其解释为 loc 时区的时间
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
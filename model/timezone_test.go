@@ -0,0 +1,83 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func TestTimeFormat(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	cst := time.FixedZone("CST", 8*3600)
+	at := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	tt.Equal("2025-01-02 08:00:00", formatTime(at, "Y-m-d H:i:s", cst))
+	tt.Equal("2025-01-02T08:00:00+08:00", formatTime(at, TimeFormatRFC3339, cst))
+
+	parsed, err := parseTime("2025-01-02 08:00:00", "", cst)
+	tt.NoError(err)
+	tt.Equal(true, parsed.Equal(at))
+	parsed, err = parseTime("2025-01-02T00:00:00Z", "", cst)
+	tt.NoError(err)
+	tt.Equal(true, parsed.Equal(at))
+
+	SetTimeFormat(TimeFormatRFC3339)
+	defer SetTimeFormat("")
+	b, err := DataTime{Time: at}.MarshalJSON()
+	tt.NoError(err)
+	tt.Equal(`"2025-01-02T00:00:00Z"`, string(b))
+
+	var dt DataTime
+	tt.NoError(dt.UnmarshalJSON([]byte(`"2025-01-02T08:00:00+08:00"`)))
+	tt.Equal(true, dt.Time.Equal(at))
+}
+
+func TestTimeZone(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	SetStorageTimeZone(time.UTC)
+	defer SetStorageTimeZone(nil)
+
+	timestamps := true
+	s := schema.Schema{
+		Name:    "tz_events",
+		Table:   schema.Table{Name: "tz_events"},
+		Options: schema.Options{Timestamps: &timestamps, TimeFormat: TimeFormatRFC3339},
+		Fields: map[string]schema.Field{
+			"start_at": {Type: schema.Time, Label: "Start", Options: schema.FieldOption{FormatTime: "Y/m/d H:i"}},
+		},
+	}
+	_, schemas := newTestSchemas(t, s)
+	store := schemas.MustGet("tz_events").Model()
+	cst := time.FixedZone("CST", 8*3600)
+	local := store.WithContext(WithTimeZone(context.Background(), cst))
+
+	id, err := local.Insert(ztype.Map{"start_at": "2025/01/02 08:00"})
+	tt.NoError(err)
+
+	row, err := store.FindOneByID(id)
+	tt.NoError(err)
+	tt.Equal("2025/01/02 00:00", row.Get("start_at").String())
+
+	row, err = local.FindOneByID(id)
+	tt.NoError(err)
+	tt.Equal("2025/01/02 08:00", row.Get("start_at").String())
+	created, err := time.Parse(time.RFC3339, row.Get(CreatedAtKey).String())
+	tt.NoError(err)
+	_, offset := created.Zone()
+	tt.Equal(8*3600, offset)
+
+	rows, err := local.Find(Filter{"start_at >=": "2025-01-02 07:00:00"})
+	tt.NoError(err)
+	tt.Equal(1, len(rows))
+	rows, err = local.Find(Filter{"start_at >=": "2025-01-02 09:00:00"})
+	tt.NoError(err)
+	tt.Equal(0, len(rows))
+
+	_, err = local.Insert(ztype.Map{"start_at": "not a time"})
+	tt.Equal(true, err != nil)
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+type commitQueueKey struct{}
+
+// commitQueue 事务结束后执行的回调，嵌套事务提交时合并到上层
+type commitQueue struct {
+	parent    *commitQueue
+	commits   []func()
+	rollbacks []func()
+	mu        sync.Mutex
+}
+
+func (q *commitQueue) add(fn func()) {
+	q.mu.Lock()
+	q.commits = append(q.commits, fn)
+	q.mu.Unlock()
+}
+
+func (q *commitQueue) addRollback(fn func()) {
+	q.mu.Lock()
+	q.rollbacks = append(q.rollbacks, fn)
+	q.mu.Unlock()
+}
+
+func (q *commitQueue) take() (commits, rollbacks []func()) {
+	q.mu.Lock()
+	commits, rollbacks = q.commits, q.rollbacks
+	q.commits, q.rollbacks = nil, nil
+	q.mu.Unlock()
+	return
+}
+
+// commit 最外层事务提交后执行提交回调，嵌套事务的回调交由上层决定
+func (q *commitQueue) commit() {
+	commits, rollbacks := q.take()
+	if q.parent != nil {
+		q.parent.mu.Lock()
+		q.parent.commits = append(q.parent.commits, commits...)
+		q.parent.rollbacks = append(q.parent.rollbacks, rollbacks...)
+		q.parent.mu.Unlock()
+		return
+	}
+	for i := range commits {
+		commits[i]()
+	}
+}
+
+// rollback 丢弃提交回调并执行回滚回调
+func (q *commitQueue) rollback() {
+	_, rollbacks := q.take()
+	for i := range rollbacks {
+		rollbacks[i]()
+	}
+}
+
+// TxOptions 事务选项，只对最外层事务生效
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+}
+
+// TxOption 事务选项函数
+type TxOption func(*TxOptions)
+
+// TxIsolation 设置事务隔离级别
+func TxIsolation(level sql.IsolationLevel) TxOption {
+	return func(o *TxOptions) {
+		o.Isolation = level
+	}
+}
+
+// TxReadOnly 设置为只读事务
+func TxReadOnly() TxOption {
+	return func(o *TxOptions) {
+		o.ReadOnly = true
+	}
+}
+
+// afterCommit 在事务提交后执行，不在事务中时立即执行
+func (m *Schema) afterCommit(fn func()) {
+	if q, ok := m.Context().Value(commitQueueKey{}).(*commitQueue); ok {
+		q.add(fn)
+		return
+	}
+	fn()
+}
+
+// afterRollback 在事务回滚后执行，不在事务中时忽略
+func (m *Schema) afterRollback(fn func()) {
+	if q, ok := m.Context().Value(commitQueueKey{}).(*commitQueue); ok {
+		q.addRollback(fn)
+	}
+}
+
+// transaction 在事务中执行，已处于事务中时以保存点嵌套执行
+func transaction(m *Schema, run func(tx *Schema) error, opts ...TxOption) error {
+	var o TxOptions
+	for _, f := range opts {
+		f(&o)
+	}
+
+	parent, _ := m.Context().Value(commitQueueKey{}).(*commitQueue)
+	q := &commitQueue{parent: parent}
+	ctx := context.WithValue(m.Context(), commitQueueKey{}, q)
+	err := storageTransaction(m.Storage, o, func(s Storageer) error {
+		return run(cloneSchemaWith(m, func(c *Schema) {
+			c.Storage = s
+			c.ctx = ctx
+		}))
+	})
+	if err != nil {
+		q.rollback()
+		return err
+	}
+
+	q.commit()
+	return nil
+}
+
+// storageTransaction 按选项开启存储事务
+func storageTransaction(s Storageer, o TxOptions, run func(s Storageer) error) error {
+	if o == (TxOptions{}) {
+		return s.Transaction(run)
+	}
+	t, ok := s.(TransactionOptioner)
+	if !ok {
+		return ErrTxOptionsNotSupported
+	}
+	return t.TransactionWithOptions(o, run)
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztype"
+)
+
+func TestNestedTransaction(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	_, m := newTestDB(t, "tx_nested")
+
+	type txRepo = Repository[ztype.Map, QueryFilter, ztype.Map, ztype.Map]
+	repo := m.Model().Repository()
+	inner := errors.New("inner")
+
+	var events []string
+	err := repo.Tx(func(tx *txRepo) error {
+		tx.AfterCommit(func() { events = append(events, "outer commit") })
+		if _, err := tx.Insert(ztype.Map{"name": "Outer", "email": "outer@test.com", "age": 30, "status": 1}); err != nil {
+			return err
+		}
+
+		err := tx.Tx(func(tx *txRepo) error {
+			tx.AfterCommit(func() { events = append(events, "failed commit") })
+			tx.AfterRollback(func() { events = append(events, "failed rollback") })
+			if _, err := tx.Insert(ztype.Map{"name": "Inner1", "email": "inner1@test.com", "age": 20, "status": 1}); err != nil {
+				return err
+			}
+			return inner
+		})
+		tt.Equal(inner, err)
+		tt.Equal([]string{"failed rollback"}, events)
+
+		return tx.Tx(func(tx *txRepo) error {
+			tx.AfterCommit(func() { events = append(events, "inner commit") })
+			_, err := tx.Insert(ztype.Map{"name": "Inner2", "email": "inner2@test.com", "age": 20, "status": 1})
+			return err
+		})
+	})
+	tt.NoError(err)
+	tt.Equal([]string{"failed rollback", "outer commit", "inner commit"}, events)
+
+	names, err := m.Model().FindCols("name", Filter{})
+	tt.NoError(err)
+	tt.Equal([]string{"Outer", "Inner2"}, names.String())
+
+	events = events[:0]
+	err = repo.Tx(func(tx *txRepo) error {
+		tx.AfterRollback(func() { events = append(events, "outer rollback") })
+		err := tx.Tx(func(tx *txRepo) error {
+			tx.AfterRollback(func() { events = append(events, "released rollback") })
+			_, err := tx.Insert(ztype.Map{"name": "Gone", "email": "gone@test.com", "age": 20, "status": 1})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return inner
+	})
+	tt.Equal(inner, err)
+	tt.Equal([]string{"outer rollback", "released rollback"}, events)
+
+	count, err := repo.Query().Count()
+	tt.NoError(err)
+	tt.Equal(uint64(2), count)
+}
+
+func TestTransactionOptions(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	_, m := newTestDB(t, "tx_options")
+
+	type txRepo = Repository[ztype.Map, QueryFilter, ztype.Map, ztype.Map]
+	repo := m.Model().Repository()
+
+	err := repo.Tx(func(tx *txRepo) error {
+		_, err := tx.Insert(ztype.Map{"name": "ReadOnly", "email": "ro@test.com", "age": 20, "status": 1})
+		return err
+	}, TxReadOnly())
+	tt.Equal(true, err != nil)
+
+	err = repo.Tx(func(tx *txRepo) error {
+		_, err := tx.Query().Count()
+		return err
+	}, TxReadOnly(), TxIsolation(sql.LevelSerializable))
+	tt.NoError(err)
+
+	err = repo.Tx(func(tx *txRepo) error {
+		return nil
+	}, TxIsolation(sql.LevelReadCommitted))
+	tt.Equal(ErrTxOptionsNotSupported, err)
+
+	_, err = repo.Insert(ztype.Map{"name": "Writable", "email": "rw@test.com", "age": 20, "status": 1})
+	tt.NoError(err)
+
+	stmt, err := setTransactionStatement(TxOptions{Isolation: sql.LevelReadCommitted, ReadOnly: true})
+	tt.NoError(err)
+	tt.Equal("SET TRANSACTION ISOLATION LEVEL READ COMMITTED, READ ONLY", stmt)
+	_, err = setTransactionStatement(TxOptions{Isolation: sql.LevelSnapshot})
+	tt.Equal(ErrTxOptionsNotSupported, err)
+}
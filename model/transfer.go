@@ -0,0 +1,545 @@
+package model
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sohaha/zlsgo/zjson"
+	"github.com/sohaha/zlsgo/ztype"
+	mSchema "github.com/zlsgo/app_module/model/schema"
+	"github.com/zlsgo/zdb/schema"
+)
+
+// TransferFormat 导入导出的文件格式
+type TransferFormat string
+
+const (
+	// FormatCSV 逗号分隔，首行为表头，导出时带 UTF-8 BOM 便于 Excel 识别
+	FormatCSV TransferFormat = "csv"
+	// FormatJSONL 每行一个 JSON 对象，键为字段名，值不做展示转换
+	FormatJSONL TransferFormat = "jsonl"
+	// FormatXLSX Excel 工作簿，只读写第一个工作表
+	FormatXLSX TransferFormat = "xlsx"
+)
+
+const utf8BOM = "\ufeff"
+
+// ExportOptions 导出选项
+type ExportOptions struct {
+	// Fields 导出的字段，默认为调用方可见的全部字段
+	Fields []string
+	// BatchSize 每次查询的行数
+	BatchSize int
+	// FieldNameHeader 表头使用字段名而不是 Label
+	FieldNameHeader bool
+}
+
+// ImportMode 导入的事务模式
+type ImportMode uint8
+
+const (
+	// ImportAtomic 在同一事务中导入，任意行失败时全部回滚
+	ImportAtomic ImportMode = iota
+	// ImportBestEffort 逐行导入，失败的行被跳过并记录
+	ImportBestEffort
+)
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	// UpsertKey 按该字段更新已存在的记录，为空时全部插入
+	UpsertKey string
+	Mode      ImportMode
+	// DryRun 只校验不写入
+	DryRun bool
+	// MaxXLSXSize XLSX 需要整体读入内存，超过该字节数时返回 ErrFileTooLarge，默认 DefaultMaxXLSXSize
+	MaxXLSXSize int64
+}
+
+// ImportRowError 导入失败的行，Row 为文件中的行号
+type ImportRowError struct {
+	Err error
+	Row int
+}
+
+// Error 返回行错误信息
+func (e ImportRowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+// Unwrap 返回原始错误
+func (e ImportRowError) Unwrap() error {
+	return e.Err
+}
+
+// ImportResult 导入结果，事务模式回滚时写入计数为 0
+type ImportResult struct {
+	Errors   []ImportRowError `json:"errors"`
+	Total    int              `json:"total"`
+	Inserted int              `json:"inserted"`
+	Updated  int              `json:"updated"`
+}
+
+type tableWriter interface {
+	writeRow(cells []any) error
+	close() error
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) writeRow(cells []any) error {
+	record := make([]string, len(cells))
+	for i := range cells {
+		record[i] = ztype.ToString(cells[i])
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// Export 按主键顺序分批查询，以流式写入 w，返回导出的行数
+func Export(m *Store, filter QueryFilter, format TransferFormat, w io.Writer, opts ...func(*ExportOptions)) (int, error) {
+	o := ExportOptions{BatchSize: DefaultBatchSize}
+	for _, f := range opts {
+		f(&o)
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchSize
+	}
+
+	s := m.schema
+	fields := o.Fields
+	if len(fields) == 0 {
+		fields = exportFields(s)
+	}
+	for _, name := range fields {
+		if err := s.checkColumn(name); err != nil {
+			return 0, err
+		}
+	}
+
+	var (
+		table tableWriter
+		enc   *json.Encoder
+	)
+	switch format {
+	case FormatCSV:
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return 0, err
+		}
+		table = &csvWriter{w: csv.NewWriter(w)}
+	case FormatXLSX:
+		x, err := newXLSXWriter(w)
+		if err != nil {
+			return 0, err
+		}
+		table = x
+	case FormatJSONL:
+		enc = json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+	default:
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+
+	defines := make([]*mSchema.Field, len(fields))
+	for i, name := range fields {
+		defines[i], _ = s.getField(name)
+	}
+	if table != nil {
+		header := make([]any, len(fields))
+		for i, name := range fields {
+			header[i] = name
+			if !o.FieldNameHeader && defines[i] != nil && defines[i].Label != "" {
+				header[i] = defines[i].Label
+			}
+		}
+		if err := table.writeRow(header); err != nil {
+			return 0, err
+		}
+	}
+
+	orderBy := make([]OrderByItem, 0, 1)
+	for _, key := range s.PrimaryKeys() {
+		orderBy = append(orderBy, OrderByItem{Field: key, Direction: "ASC"})
+	}
+
+	total := 0
+	for offset := 0; ; offset += o.BatchSize {
+		rows, err := m.Find(filter, func(co *CondOptions) {
+			co.Fields = append(co.Fields[:0], fields...)
+			co.OrderBy = append(co.OrderBy[:0], orderBy...)
+			co.Limit = o.BatchSize
+			co.Offset = offset
+		})
+		if err != nil {
+			return total, err
+		}
+
+		for _, row := range rows {
+			if enc != nil {
+				item := make(map[string]any, len(fields))
+				for _, name := range fields {
+					if v, ok := row[name]; ok {
+						item[name] = v
+					}
+				}
+				err = enc.Encode(item)
+			} else {
+				cells := make([]any, len(fields))
+				for i, name := range fields {
+					cells[i] = exportCell(s, defines[i], row[name])
+				}
+				err = table.writeRow(cells)
+			}
+			if err != nil {
+				return total, err
+			}
+		}
+
+		total += len(rows)
+		if len(rows) < o.BatchSize {
+			break
+		}
+	}
+
+	if table != nil {
+		return total, table.close()
+	}
+	return total, nil
+}
+
+// Import 流式读取并逐行校验写入，返回导入结果与每行的错误
+func Import(m *Store, r io.Reader, format TransferFormat, opts ...func(*ImportOptions)) (*ImportResult, error) {
+	var o ImportOptions
+	for _, f := range opts {
+		f(&o)
+	}
+
+	s := m.schema
+	if o.UpsertKey != "" {
+		if err := s.checkColumn(o.UpsertKey); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &ImportResult{}
+	each := func(fn func(line int, data ztype.Map) error) error {
+		return readImportRows(s, r, format, o.MaxXLSXSize, fn)
+	}
+
+	if o.DryRun || o.Mode == ImportBestEffort {
+		err := each(func(line int, data ztype.Map) error {
+			result.Total++
+			if err := importRow(m, data, &o, result); err != nil {
+				result.Errors = append(result.Errors, ImportRowError{Row: line, Err: err})
+			}
+			return nil
+		})
+		return result, err
+	}
+
+	err := transaction(s, func(tx *Schema) error {
+		err := each(func(line int, data ztype.Map) error {
+			result.Total++
+			// 每行使用保存点，数据库报错的行不影响后续行的校验
+			err := transaction(tx, func(rowTx *Schema) error {
+				return importRow(rowTx.Model(), data, &o, result)
+			})
+			if err != nil {
+				result.Errors = append(result.Errors, ImportRowError{Row: line, Err: err})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(result.Errors) > 0 {
+			return ErrImportFailed
+		}
+		return nil
+	})
+	if err != nil {
+		result.Inserted, result.Updated = 0, 0
+		return result, err
+	}
+	return result, nil
+}
+
+// importRow 写入单行，配置 UpsertKey 时已存在的记录执行更新
+func importRow(m *Store, data ztype.Map, o *ImportOptions, result *ImportResult) error {
+	exists := false
+	if o.UpsertKey != "" {
+		key, ok := data[o.UpsertKey]
+		if !ok || key == nil {
+			return fmt.Errorf("%w: missing %s", ErrInvalidData, o.UpsertKey)
+		}
+		var err error
+		if exists, err = m.Exists(Filter{o.UpsertKey: key}); err != nil {
+			return err
+		}
+	}
+
+	s := m.schema
+	if o.DryRun {
+		active := activeCreate
+		if exists {
+			active = activeUpdate
+		}
+		_, err := verifiData(cloneFilterMap(data), s.GetDefineFields(), active, s.displayZone())
+		return err
+	}
+
+	if exists {
+		if _, err := m.UpdateMany(Filter{o.UpsertKey: data[o.UpsertKey]}, data); err != nil {
+			return err
+		}
+		result.Updated++
+		return nil
+	}
+	if _, err := m.Insert(data); err != nil {
+		return err
+	}
+	result.Inserted++
+	return nil
+}
+
+// readImportRows 按格式逐行读取并转换为字段数据
+func readImportRows(s *Schema, r io.Reader, format TransferFormat, maxXLSXSize int64, fn func(line int, data ztype.Map) error) error {
+	switch format {
+	case FormatJSONL:
+		dec := json.NewDecoder(r)
+		dec.UseNumber()
+		for line := 1; ; line++ {
+			var item map[string]any
+			err := dec.Decode(&item)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			data := make(ztype.Map, len(item))
+			for k, v := range item {
+				if n, ok := v.(json.Number); ok {
+					v = n.String()
+				}
+				data[k] = v
+			}
+			if err = fn(line, data); err != nil {
+				return err
+			}
+		}
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		var columns []importColumn
+		for line := 1; ; line++ {
+			record, err := cr.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if columns == nil {
+				if len(record) > 0 {
+					record[0] = strings.TrimPrefix(record[0], utf8BOM)
+				}
+				columns = importColumns(s, record)
+				continue
+			}
+			if err = fn(line, importData(columns, record, false)); err != nil {
+				return err
+			}
+		}
+	case FormatXLSX:
+		var columns []importColumn
+		return readXLSX(r, maxXLSXSize, func(line int, cells []string) error {
+			if columns == nil {
+				columns = importColumns(s, cells)
+				return nil
+			}
+			return fn(line, importData(columns, cells, true))
+		})
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+}
+
+type importColumn struct {
+	field *mSchema.Field
+	name  string
+}
+
+// importColumns 按字段名或 Label 匹配表头，无法匹配的列会被忽略
+func importColumns(s *Schema, header []string) []importColumn {
+	labels := make(map[string]string, len(s.define.Fields))
+	for name, f := range s.define.Fields {
+		if f.Label != "" {
+			labels[f.Label] = name
+		}
+	}
+
+	columns := make([]importColumn, len(header))
+	for i := range header {
+		title := strings.TrimSpace(header[i])
+		name := title
+		if _, ok := s.getField(name); !ok {
+			name = labels[title]
+		}
+		if f, ok := s.getField(name); ok {
+			columns[i] = importColumn{name: name, field: f}
+		}
+	}
+	return columns
+}
+
+// importData 将表格中的一行转换为字段数据，空单元格视为未填写
+func importData(columns []importColumn, cells []string, xlsx bool) ztype.Map {
+	data := make(ztype.Map, len(columns))
+	for i := range columns {
+		if columns[i].field == nil || i >= len(cells) {
+			continue
+		}
+		v := unescapeFormula(strings.TrimSpace(cells[i]))
+		if v == "" {
+			continue
+		}
+		data[columns[i].name] = importValue(columns[i].field, v, xlsx)
+	}
+	return data
+}
+
+// importValue 枚举 Label 还原为枚举值，Excel 日期序列号还原为时间
+func importValue(f *mSchema.Field, v string, xlsx bool) any {
+	if len(f.Options.Enum) > 0 {
+		if !f.Options.IsArray {
+			return enumValueOf(f, v)
+		}
+		if zjson.Valid(v) {
+			return v
+		}
+		items := strings.Split(v, ",")
+		values := make([]string, 0, len(items))
+		for i := range items {
+			if item := strings.TrimSpace(items[i]); item != "" {
+				values = append(values, enumValueOf(f, item))
+			}
+		}
+		return values
+	}
+
+	if xlsx && f.Type == schema.Time {
+		// Excel 以 1899-12-30 起的天数保存日期
+		if days, err := strconv.ParseFloat(v, 64); err == nil && days > 0 && days < 2958466 {
+			t := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).Add(time.Duration(days * 24 * float64(time.Hour)))
+			return t.Round(time.Second).Format(time.DateTime)
+		}
+	}
+	return v
+}
+
+func enumValueOf(f *mSchema.Field, v string) string {
+	for _, e := range f.Options.Enum {
+		if e.Value == v {
+			return v
+		}
+	}
+	for _, e := range f.Options.Enum {
+		if e.Label == v {
+			return e.Value
+		}
+	}
+	return v
+}
+
+// exportCell 表格单元格的展示值，枚举输出 Label，复合值输出 JSON
+func exportCell(m *Schema, f *mSchema.Field, v any) any {
+	if v == nil {
+		return ""
+	}
+	if f != nil && len(f.Options.Enum) > 0 {
+		if !f.Options.IsArray {
+			value := ztype.ToString(v)
+			for _, e := range f.Options.Enum {
+				if e.Value == value {
+					return escapeFormula(e.Label)
+				}
+			}
+			return escapeFormula(value)
+		}
+
+		var values []string
+		if s, ok := v.(string); ok {
+			values = zjson.Parse(s).Slice().String()
+		} else {
+			values = ztype.ToSlice(v).String()
+		}
+		for i := range values {
+			for _, e := range f.Options.Enum {
+				if e.Value == values[i] {
+					values[i] = e.Label
+					break
+				}
+			}
+		}
+		return escapeFormula(strings.Join(values, ","))
+	}
+
+	switch val := v.(type) {
+	case string:
+		return escapeFormula(val)
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return val
+	case Decimal:
+		return val.String()
+	case DataTime:
+		return val.String()
+	case time.Time:
+		return formatTime(val, m.timeFormat(f), m.displayZone())
+	case []byte:
+		return escapeFormula(string(val))
+	default:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return escapeFormula(ztype.ToString(val))
+		}
+		return string(b)
+	}
+}
+
+// escapeFormula 以 = + - @ 开头的文本加上单引号前缀，避免表格软件将其作为公式执行
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// unescapeFormula 去掉导出时为公式字符添加的单引号前缀
+func unescapeFormula(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune("=+-@", rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+// exportFields 默认导出的字段，排除对调用方隐藏的字段
+func exportFields(s *Schema) []string {
+	fields := make([]string, 0, len(s.GetFields()))
+	for _, name := range s.GetFields() {
+		if s.FieldVisibility(name) != mSchema.VisibilityHidden {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
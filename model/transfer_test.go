@@ -0,0 +1,171 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func TestTransfer(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	items := schema.Schema{
+		Name:  "transfer_items",
+		Table: schema.Table{Name: "transfer_items"},
+		Fields: map[string]schema.Field{
+			"sku":   {Type: schema.String, Size: 20, Label: "编号"},
+			"title": {Type: schema.String, Size: 12, Label: "名称"},
+			"state": {Type: schema.String, Size: 10, Label: "状态", Options: schema.FieldOption{
+				Enum: []schema.FieldEnum{{Value: "on", Label: "上架"}, {Value: "off", Label: "下架"}},
+			}},
+		},
+	}
+	_, schemas := newTestSchemas(t, items)
+	store := schemas.MustGet("transfer_items").Model()
+	repo := store.Repository()
+
+	for _, row := range []ztype.Map{
+		{"sku": "a1", "title": "apple", "state": "on"},
+		{"sku": "b2", "title": "pear, \"big\"", "state": "off"},
+	} {
+		_, err := repo.Insert(row)
+		tt.NoError(err)
+	}
+
+	fields := func(o *ExportOptions) {
+		o.Fields = []string{"sku", "title", "state"}
+		o.BatchSize = 1
+	}
+
+	var buf bytes.Buffer
+	total, err := Export(store, Filter{}, FormatCSV, &buf, fields)
+	tt.NoError(err)
+	tt.Equal(2, total)
+	tt.Equal("\ufeff编号,名称,状态\na1,apple,上架\nb2,\"pear, \"\"big\"\"\",下架\n", buf.String())
+
+	exported := map[TransferFormat][]byte{FormatCSV: buf.Bytes()}
+	for _, format := range []TransferFormat{FormatJSONL, FormatXLSX} {
+		var b bytes.Buffer
+		total, err = Export(store, Filter{}, format, &b, fields)
+		tt.NoError(err)
+		tt.Equal(2, total)
+		exported[format] = b.Bytes()
+	}
+
+	for format, data := range exported {
+		_, err = repo.DeleteMany(Filter{})
+		tt.NoError(err)
+
+		result, err := Import(store, bytes.NewReader(data), format)
+		tt.NoError(err)
+		tt.Equal(2, result.Inserted)
+
+		rows, err := repo.Query().OrderBy("sku").Find()
+		tt.NoError(err)
+		tt.Equal(2, len(rows))
+		tt.Equal("pear, \"big\"", rows[1].Get("title").String())
+		tt.Equal("off", rows[1].Get("state").String())
+	}
+
+	csv := "编号,名称,状态\na1,apple2,下架\nc3,cherry,上架\n"
+	result, err := Import(store, strings.NewReader(csv), FormatCSV, func(o *ImportOptions) {
+		o.UpsertKey = "sku"
+		o.DryRun = true
+	})
+	tt.NoError(err)
+	tt.Equal(2, result.Total)
+	tt.Equal(0, len(result.Errors))
+	count, _ := repo.Query().Count()
+	tt.Equal(uint64(2), count)
+
+	result, err = Import(store, strings.NewReader(csv), FormatCSV, func(o *ImportOptions) {
+		o.UpsertKey = "sku"
+	})
+	tt.NoError(err)
+	tt.Equal(1, result.Updated)
+	tt.Equal(1, result.Inserted)
+	row, err := repo.Query().Where("sku", "a1").First()
+	tt.NoError(err)
+	tt.Equal("apple2", row.Get("title").String())
+	tt.Equal("off", row.Get("state").String())
+
+	bad := "sku,title\nd4,date\ne5,a title too long for the column\n"
+	result, err = Import(store, strings.NewReader(bad), FormatCSV)
+	tt.Equal(true, errors.Is(err, ErrImportFailed))
+	tt.Equal(1, len(result.Errors))
+	tt.Equal(3, result.Errors[0].Row)
+	tt.Equal(0, result.Inserted)
+	count, _ = repo.Query().Count()
+	tt.Equal(uint64(3), count)
+
+	result, err = Import(store, strings.NewReader(bad), FormatCSV, func(o *ImportOptions) {
+		o.Mode = ImportBestEffort
+	})
+	tt.NoError(err)
+	tt.Equal(1, result.Inserted)
+	tt.Equal(1, len(result.Errors))
+	count, _ = repo.Query().Count()
+	tt.Equal(uint64(4), count)
+
+	_, err = Export(store, Filter{}, "xml", &buf)
+	tt.Equal(true, errors.Is(err, ErrUnsupportedFormat))
+}
+
+func TestTransferFormulaAndSize(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	items := schema.Schema{
+		Name:  "transfer_formulas",
+		Table: schema.Table{Name: "transfer_formulas"},
+		Fields: map[string]schema.Field{
+			"sku":   {Type: schema.String, Size: 20},
+			"title": {Type: schema.String, Size: 20},
+		},
+	}
+	_, schemas := newTestSchemas(t, items)
+	store := schemas.MustGet("transfer_formulas").Model()
+	repo := store.Repository()
+
+	_, err := repo.Insert(ztype.Map{"sku": "a1", "title": "=HYPERLINK(1)"})
+	tt.NoError(err)
+	_, err = repo.Insert(ztype.Map{"sku": "b2", "title": "@SUM(A1)"})
+	tt.NoError(err)
+
+	fields := func(o *ExportOptions) {
+		o.Fields = []string{"sku", "title"}
+		o.FieldNameHeader = true
+	}
+
+	// 导出时公式字符加上单引号前缀，导入时还原
+	var buf bytes.Buffer
+	_, err = Export(store, Filter{}, FormatCSV, &buf, fields)
+	tt.NoError(err)
+	tt.Equal("\ufeffsku,title\na1,'=HYPERLINK(1)\nb2,'@SUM(A1)\n", buf.String())
+
+	var xlsx bytes.Buffer
+	_, err = Export(store, Filter{}, FormatXLSX, &xlsx, fields)
+	tt.NoError(err)
+
+	for format, data := range map[TransferFormat][]byte{FormatCSV: buf.Bytes(), FormatXLSX: xlsx.Bytes()} {
+		_, err = repo.DeleteMany(Filter{})
+		tt.NoError(err)
+		_, err = Import(store, bytes.NewReader(data), format)
+		tt.NoError(err)
+		row, err := repo.Query().Where("sku", "a1").First()
+		tt.NoError(err)
+		tt.Equal("=HYPERLINK(1)", row.Get("title").String())
+	}
+
+	// XLSX 超过大小上限时拒绝读取
+	limit := func(o *ImportOptions) { o.MaxXLSXSize = 64 }
+	_, err = Import(store, struct{ io.Reader }{bytes.NewReader(xlsx.Bytes())}, FormatXLSX, limit)
+	tt.Equal(true, errors.Is(err, ErrFileTooLarge))
+	_, err = Import(store, bytes.NewReader(xlsx.Bytes()), FormatXLSX, limit)
+	tt.Equal(true, errors.Is(err, ErrFileTooLarge))
+}
@@ -0,0 +1,336 @@
+package model
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sohaha/zlsgo/ztype"
+)
+
+const xlsxSheetPath = "xl/worksheets/sheet1.xml"
+
+// DefaultMaxXLSXSize 导入 XLSX 时默认允许读入内存的字节数
+const DefaultMaxXLSXSize int64 = 64 << 20
+
+// xlsxFiles 单工作表文件的固定部分
+var xlsxFiles = [...]struct{ name, body string }{
+	{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// xlsxWriter 以内联字符串流式写入单个工作表
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	rows  int
+	buf   bytes.Buffer
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+	for _, f := range xlsxFiles {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.WriteString(fw, xml.Header+f.body); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := zw.Create(xlsxSheetPath)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.WriteString(sheet, xml.Header+
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxWriter{zw: zw, sheet: sheet}, nil
+}
+
+func (x *xlsxWriter) writeRow(cells []any) error {
+	x.rows++
+	line := strconv.Itoa(x.rows)
+	x.buf.Reset()
+	x.buf.WriteString(`<row r="` + line + `">`)
+	for i, v := range cells {
+		ref := xlsxColumn(i) + line
+		switch val := v.(type) {
+		case nil:
+			continue
+		case bool:
+			b := "0"
+			if val {
+				b = "1"
+			}
+			x.buf.WriteString(`<c r="` + ref + `" t="b"><v>` + b + `</v></c>`)
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			x.buf.WriteString(`<c r="` + ref + `"><v>` + ztype.ToString(val) + `</v></c>`)
+		default:
+			s := ztype.ToString(val)
+			if s == "" {
+				continue
+			}
+			x.buf.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(&x.buf, []byte(s)); err != nil {
+				return err
+			}
+			x.buf.WriteString(`</t></is></c>`)
+		}
+	}
+	x.buf.WriteString(`</row>`)
+	_, err := x.sheet.Write(x.buf.Bytes())
+	return err
+}
+
+func (x *xlsxWriter) close() error {
+	if _, err := io.WriteString(x.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+// readXLSX 逐行读取第一个工作表，zip 格式需要随机访问，
+// r 支持随机访问（如 *os.File）时直接读取，否则整体读入内存，超过 maxSize 时返回 ErrFileTooLarge
+func readXLSX(r io.Reader, maxSize int64, fn func(line int, cells []string) error) error {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxXLSXSize
+	}
+
+	var (
+		ra   io.ReaderAt
+		size int64
+	)
+	if f, ok := r.(interface {
+		io.ReaderAt
+		Stat() (fs.FileInfo, error)
+	}); ok {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			ra, size = f, info.Size()
+		}
+	} else if f, ok := r.(interface {
+		io.ReaderAt
+		Size() int64
+	}); ok {
+		ra, size = f, f.Size()
+	}
+	if ra == nil {
+		data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+		if err != nil {
+			return err
+		}
+		ra, size = bytes.NewReader(data), int64(len(data))
+	}
+	if size > maxSize {
+		return fmt.Errorf("%w: xlsx exceeds %d bytes", ErrFileTooLarge, maxSize)
+	}
+
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	sheets := make([]string, 0, 1)
+	for _, f := range zr.File {
+		files[f.Name] = f
+		if strings.HasPrefix(f.Name, "xl/worksheets/") && strings.HasSuffix(f.Name, ".xml") {
+			sheets = append(sheets, f.Name)
+		}
+	}
+	sheet, ok := files[xlsxSheetPath]
+	if !ok {
+		if len(sheets) == 0 {
+			return errors.New("xlsx: worksheet not found")
+		}
+		sort.Strings(sheets)
+		sheet = files[sheets[0]]
+	}
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if shared, err = readXLSXSharedStrings(f); err != nil {
+			return err
+		}
+	}
+
+	rc, err := sheet.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	var (
+		dec     = xml.NewDecoder(rc)
+		row     []string
+		text    strings.Builder
+		typ     string
+		line    int
+		col     int
+		inValue bool
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				row, col = row[:0], -1
+				line++
+				for _, a := range t.Attr {
+					if a.Name.Local == "r" {
+						if n, err := strconv.Atoi(a.Value); err == nil {
+							line = n
+						}
+					}
+				}
+			case "c":
+				col++
+				typ = ""
+				text.Reset()
+				for _, a := range t.Attr {
+					switch a.Name.Local {
+					case "r":
+						if i := xlsxColumnIndex(a.Value); i >= 0 {
+							col = i
+						}
+					case "t":
+						typ = a.Value
+					}
+				}
+			case "v", "t":
+				inValue = true
+			}
+		case xml.CharData:
+			if inValue {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				value := text.String()
+				switch typ {
+				case "s":
+					i, err := strconv.Atoi(value)
+					if err != nil || i < 0 || i >= len(shared) {
+						return errors.New("xlsx: invalid shared string index")
+					}
+					value = shared[i]
+				case "b":
+					value = strconv.FormatBool(value == "1")
+				}
+				for len(row) <= col {
+					row = append(row, "")
+				}
+				row[col] = value
+			case "row":
+				if err := fn(line, row); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func readXLSXSharedStrings(f *zip.File) ([]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var (
+		dec      = xml.NewDecoder(rc)
+		shared   []string
+		text     strings.Builder
+		inText   bool
+		phonetic bool
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return shared, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				text.Reset()
+			case "rPh":
+				phonetic = true
+			case "t":
+				inText = !phonetic
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "rPh":
+				phonetic = false
+			case "si":
+				shared = append(shared, text.String())
+			}
+		}
+	}
+}
+
+// xlsxColumn 列序号（从 0 开始）转换为列名
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxColumnIndex 单元格引用（如 AB12）转换为列序号
+func xlsxColumnIndex(ref string) int {
+	i := 0
+	n := 0
+	for ; n < len(ref) && ref[n] >= 'A' && ref[n] <= 'Z'; n++ {
+		i = i*26 + int(ref[n]-'A'+1)
+	}
+	if n == 0 {
+		return -1
+	}
+	return i - 1
+}
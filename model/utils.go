@@ -2,15 +2,11 @@ package model
 
 import (
 	"errors"
-	"io/fs"
-	"path/filepath"
 	"strings"
 
 	"github.com/sohaha/zlsgo/zarray"
 	"github.com/sohaha/zlsgo/zdi"
 	"github.com/sohaha/zlsgo/zerror"
-	"github.com/sohaha/zlsgo/zfile"
-	"github.com/sohaha/zlsgo/zjson"
 	"github.com/sohaha/zlsgo/zlog"
 	"github.com/sohaha/zlsgo/ztype"
 	"github.com/sohaha/zlsgo/zutil"
@@ -58,48 +54,16 @@ func fillFieldsTablePrefix(f []string, table string) []string {
 	return result
 }
 
-func parseSchema(dir string) ([]schema.Schema, error) {
-	var files []string
-	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if !d.IsDir() {
-			if strings.ToLower(filepath.Ext(path)) != ".json" {
-				return nil
-			}
-			files = append(files, path)
-		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	schemaModelsDefine := make([]schema.Schema, 0, len(files))
-	for _, filePath := range files {
-		text, err := zfile.ReadFile(filePath)
-		if err != nil {
-			return nil, zerror.With(err, "read schema file: "+filePath)
-		}
-
-		var d schema.Schema
-		if err := zjson.Unmarshal(text, &d); err != nil {
-			return nil, zerror.With(err, "invalid schema file: "+filePath)
-		}
-
-		d.SchemaPath = filePath
-		schemaModelsDefine = append(schemaModelsDefine, d)
-	}
-
-	return schemaModelsDefine, nil
-}
-
 func parseExprsBuildCond(d *builder.BuildCond, value interface{}, exprs []string) ([]string, error) {
 	var expr string
 	switch val := value.(type) {
 	case func(*builder.BuildCond) string:
 		expr = val(d)
+	case condBuilder:
+		var err error
+		if expr, err = val(d); err != nil {
+			return nil, err
+		}
 	case func() string:
 		expr = val()
 	default:
@@ -138,10 +102,13 @@ func initModels(m *Module, di zdi.Invoker) (err error) {
 	}
 
 	m.schemas = NewSchemas(injector, storageer, opt.SchemaOptions)
+	if opt.Hooks != nil {
+		opt.Hooks(m.schemas.Hooks())
+	}
 	m.stores = &Stores{items: zarray.NewHashMap[string, *Store]()}
 
 	if opt.SchemaDir != "" {
-		schemaModelsDefine, err := parseSchema(opt.SchemaDir)
+		schemaModelsDefine, err := parseSchema(opt.SchemaDir, opt.SchemaEnv)
 		if err != nil {
 			return err
 		}
@@ -174,6 +141,40 @@ func initModels(m *Module, di zdi.Invoker) (err error) {
 		})
 	}
 
+	if opt.SchemaRepository != nil {
+		// 管理接口会执行 DDL，必须显式配置鉴权
+		if opt.SchemaApi != "" && opt.SchemaManageMiddleware == nil {
+			return errors.New("schema management api requires SchemaManageMiddleware")
+		}
+		repo, err := opt.SchemaRepository(m.schemas)
+		if err != nil {
+			return zerror.With(err, "init schema repository error")
+		}
+		m.manager, err = NewSchemaManager(m.schemas, repo, func(name string, s *Schema) {
+			if s == nil {
+				m.stores.items.Delete(name)
+				return
+			}
+			m.stores.items.Set(name, s.Model())
+		})
+		if err != nil {
+			return zerror.With(err, "load managed schemas error")
+		}
+	}
+
+	if opt.SchemaDir != "" && opt.SchemaWatch > 0 {
+		m.watcher, err = m.schemas.WatchDir(opt.SchemaDir, func(o *SchemaWatchOptions) {
+			o.Env = opt.SchemaEnv
+			o.Interval = opt.SchemaWatch
+			o.OnReload = func(name string, s *Schema) {
+				m.stores.items.Set(name, s.Model())
+			}
+		})
+		if err != nil {
+			return zerror.With(err, "watch schema dir error")
+		}
+	}
+
 	_ = mapper.Maps(m.schemas, m.stores)
 
 	zlog.Debugf("Models %s\n", m.schemas)
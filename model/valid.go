@@ -2,12 +2,10 @@ package model
 
 import (
 	"errors"
-	"strconv"
 	"time"
 
 	"github.com/sohaha/zlsgo/zarray"
 	"github.com/sohaha/zlsgo/zjson"
-	"github.com/sohaha/zlsgo/ztime"
 	"github.com/sohaha/zlsgo/ztype"
 	mSchema "github.com/zlsgo/app_module/model/schema"
 	"github.com/zlsgo/zdb/schema"
@@ -47,6 +45,11 @@ func filterDate(data ztype.Map, fields []string) ztype.Map {
 
 // VerifiData 验证数据
 func VerifiData(data ztype.Map, columns mSchema.Fields, active activeType) (ztype.Map, error) {
+	return verifiData(data, columns, active, nil)
+}
+
+// verifiData 验证数据，不含时区的时间按 loc 解释
+func verifiData(data ztype.Map, columns mSchema.Fields, active activeType, loc *time.Location) (ztype.Map, error) {
 	d := make(ztype.Map, len(columns))
 	for name, column := range columns {
 		if active == activeUpdate && column.Options.ReadOnly {
@@ -96,38 +99,27 @@ func VerifiData(data ztype.Map, columns mSchema.Fields, active activeType) (ztyp
 		if err != nil {
 			return d, err
 		}
-		switch t := v.(type) {
+		switch v.(type) {
+		case DataTime, time.Time, string, []byte,
+			int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
 		default:
 			return d, errors.New(label + ": 未知时间格式")
-		case DataTime:
-			d[name] = t
-		case time.Time:
-			d[name] = DataTime{Time: t}
-		case int, int8, int16, int32, int64:
-			d[name] = DataTime{Time: ztime.Unix(ztype.ToInt64(v))}
-		case uint, uint8, uint16, uint32, uint64:
-			d[name] = DataTime{Time: ztime.Unix(ztype.ToInt64(v))}
-		case float32, float64:
-			d[name] = DataTime{Time: ztime.Unix(ztype.ToInt64(v))}
-		case string:
-			var (
-				r   time.Time
-				err error
-			)
-			// if column.Options.FormatTime == "" {
-			r, err = ztime.Parse(t)
-			// } else {
-			// 	r, err = ztime.Parse(t, column.Options.FormatTime)
-			// }
-			if err != nil {
-				if ts, err2 := strconv.ParseInt(t, 10, 64); err2 == nil {
-					d[name] = DataTime{Time: ztime.Unix(ts)}
-					break
-				}
-				return d, errors.New(label + ": 时间格式错误")
-			}
-			d[name] = DataTime{Time: r}
 		}
+		t, err := parseTime(v, column.Options.FormatTime, loc)
+		if err != nil {
+			return d, errors.New(label + ": 时间格式错误")
+		}
+		d[name] = DataTime{Time: t}
+	case mSchema.Decimal:
+		err := column.GetValidations().VerifiAny(v).Error()
+		if err != nil {
+			return d, err
+		}
+		val, err := verifyDecimal(label, &column, v)
+		if err != nil {
+			return d, err
+		}
+		d[name] = val
 	case schema.JSON:
 		err := column.GetValidations().VerifiAny(v).Error()
 		if err != nil {
@@ -177,6 +169,9 @@ func VerifiData(data ztype.Map, columns mSchema.Fields, active activeType) (ztyp
 
 				d[name] = val
 			}
+			if err := verifyEnum(label, &column, d[name]); err != nil {
+				return d, err
+			}
 		}
 	}
 
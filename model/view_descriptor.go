@@ -0,0 +1,502 @@
+package model
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/sohaha/zlsgo/ztype"
+	mSchema "github.com/zlsgo/app_module/model/schema"
+	"github.com/zlsgo/zdb/schema"
+)
+
+const (
+	// FormViewName 表单视图在 Extend.views 中的默认名称
+	FormViewName = "form"
+	// ListViewName 列表视图在 Extend.views 中的默认名称
+	ListViewName = "lists"
+)
+
+// viewLabelFields 关联选择器未指定显示字段时依次尝试的字段
+var viewLabelFields = []string{"name", "title", "label", "nickname", "username"}
+
+// ViewDescriptors 返回管理端使用的表单与列表描述
+func (m *Schema) ViewDescriptors() ztype.Map {
+	return ztype.Map{
+		"form": m.FormView(""),
+		"list": m.ListView(""),
+	}
+}
+
+// FormView 表单描述，控件由字段类型、枚举、长度与加密方式推断，
+// 可通过 Extend.views.<view>.overrides.<field> 按字段覆盖，view 为空时使用 form
+func (m *Schema) FormView(view string) ztype.Map {
+	if view == "" {
+		view = FormViewName
+	}
+	data := m.define.Extend.Get("views").Get(view).Map()
+	if data.Get("disabled").Bool() {
+		return ztype.Map{}
+	}
+
+	fields := data.Get("fields").Slice().String()
+	if len(fields) == 0 {
+		fields = m.GetFields()
+	}
+
+	relations := m.viewRelationPickers()
+	overrides := data.Get("overrides").Map()
+	names := make([]string, 0, len(fields))
+	items := make([]ztype.Map, 0, len(fields))
+	for _, name := range zarray.Unique(fields) {
+		f, ok := m.getField(name)
+		if !ok || m.isInlayField(name) || m.isBlindIndexColumn(name) ||
+			m.FieldVisibility(name) == mSchema.VisibilityHidden {
+			continue
+		}
+
+		item := ztype.Map{
+			"name":     name,
+			"label":    viewLabel(name, f),
+			"widget":   "input",
+			"required": !f.Nullable && f.Default == nil,
+			"readonly": f.Options.ReadOnly || zarray.Contains(m.readOnlyKeys, name),
+		}
+		props := ztype.Map{}
+		m.viewWidget(f, item, props)
+		if picker, ok := relations[name]; ok {
+			item["widget"] = "relation"
+			item["relation"] = picker
+		}
+		if len(props) > 0 {
+			item["props"] = props
+		}
+		if f.Default != nil {
+			item["default"] = f.Default
+		}
+		if f.Comment != "" {
+			item["help"] = f.Comment
+		}
+		if rules := viewRules(f, ztype.ToBool(item["required"])); len(rules) > 0 {
+			item["rules"] = rules
+		}
+
+		if item = viewOverride(item, overrides.Get(name).Map()); item == nil {
+			continue
+		}
+		names = append(names, name)
+		items = append(items, item)
+	}
+
+	title := data.Get("title").String()
+	if title == "" {
+		title = m.GetComment()
+	}
+	form := ztype.Map{
+		"title":  title,
+		"fields": names,
+		"items":  items,
+	}
+	if layouts := data.Get("layouts").Map(); !layouts.IsEmpty() {
+		form["layouts"] = layouts
+	}
+	return form
+}
+
+// ListView 列表描述，包含可筛选、排序与搜索的列及默认操作，
+// 可通过 Extend.views.<view>.overrides.<field> 按字段覆盖，view 为空时使用 lists
+func (m *Schema) ListView(view string) ztype.Map {
+	if view == "" {
+		view = ListViewName
+	}
+	views := m.define.Extend.Get("views")
+	data := views.Get(view).Map()
+	if data.Get("disabled").Bool() {
+		return ztype.Map{}
+	}
+
+	keys := m.PrimaryKeys()
+	fields := append(append([]string{}, keys...), data.Get("fields").Slice().String()...)
+	if len(fields) == len(keys) {
+		fields = append(fields, m.GetFields()...)
+	}
+
+	relations := m.viewRelationPickers()
+	overrides := data.Get("overrides").Map()
+	names := make([]string, 0, len(fields))
+	search := make([]string, 0, len(fields))
+	columns := make([]ztype.Map, 0, len(fields))
+	for _, name := range zarray.Unique(fields) {
+		f, ok := m.getField(name)
+		if !ok || name == DeletedAtKey || m.isBlindIndexColumn(name) || isFieldHashed(f) {
+			continue
+		}
+		visibility := m.FieldVisibility(name)
+		if visibility == mSchema.VisibilityHidden {
+			continue
+		}
+
+		typ := string(f.Type)
+		if name == idKey && *m.define.Options.CryptID {
+			typ = "string"
+		}
+		column := ztype.Map{
+			"name":  name,
+			"label": viewLabel(name, f),
+			"type":  typ,
+		}
+
+		// 加密与脱敏的值无法在数据库中比较
+		opaque := isFieldCrypt(f) || visibility == mSchema.VisibilityMasked
+		column["sortable"] = !opaque && f.Type != schema.Text && f.Type != schema.JSON && f.Type != schema.Bytes
+		column["searchable"] = !opaque && len(f.Options.Enum) == 0 && (f.Type == schema.String || f.Type == schema.Text) &&
+			!zarray.Contains(keys, name)
+		if filter := m.viewFilter(name, f, relations); filter != nil && (!opaque || f.Options.BlindIndex) {
+			column["filterable"] = true
+			column["filter"] = filter
+		} else {
+			column["filterable"] = false
+		}
+		if visibility == mSchema.VisibilityMasked {
+			column["masked"] = true
+		}
+
+		switch {
+		case len(f.Options.Enum) > 0:
+			column["display"] = "tag"
+			column["options"] = viewOptions(f)
+		case f.Type == schema.Bool:
+			column["display"] = "switch"
+		case f.Type == schema.Time:
+			column["display"] = "datetime"
+			column["format"] = m.timeFormat(f)
+		case f.Type == schema.JSON:
+			column["display"] = "json"
+		default:
+			column["display"] = "text"
+		}
+		if picker, ok := relations[name]; ok {
+			column["display"] = "relation"
+			column["relation"] = picker
+		}
+		if layout := data.Get("layouts").Get(name).Map(); !layout.IsEmpty() {
+			column["layout"] = layout
+		}
+
+		if column = viewOverride(column, overrides.Get(name).Map()); column == nil {
+			continue
+		}
+		if ztype.ToBool(column["searchable"]) {
+			search = append(search, name)
+		}
+		names = append(names, name)
+		columns = append(columns, column)
+	}
+
+	title := data.Get("title").String()
+	if title == "" {
+		title = m.GetName()
+	}
+
+	sortBy := data.Get("sort").Map()
+	if sortBy.IsEmpty() && len(keys) > 0 {
+		sortBy = ztype.Map{"field": keys[0], "direction": "desc"}
+	}
+
+	actions := data.Get("actions").Map()
+	if actions.IsEmpty() {
+		actions = m.viewActions(views)
+	}
+
+	return ztype.Map{
+		"title":       title,
+		"primary_key": keys,
+		"fields":      names,
+		"columns":     columns,
+		"search":      search,
+		"sort":        sortBy,
+		"actions":     actions,
+	}
+}
+
+// viewWidget 按字段推断表单控件与控件属性
+func (m *Schema) viewWidget(f *mSchema.Field, item, props ztype.Map) {
+	if len(f.Options.Enum) > 0 {
+		item["options"] = viewOptions(f)
+		switch {
+		case f.Options.IsArray:
+			item["widget"] = "checkbox"
+		case len(f.Options.Enum) <= 4:
+			item["widget"] = "radio"
+		default:
+			item["widget"] = "select"
+		}
+		return
+	}
+
+	if isFieldHashed(f) {
+		// 哈希存储的字段留空表示不修改
+		item["widget"] = "password"
+		item["required"] = false
+		return
+	}
+
+	switch f.Type {
+	case schema.Bool:
+		item["widget"] = "switch"
+	case schema.Int, schema.Int8, schema.Int16, schema.Int32, schema.Int64,
+		schema.Uint, schema.Uint8, schema.Uint16, schema.Uint32, schema.Uint64:
+		item["widget"] = "number"
+		props["precision"] = 0
+		if r, ok := jsonSchemaIntRanges[f.Type]; ok {
+			props["min"], props["max"] = r[0], r[1]
+		}
+		if f.Size > 0 {
+			props["max"] = f.Size
+		}
+	case schema.Float:
+		item["widget"] = "number"
+		if f.Size > 0 {
+			props["max"] = f.Size
+		}
+	case mSchema.Decimal:
+		item["widget"] = "number"
+		_, scale := decimalSpec(f)
+		props["precision"] = scale
+		props["string_mode"] = true
+	case schema.Time:
+		format := m.timeFormat(f)
+		item["widget"] = "datetime"
+		if !strings.ContainsAny(format, "HhGgis") {
+			item["widget"] = "date"
+		}
+		props["format"] = format
+	case schema.JSON:
+		item["widget"] = "json"
+		if f.Options.IsArray {
+			item["widget"] = "tags"
+		}
+	case schema.Bytes:
+		item["widget"] = "upload"
+	case schema.Text:
+		item["widget"] = "textarea"
+	default:
+		if f.Size > 255 {
+			item["widget"] = "textarea"
+		}
+		if f.Size > 0 {
+			props["maxlength"] = f.Size
+		}
+		for _, v := range f.Validations {
+			switch v.Method {
+			case "mail":
+				props["type"] = "email"
+			case "url":
+				props["type"] = "url"
+			case "mobile":
+				props["type"] = "tel"
+			}
+		}
+	}
+}
+
+// viewFilter 列表筛选控件，不支持筛选时返回 nil
+func (m *Schema) viewFilter(name string, f *mSchema.Field, relations map[string]ztype.Map) ztype.Map {
+	if picker, ok := relations[name]; ok {
+		return ztype.Map{"widget": "relation", "relation": picker}
+	}
+	if len(f.Options.Enum) > 0 {
+		return ztype.Map{"widget": "select", "options": viewOptions(f), "multiple": true}
+	}
+
+	switch f.Type {
+	case schema.Bool:
+		return ztype.Map{"widget": "select", "options": []mSchema.FieldEnum{{Value: "true", Label: "是"}, {Value: "false", Label: "否"}}}
+	case schema.Time:
+		return ztype.Map{"widget": "date_range", "range": true}
+	case schema.Int, schema.Int8, schema.Int16, schema.Int32, schema.Int64,
+		schema.Uint, schema.Uint8, schema.Uint16, schema.Uint32, schema.Uint64,
+		schema.Float, mSchema.Decimal:
+		if zarray.Contains(m.PrimaryKeys(), name) {
+			return ztype.Map{"widget": "input"}
+		}
+		return ztype.Map{"widget": "number_range", "range": true}
+	case schema.String:
+		if zarray.Contains(m.PrimaryKeys(), name) || f.Index != nil || f.Unique != nil || f.Options.BlindIndex {
+			return ztype.Map{"widget": "input"}
+		}
+	}
+	return nil
+}
+
+// viewActions 默认操作，只包含 restapi 提供路由的操作，禁用的视图不提供对应操作
+func (m *Schema) viewActions(views ztype.Type) ztype.Map {
+	enabled := func(view string) bool {
+		return !views.Get(view).Get("disabled").Bool()
+	}
+
+	toolbar, row := []string{}, []string{}
+	if enabled(FormViewName) {
+		toolbar = append(toolbar, "create")
+	}
+	// 复合主键模型没有 /{model}/{id} 路由
+	if !m.IsCompositeKey() {
+		if enabled("info") {
+			row = append(row, "info")
+		}
+		if enabled(FormViewName) {
+			row = append(row, "edit")
+		}
+		row = append(row, "delete")
+	}
+	return ztype.Map{"toolbar": toolbar, "row": row, "batch": []string{}}
+}
+
+// viewRelationPickers 一对一关联的本表外键字段对应的关联选择器
+func (m *Schema) viewRelationPickers() map[string]ztype.Map {
+	pickers := make(map[string]ztype.Map)
+	names := zarray.Keys(m.define.Relations)
+	sort.Strings(names)
+	for _, relName := range names {
+		rel := m.define.Relations[relName]
+		if rel.Type != mSchema.RelationSingle && rel.Type != mSchema.RelationSingleMerge {
+			continue
+		}
+		if len(rel.ForeignKey) != 1 || len(rel.SchemaKey) != 1 {
+			continue
+		}
+		field := rel.ForeignKey[0]
+		if _, ok := m.define.Fields[field]; !ok {
+			continue
+		}
+		if _, ok := pickers[field]; ok {
+			continue
+		}
+
+		picker := ztype.Map{
+			"name":        relName,
+			"schema":      rel.Schema,
+			"value_field": rel.SchemaKey[0],
+			"label_field": rel.SchemaKey[0],
+		}
+		if target, ok := m.getSchema(rel.Schema); ok {
+			picker["label_field"] = target.viewLabelField(rel.SchemaKey[0])
+		}
+		pickers[field] = picker
+	}
+	return pickers
+}
+
+// viewLabelField 作为关联选项显示的字段，Extend.label_field 优先
+func (m *Schema) viewLabelField(fallback string) string {
+	if field := m.define.Extend.Get("label_field").String(); field != "" {
+		return field
+	}
+	for _, name := range viewLabelFields {
+		if f, ok := m.define.Fields[name]; ok && !isFieldCrypt(&f) && !isFieldHashed(&f) {
+			return name
+		}
+	}
+
+	names := zarray.Keys(m.define.Fields)
+	sort.Strings(names)
+	for _, name := range names {
+		f := m.define.Fields[name]
+		if f.Type == schema.String && len(f.Options.Enum) == 0 && f.Options.Crypt == "" {
+			return name
+		}
+	}
+	return fallback
+}
+
+// isBlindIndexColumn 是否为自动生成的盲索引列
+func (m *Schema) isBlindIndexColumn(name string) bool {
+	for _, column := range m.blindIndexes {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isFieldHashed 是否为不可逆的哈希字段
+func isFieldHashed(f *mSchema.Field) bool {
+	switch strings.ToLower(f.Options.Crypt) {
+	case "md5", "password":
+		return true
+	}
+	return false
+}
+
+// viewRules 校验规则转换为客户端通用格式
+func viewRules(f *mSchema.Field, required bool) []ztype.Map {
+	rules := make([]ztype.Map, 0, len(f.Validations)+2)
+	if required {
+		rules = append(rules, ztype.Map{"type": "required"})
+	}
+	if f.Type == schema.String && f.Size > 0 && !isFieldHashed(f) {
+		rules = append(rules, ztype.Map{"type": "maxLength", "value": f.Size})
+	}
+
+	for _, v := range f.Validations {
+		rule := ztype.Map{}
+		switch v.Method {
+		case "regex":
+			rule["type"], rule["value"] = "pattern", ztype.ToString(v.Args)
+		case "minLength", "maxLength":
+			rule["type"], rule["value"] = v.Method, ztype.ToInt(v.Args)
+		case "min", "max":
+			rule["type"], rule["value"] = v.Method, ztype.ToFloat64(v.Args)
+		case "mail":
+			rule["type"] = "email"
+		case "url", "ip":
+			rule["type"] = v.Method
+		case "mobile":
+			rule["type"], rule["value"] = "pattern", `^1[3-9]\d{9}$`
+		case "enum":
+			rule["type"], rule["value"] = "enum", ztype.ToSlice(v.Args).Value()
+		default:
+			rule["type"] = v.Method
+			if v.Args != nil {
+				rule["value"] = v.Args
+			}
+		}
+		if v.Message != "" {
+			rule["message"] = v.Message
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func viewOptions(f *mSchema.Field) []mSchema.FieldEnum {
+	options := make([]mSchema.FieldEnum, 0, len(f.Options.Enum))
+	for _, e := range f.Options.Enum {
+		if e.Label == "" {
+			e.Label = e.Value
+		}
+		options = append(options, e)
+	}
+	return options
+}
+
+func viewLabel(name string, f *mSchema.Field) string {
+	if f.Label != "" {
+		return f.Label
+	}
+	return name
+}
+
+// viewOverride 合并字段覆盖配置，hidden 为 true 时移除该字段
+func viewOverride(item, override ztype.Map) ztype.Map {
+	if override.Get("hidden").Bool() {
+		return nil
+	}
+	for k, v := range override {
+		if k == "hidden" || k == "name" {
+			continue
+		}
+		item[k] = v
+	}
+	return item
+}
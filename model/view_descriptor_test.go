@@ -0,0 +1,121 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func TestViewDescriptors(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	timestamps := true
+	authors := schema.Schema{
+		Name: "vd_authors",
+		Fields: map[string]schema.Field{
+			"nickname": {Type: schema.String, Size: 40, Label: "昵称"},
+			"password": {Type: schema.String, Size: 100, Options: schema.FieldOption{Crypt: "password"}},
+		},
+	}
+	articles := schema.Schema{
+		Name:    "vd_articles",
+		Table:   schema.Table{Comment: "文章"},
+		Options: schema.Options{Timestamps: &timestamps},
+		Fields: map[string]schema.Field{
+			"title": {Type: schema.String, Size: 80, Label: "标题", Validations: []schema.Validations{
+				{Method: "minLength", Args: 2, Message: "标题太短"},
+			}},
+			"status": {Type: schema.Int8, Label: "状态", Default: 1, Options: schema.FieldOption{
+				Enum: []schema.FieldEnum{{Value: "1", Label: "草稿"}, {Value: "2", Label: "发布"}},
+			}},
+			"tags":      {Type: schema.JSON, Nullable: true, Options: schema.FieldOption{IsArray: true}},
+			"body":      {Type: schema.Text, Label: "内容"},
+			"price":     {Type: schema.Decimal, Size: 8, Scale: 2},
+			"author_id": {Type: schema.Int, Label: "作者"},
+		},
+		Relations: map[string]schema.Relation{
+			"author": {Type: schema.RelationSingle, Schema: "vd_authors", ForeignKey: []string{"author_id"}, SchemaKey: []string{"id"}},
+		},
+		Extend: ztype.Map{
+			"views": ztype.Map{
+				"form": ztype.Map{
+					"overrides": ztype.Map{
+						"body":  ztype.Map{"widget": "editor"},
+						"price": ztype.Map{"hidden": true},
+					},
+				},
+				"info": ztype.Map{"disabled": true},
+			},
+		},
+	}
+	_, schemas := newTestSchemas(t, authors, articles)
+	m := schemas.MustGet("vd_articles")
+
+	form := m.FormView("")
+	tt.Equal("文章", form.Get("title").String())
+	items := make(map[string]ztype.Map)
+	for _, item := range form["items"].([]ztype.Map) {
+		items[item.Get("name").String()] = item
+	}
+	tt.Equal(false, items[IDKey()] != nil)
+	tt.Equal(false, items[CreatedAtKey] != nil)
+	tt.Equal(false, items["price"] != nil)
+
+	title := items["title"]
+	tt.Equal("input", title.Get("widget").String())
+	tt.Equal(true, title.Get("required").Bool())
+	tt.Equal(80, title.Get("props").Get("maxlength").Int())
+	rules := title["rules"].([]ztype.Map)
+	tt.Equal(3, len(rules))
+	tt.Equal("minLength", rules[2].Get("type").String())
+	tt.Equal("标题太短", rules[2].Get("message").String())
+
+	tt.Equal("radio", items["status"].Get("widget").String())
+	tt.Equal(false, items["status"].Get("required").Bool())
+	tt.Equal("tags", items["tags"].Get("widget").String())
+	tt.Equal("editor", items["body"].Get("widget").String())
+
+	author := items["author_id"]
+	tt.Equal("relation", author.Get("widget").String())
+	tt.Equal("vd_authors", author.Get("relation").Get("schema").String())
+	tt.Equal("nickname", author.Get("relation").Get("label_field").String())
+
+	password := schemas.MustGet("vd_authors").FormView("")["items"].([]ztype.Map)
+	for _, item := range password {
+		if item.Get("name").String() == "password" {
+			tt.Equal("password", item.Get("widget").String())
+			tt.Equal(false, item.Get("required").Bool())
+		}
+	}
+
+	list := m.ListView("")
+	columns := make(map[string]ztype.Map)
+	for _, column := range list["columns"].([]ztype.Map) {
+		columns[column.Get("name").String()] = column
+	}
+	tt.Equal(true, columns[IDKey()] != nil)
+	tt.Equal(true, columns[CreatedAtKey].Get("sortable").Bool())
+	tt.Equal("date_range", columns[CreatedAtKey].Get("filter").Get("widget").String())
+	tt.Equal(true, columns["title"].Get("searchable").Bool())
+	tt.Equal(false, columns["title"].Get("filterable").Bool())
+	tt.Equal(false, columns["body"].Get("sortable").Bool())
+	tt.Equal("tag", columns["status"].Get("display").String())
+	tt.Equal(true, columns["status"].Get("filterable").Bool())
+	tt.Equal("relation", columns["author_id"].Get("filter").Get("widget").String())
+	search := list["search"].([]string)
+	tt.Equal(2, len(search))
+	tt.Equal(true, zarray.Contains(search, "title") && zarray.Contains(search, "body"))
+	tt.Equal(IDKey(), list.Get("sort").Get("field").String())
+
+	actions := list.Get("actions").Map()
+	tt.Equal([]string{"create"}, actions.Get("toolbar").Slice().String())
+	tt.Equal([]string{"edit", "delete"}, actions.Get("row").Slice().String())
+	tt.Equal(0, len(actions.Get("batch").Slice().String()))
+
+	for _, column := range schemas.MustGet("vd_authors").ListView("")["columns"].([]ztype.Map) {
+		tt.Equal(false, column.Get("name").String() == "password")
+	}
+}
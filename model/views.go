@@ -13,7 +13,19 @@ func (m *Schema) ParseLables(items ztype.Maps) ztype.Maps {
 				continue
 			}
 
-			if len(s.Options.Enum) > 0 {
+			if len(s.Options.Enum) > 0 && s.Options.IsArray {
+				values := v.Get(k).Slice().String()
+				labels := make([]string, 0, len(values))
+				for i := range values {
+					for j := range s.Options.Enum {
+						if s.Options.Enum[j].Value == values[i] {
+							labels = append(labels, s.Options.Enum[j].Label)
+							break
+						}
+					}
+				}
+				v[k+"_label"] = labels
+			} else if len(s.Options.Enum) > 0 {
 				for i := range s.Options.Enum {
 					if s.Options.Enum[i].Value == v.Get(k).String() {
 						v[k+"_label"] = s.Options.Enum[i].Label
@@ -41,7 +53,7 @@ func (m *Schema) GetViewFields(view string) []string {
 			fields = m.GetFields()
 		}
 	}
-	return zarray.Unique(append(fields, idKey))
+	return zarray.Unique(append(fields, m.PrimaryKeys()...))
 }
 
 func parseViewLists(m *Schema) ztype.Map {
@@ -52,8 +64,9 @@ func parseViewLists(m *Schema) ztype.Map {
 		return ztype.Map{}
 	}
 
-	fields := append([]string{idKey}, data.Get("fields").Slice().String()...)
-	if len(fields) == 1 {
+	keys := m.PrimaryKeys()
+	fields := append(append([]string{}, keys...), data.Get("fields").Slice().String()...)
+	if len(fields) == len(keys) {
 		fields = append(fields, m.GetFields()...)
 	}
 	fields = zarray.Unique(fields)
@@ -100,8 +113,9 @@ func parseViewInfo(m *Schema) ztype.Map {
 
 	columns := make(map[string]ztype.Map, 0)
 
-	fields := append([]string{idKey}, data.Get("fields").Slice().String()...)
-	if len(fields) == 1 {
+	keys := m.PrimaryKeys()
+	fields := append(append([]string{}, keys...), data.Get("fields").Slice().String()...)
+	if len(fields) == len(keys) {
 		fields = append(fields, m.fullFields...)
 	}
 	fields = zarray.Unique(fields)
@@ -154,5 +168,5 @@ func parseViews(m *Schema) ztype.Map {
 }
 
 func (m *Schema) GetViews() ztype.Map {
-	return m.views
+	return m.viewsWithPolicies(m.views)
 }
@@ -0,0 +1,245 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/sohaha/zlsgo/ztype"
+	mSchema "github.com/zlsgo/app_module/model/schema"
+)
+
+const (
+	// MaskEmail 邮箱脱敏，保留首字符与域名
+	MaskEmail = "email"
+	// MaskPhone 手机号脱敏，保留前三位与后四位
+	MaskPhone = "phone"
+	// MaskCard 卡号脱敏，仅保留后四位
+	MaskCard = "card"
+)
+
+// ErrMaskNotFound 脱敏函数不存在
+var ErrMaskNotFound = errors.New("mask function not found")
+
+// MaskFunc 脱敏函数
+type MaskFunc func(value string) string
+
+type (
+	rolesCtxKey  struct{}
+	systemCtxKey struct{}
+
+	fieldPolicy struct {
+		mask       MaskFunc
+		visibility mSchema.Visibility
+		roles      []string
+	}
+)
+
+var (
+	maskFuncs = map[string]MaskFunc{
+		MaskEmail: maskEmail,
+		MaskPhone: maskPhone,
+		MaskCard:  maskCard,
+		"":        maskDefault,
+	}
+	maskMu sync.RWMutex
+)
+
+// RegisterMask 注册自定义脱敏函数
+func RegisterMask(name string, fn MaskFunc) {
+	maskMu.Lock()
+	maskFuncs[strings.ToLower(name)] = fn
+	maskMu.Unlock()
+}
+
+func getMask(name string) (MaskFunc, bool) {
+	maskMu.RLock()
+	defer maskMu.RUnlock()
+	fn, ok := maskFuncs[strings.ToLower(name)]
+	return fn, ok && fn != nil
+}
+
+// WithRoles 在上下文中设置调用方角色，设置后查询结果将按字段可见性策略处理
+func WithRoles(ctx context.Context, roles ...string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, rolesCtxKey{}, append([]string{}, roles...))
+}
+
+// RolesFromContext 从上下文中获取调用方角色，未设置调用方时 ok 为 false
+func RolesFromContext(ctx context.Context) (roles []string, ok bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	roles, ok = ctx.Value(rolesCtxKey{}).([]string)
+	return
+}
+
+// WithSystem 将上下文标记为可信的系统调用，查询结果读取原值不应用字段可见性策略，仅用于内部逻辑（如登录校验密码）
+func WithSystem(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, systemCtxKey{}, true)
+}
+
+// IsSystemContext 上下文是否为可信的系统调用
+func IsSystemContext(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	system, _ := ctx.Value(systemCtxKey{}).(bool)
+	return system
+}
+
+// parseFieldPolicy 解析字段可见性策略
+func parseFieldPolicy(m *Schema, name string, f *mSchema.Field) error {
+	switch f.Options.Visibility {
+	case mSchema.VisibilityVisible:
+		return nil
+	case mSchema.VisibilityHidden, mSchema.VisibilityMasked:
+	default:
+		return errors.New("field " + name + ": unsupported visibility " + string(f.Options.Visibility))
+	}
+
+	policy := fieldPolicy{visibility: f.Options.Visibility, roles: f.Options.VisibleRoles}
+	if policy.visibility == mSchema.VisibilityMasked {
+		mask, ok := getMask(f.Options.Mask)
+		if !ok {
+			return errors.New("field " + name + ": " + ErrMaskNotFound.Error() + " " + f.Options.Mask)
+		}
+		policy.mask = mask
+	}
+	m.policies[name] = policy
+	return nil
+}
+
+// HasFieldPolicies 模型是否声明了字段可见性策略
+func (m *Schema) HasFieldPolicies() bool {
+	return len(m.policies) > 0
+}
+
+// FieldVisibility 返回当前调用方对字段的可见性，未设置角色时按无角色处理，仅系统调用不受限制
+func (m *Schema) FieldVisibility(name string) mSchema.Visibility {
+	policy, ok := m.policies[name]
+	if !ok || IsSystemContext(m.ctx) {
+		return mSchema.VisibilityVisible
+	}
+	roles, _ := RolesFromContext(m.ctx)
+	for i := range roles {
+		if zarray.Contains(policy.roles, roles[i]) {
+			return mSchema.VisibilityVisible
+		}
+	}
+	return policy.visibility
+}
+
+// applyFieldPolicies 按调用方隐藏或脱敏查询结果
+func (m *Schema) applyFieldPolicies(rows ztype.Maps) {
+	if len(m.policies) == 0 || len(rows) == 0 || IsSystemContext(m.ctx) {
+		return
+	}
+
+	for name, policy := range m.policies {
+		switch m.FieldVisibility(name) {
+		case mSchema.VisibilityHidden:
+			for i := range rows {
+				delete(rows[i], name)
+			}
+		case mSchema.VisibilityMasked:
+			for i := range rows {
+				v, ok := rows[i][name]
+				if !ok || v == nil {
+					continue
+				}
+				rows[i][name] = policy.mask(ztype.ToString(v))
+			}
+		}
+	}
+}
+
+// viewsWithPolicies 按调用方过滤视图中的列
+func (m *Schema) viewsWithPolicies(views ztype.Map) ztype.Map {
+	if len(m.policies) == 0 || IsSystemContext(m.ctx) {
+		return views
+	}
+
+	out := make(ztype.Map, len(views))
+	for key, v := range views {
+		view, ok := v.(ztype.Map)
+		if !ok || view.IsEmpty() {
+			out[key] = v
+			continue
+		}
+		nview := make(ztype.Map, len(view))
+		for k, val := range view {
+			nview[k] = val
+		}
+
+		if columns, ok := view["columns"].(map[string]ztype.Map); ok {
+			ncolumns := make(map[string]ztype.Map, len(columns))
+			for name, column := range columns {
+				switch m.FieldVisibility(name) {
+				case mSchema.VisibilityHidden:
+					continue
+				case mSchema.VisibilityMasked:
+					ncolumn := make(ztype.Map, len(column)+1)
+					for ck, cv := range column {
+						ncolumn[ck] = cv
+					}
+					ncolumn["masked"] = true
+					column = ncolumn
+				}
+				ncolumns[name] = column
+			}
+			nview["columns"] = ncolumns
+		}
+		if fields, ok := view["fields"].([]string); ok {
+			nview["fields"] = zarray.Filter(fields, func(_ int, name string) bool {
+				return m.FieldVisibility(name) != mSchema.VisibilityHidden
+			})
+		}
+		out[key] = nview
+	}
+	return out
+}
+
+func maskDefault(s string) string {
+	r := []rune(s)
+	switch n := len(r); {
+	case n == 0:
+		return s
+	case n <= 2:
+		return strings.Repeat("*", n)
+	default:
+		return string(r[0]) + strings.Repeat("*", n-2) + string(r[n-1])
+	}
+}
+
+func maskEmail(s string) string {
+	i := strings.LastIndex(s, "@")
+	if i <= 0 {
+		return maskDefault(s)
+	}
+	local := []rune(s[:i])
+	return string(local[0]) + "***" + s[i:]
+}
+
+func maskPhone(s string) string {
+	r := []rune(s)
+	if len(r) < 8 {
+		return maskDefault(s)
+	}
+	return string(r[:3]) + strings.Repeat("*", len(r)-7) + string(r[len(r)-4:])
+}
+
+func maskCard(s string) string {
+	r := []rune(strings.ReplaceAll(s, " ", ""))
+	if len(r) <= 4 {
+		return strings.Repeat("*", len(r))
+	}
+	return strings.Repeat("*", len(r)-4) + string(r[len(r)-4:])
+}
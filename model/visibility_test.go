@@ -0,0 +1,96 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sohaha/zlsgo"
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/sohaha/zlsgo/ztype"
+	"github.com/zlsgo/app_module/model/schema"
+)
+
+func TestFieldMask(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	tt.Equal("a***@example.com", maskEmail("alice@example.com"))
+	tt.Equal("138****0001", maskPhone("13800000001"))
+	tt.Equal("************4242", maskCard("4242 4242 4242 4242"))
+	tt.Equal("z**s", maskDefault("zlsg"))
+	tt.Equal("**", maskDefault("ab"))
+}
+
+func TestFieldVisibility(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+
+	s := schema.Schema{
+		Name:  "visibility_users",
+		Table: schema.Table{Name: "visibility_users"},
+		Fields: map[string]schema.Field{
+			"name":     {Type: "string", Label: "Name"},
+			"password": {Type: "string", Label: "Password", Options: schema.FieldOption{Visibility: schema.VisibilityHidden}},
+			"email": {Type: "string", Label: "Email", Options: schema.FieldOption{
+				Visibility:   schema.VisibilityMasked,
+				Mask:         MaskEmail,
+				VisibleRoles: []string{"admin"},
+			}},
+		},
+	}
+	_, schemas := newTestSchemas(t, s)
+	m := schemas.MustGet("visibility_users")
+	tt.Equal(true, m.HasFieldPolicies())
+
+	id, err := m.Model().Insert(ztype.Map{"name": "a", "password": "secret", "email": "alice@example.com"})
+	tt.NoError(err)
+
+	// 未声明调用方时同样应用策略
+	row, err := m.Model().FindOneByID(id)
+	tt.NoError(err)
+	tt.Equal(false, row.Get("password").Exists())
+	tt.Equal("a***@example.com", row.Get("email").String())
+	tt.Equal(false, zarray.Contains(exportFields(m), "password"))
+
+	system := m.Model().WithContext(WithSystem(context.Background()))
+	row, err = system.FindOneByID(id)
+	tt.NoError(err)
+	tt.Equal("secret", row.Get("password").String())
+	tt.Equal("alice@example.com", row.Get("email").String())
+
+	guest := m.Model().WithContext(WithRoles(context.Background(), "user"))
+	row, err = guest.FindOneByID(id)
+	tt.NoError(err)
+	tt.Equal(false, row.Get("password").Exists())
+	tt.Equal("a***@example.com", row.Get("email").String())
+
+	page, err := guest.Pages(1, 10, Filter{})
+	tt.NoError(err)
+	tt.Equal(false, page.Items[0].Get("password").Exists())
+	tt.Equal("a***@example.com", page.Items[0].Get("email").String())
+
+	admin := m.Model().WithContext(WithRoles(context.Background(), "admin"))
+	row, err = admin.FindOneByID(id)
+	tt.NoError(err)
+	tt.Equal(false, row.Get("password").Exists())
+	tt.Equal("alice@example.com", row.Get("email").String())
+
+	columns := guest.Schema().GetViews().Get("lists").Value().(ztype.Map)["columns"].(map[string]ztype.Map)
+	_, ok := columns["password"]
+	tt.Equal(false, ok)
+	tt.Equal(true, columns["email"].Get("masked").Bool())
+	columns = m.GetViews().Get("lists").Value().(ztype.Map)["columns"].(map[string]ztype.Map)
+	_, ok = columns["password"]
+	tt.Equal(false, ok)
+	columns = system.Schema().GetViews().Get("lists").Value().(ztype.Map)["columns"].(map[string]ztype.Map)
+	_, ok = columns["password"]
+	tt.Equal(true, ok)
+
+	bad := schema.Schema{
+		Name:  "visibility_bad",
+		Table: schema.Table{Name: "visibility_bad"},
+		Fields: map[string]schema.Field{
+			"card": {Type: "string", Options: schema.FieldOption{Visibility: schema.VisibilityMasked, Mask: "unknown"}},
+		},
+	}
+	_, err = schemas.Reg("visibility_bad", bad, false)
+	tt.Equal(true, err != nil)
+}
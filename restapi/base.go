@@ -59,9 +59,22 @@ func (h *controller) Init(r *znet.Engine) error {
 		if !ok {
 			return nil, zerror.NotFound.Text("model not found")
 		}
+		if h.options.Roles != nil {
+			c.Request = c.Request.WithContext(model.WithRoles(c.Request.Context(), h.options.Roles(c)...))
+		}
+		if h.options.TimeZone != nil {
+			if loc := h.options.TimeZone(c); loc != nil {
+				c.Request = c.Request.WithContext(model.WithTimeZone(c.Request.Context(), loc))
+			}
+		}
 
 		method := c.Request.Method
 
+		// 复合主键模型没有 id 列，按 id 访问的路由不可用
+		if id != "" && id != "*" && mod.Schema().IsCompositeKey() {
+			return nil, zerror.NotFound.Text("id route not supported for composite key model")
+		}
+
 		if h.options.ResponseHook != nil && !h.options.ResponseHook(c, modelName, id, method) {
 			r.HandleNotFound(c)
 			return nil, nil
@@ -87,7 +100,7 @@ func (h *controller) Init(r *znet.Engine) error {
 			if err != nil {
 				return nil, err
 			}
-			return find(c, mod, id, filter, queryFn, maxPageSize)
+			return find(c, withRequestContext(c, mod), id, filter, queryFn, maxPageSize)
 		case "POST":
 			if id != "" {
 				return nil, zerror.InvalidInput.Text("id not allowed for POST")
@@ -132,7 +145,11 @@ func find(
 			pagesize = maxPageSize
 		}
 		return mod.Pages(page, pagesize, filter, func(o *model.CondOptions) {
-			o.OrderBy = []model.OrderByItem{{Field: model.IDKey(), Direction: "DESC"}}
+			keys := mod.Schema().PrimaryKeys()
+			o.OrderBy = make([]model.OrderByItem, 0, len(keys))
+			for _, key := range keys {
+				o.OrderBy = append(o.OrderBy, model.OrderByItem{Field: key, Direction: "DESC"})
+			}
 
 			if fn != nil {
 				fn(o)
@@ -141,6 +158,9 @@ func find(
 	case "*":
 		return nil, zerror.InvalidInput.Text("全量查询不允许，请使用分页")
 	default:
+		if mod.Schema().IsCompositeKey() {
+			return nil, zerror.InvalidInput.Wrap(model.ErrInvalidKey, "id not supported")
+		}
 		filter[model.IDKey()] = id
 		row, err := mod.FindOne(filter, fn)
 		if err != nil {
@@ -914,6 +934,12 @@ func defaultErrorHandler(c *znet.Context, err error) {
 }
 
 func statusCodeFromError(err error) int {
+	if errors.Is(err, model.ErrTenantRequired) || errors.Is(err, model.ErrInvalidTenant) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, model.ErrHookCancelled) {
+		return http.StatusConflict
+	}
 	switch zerror.GetTag(err) {
 	case zerror.InvalidInput:
 		return http.StatusBadRequest
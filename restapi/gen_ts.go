@@ -0,0 +1,233 @@
+package restapi
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sohaha/zlsgo/zarray"
+	"github.com/zlsgo/app_module/model"
+)
+
+const tsClientRuntime = `
+export type Fetcher = <T>(method: string, url: string, body?: unknown) => Promise<T>;
+
+export interface PageInfo {
+  total: number;
+  count: number;
+  curpage: number;
+  [key: string]: number;
+}
+
+export interface Page<T> {
+  items: T[];
+  page: PageInfo;
+}
+
+export type FilterOperators<T> = {
+  $eq?: T;
+  $ne?: T;
+  $gt?: T;
+  $gte?: T;
+  $lt?: T;
+  $lte?: T;
+  $in?: T[];
+  $nin?: T[];
+  $like?: string;
+  $between?: [T, T];
+  $null?: true;
+  $notnull?: true;
+};
+
+export type Filter<T> = { [K in keyof T]?: T[K] | FilterOperators<NonNullable<T[K]>> } & {
+  $or?: Filter<T>[];
+  $and?: Filter<T>[];
+};
+
+export type Order<F extends string> = F | ` + "`-${F}` | `${F}:asc` | `${F}:desc`" + `;
+
+export interface Query<F extends string, O extends string, W extends string, T> {
+  fields?: F[];
+  order?: Order<O>[];
+  with?: W[];
+  filter?: Filter<T>;
+}
+
+export interface PageQuery<F extends string, O extends string, W extends string, T> extends Query<F, O, W, T> {
+  page?: number;
+  pagesize?: number;
+}
+
+function toSearch(query: object): string {
+  const params = new URLSearchParams();
+  for (const [key, value] of Object.entries(query)) {
+    if (value === undefined) {
+      continue;
+    }
+    if (key === "filter") {
+      params.set(key, JSON.stringify(value));
+    } else if (Array.isArray(value)) {
+      params.set(key, value.join(","));
+    } else {
+      params.set(key, String(value));
+    }
+  }
+  const search = params.toString();
+  return search ? "?" + search : "";
+}
+`
+
+// GenerateTS 生成模型 TypeScript 类型与 REST API 客户端，字段、过滤、排序、关联与方法按 Options 的白名单收窄，names 为空时生成全部模型
+func GenerateTS(ss *model.Schemas, opts Options, names ...string) ([]byte, error) {
+	if ss == nil {
+		return nil, errors.New("schemas is required")
+	}
+	if len(names) == 0 {
+		ss.ForEach(func(key string, _ *model.Schema) bool {
+			names = append(names, key)
+			return true
+		})
+	}
+	names = zarray.Unique(names)
+	sort.Strings(names)
+
+	types, err := ss.GenerateTS(names...)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.AllowMethods = normalizeAllowMethods(opts.AllowMethods)
+	var b bytes.Buffer
+	b.Write(types)
+	b.WriteString(tsClientRuntime)
+
+	var client bytes.Buffer
+	for _, alias := range names {
+		m, _ := ss.Get(alias)
+		tsRoutes(&b, &client, m, &opts)
+	}
+
+	b.WriteString("\nexport function createClient(fetcher: Fetcher, prefix = " + strconv.Quote(opts.Prefix) + ") {\n  return {\n")
+	b.Write(client.Bytes())
+	b.WriteString("  };\n}\n")
+	return b.Bytes(), nil
+}
+
+// GenerateTS 使用模块配置生成 TypeScript 类型与 REST API 客户端
+func (m Module) GenerateTS(ss *model.Schemas, names ...string) ([]byte, error) {
+	return GenerateTS(ss, m.options, names...)
+}
+
+// tsRoutes 输出模型允许的字段、过滤、排序与关联联合类型，并向 client 写入对应的请求方法
+func tsRoutes(b, client *bytes.Buffer, m *model.Schema, opts *Options) {
+	alias := m.GetAlias()
+	name := model.TSTypeName(alias)
+	fields := m.GetFields()
+
+	selectable := zarray.Filter(fields, func(_ int, f string) bool { return fieldAllowed(opts, f) })
+	if fieldAllowed(opts, "*") {
+		selectable = append([]string{"*"}, selectable...)
+	}
+	filterable := zarray.Filter(fields, func(_ int, f string) bool { return filterFieldAllowed(opts, f) })
+	orderable := zarray.Filter(fields, func(_ int, f string) bool { return orderFieldAllowed(opts, f) })
+
+	relations := make([]string, 0, len(m.GetDefine().Relations))
+	for key := range m.GetDefine().Relations {
+		relations = append(relations, key)
+	}
+	sort.Strings(relations)
+	with := make([]string, 0, len(relations))
+	for _, key := range relations {
+		if relationAllowed(opts, key) {
+			with = append(with, strconv.Quote(key), "`"+key+".${string}`")
+			continue
+		}
+		paths := make([]string, 0)
+		for path, ok := range opts.AllowRelations {
+			if ok && strings.HasPrefix(path, key+".") {
+				paths = append(paths, strconv.Quote(path))
+			}
+		}
+		sort.Strings(paths)
+		with = append(with, paths...)
+	}
+
+	b.WriteString("\n")
+	writeTSUnion(b, name+"Field", selectable)
+	writeTSUnion(b, name+"FilterField", filterable)
+	writeTSUnion(b, name+"OrderField", orderable)
+	if len(with) == 0 {
+		b.WriteString("export type " + name + "With = never;\n")
+	} else {
+		b.WriteString("export type " + name + "With = " + strings.Join(with, " | ") + ";\n")
+	}
+	b.WriteString("export type " + name + "FilterValues = Pick<" + name + "Read, Extract<" + name + "FilterField, keyof " + name + "Read>>;\n")
+
+	path := strconv.Quote("/" + alias)
+	query := name + "Field, " + name + "OrderField, W, " + name + "FilterValues"
+	result := name + "Read & Pick<" + name + "Relations, Extract<W, keyof " + name + "Relations>>"
+	id := name + "Read[\"id\"]"
+
+	var methods []string
+	if methodAllowed(opts.AllowMethods, "GET") {
+		methods = append(methods, "      list<W extends "+name+"With = never>(query: PageQuery<"+query+"> = {}) {\n"+
+			"        return fetcher<Page<"+result+">>(\"GET\", prefix + "+path+" + toSearch(query));\n      },\n")
+		if !m.IsCompositeKey() {
+			methods = append(methods, "      get<W extends "+name+"With = never>(id: "+id+", query: Query<"+query+"> = {}) {\n"+
+				"        return fetcher<"+result+">(\"GET\", prefix + "+path+" + \"/\" + encodeURIComponent(String(id)) + toSearch(query));\n      },\n")
+		}
+	}
+	if methodAllowed(opts.AllowMethods, "POST") {
+		created := "unknown"
+		if !m.IsCompositeKey() {
+			created = id
+		}
+		methods = append(methods, "      create(data: "+name+"Create) {\n"+
+			"        return fetcher<{ id: "+created+" }>(\"POST\", prefix + "+path+", data);\n      },\n")
+	}
+	if !m.IsCompositeKey() {
+		update := "PATCH"
+		if !methodAllowed(opts.AllowMethods, update) {
+			update = "PUT"
+		}
+		if methodAllowed(opts.AllowMethods, update) {
+			methods = append(methods, "      update(id: "+id+", data: "+name+"Update) {\n"+
+				"        return fetcher<{ total: number }>(\""+update+"\", prefix + "+path+" + \"/\" + encodeURIComponent(String(id)), data);\n      },\n")
+		}
+		if methodAllowed(opts.AllowMethods, "DELETE") {
+			methods = append(methods, "      delete(id: "+id+") {\n"+
+				"        return fetcher<{ total: number }>(\"DELETE\", prefix + "+path+" + \"/\" + encodeURIComponent(String(id)));\n      },\n")
+		}
+	}
+
+	client.WriteString("    " + tsKey(alias) + ": {\n")
+	for _, method := range methods {
+		client.WriteString(method)
+	}
+	client.WriteString("    },\n")
+}
+
+func writeTSUnion(b *bytes.Buffer, name string, values []string) {
+	if len(values) == 0 {
+		b.WriteString("export type " + name + " = never;\n")
+		return
+	}
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, strconv.Quote(v))
+	}
+	b.WriteString("export type " + name + " = " + strings.Join(quoted, " | ") + ";\n")
+}
+
+// tsKey 客户端对象的属性名，非标识符时加引号
+func tsKey(alias string) string {
+	for i, r := range alias {
+		if r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9' {
+			continue
+		}
+		return strconv.Quote(alias)
+	}
+	return alias
+}
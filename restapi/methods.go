@@ -7,8 +7,27 @@ import (
 	"github.com/sohaha/zlsgo/znet"
 	"github.com/sohaha/zlsgo/ztype"
 	"github.com/zlsgo/app_module/model"
+	"github.com/zlsgo/app_module/model/schema"
 )
 
+// withRequestContext 多租户、声明了字段可见性策略或指定了时区的请求绑定请求上下文
+func withRequestContext(c *znet.Context, store *model.Store) *model.Store {
+	if c == nil || c.Request == nil {
+		return store
+	}
+	s := store.Schema()
+	if _, ok := model.TimeZoneFromContext(c.Request.Context()); ok && !s.HasFieldPolicies() {
+		return store.WithContext(c.Request.Context())
+	}
+	if s.HasFieldPolicies() {
+		return store.WithContext(c.Request.Context())
+	}
+	if s.Tenancy() == schema.TenancyNone {
+		return store
+	}
+	return store.WithContext(c.Request.Context())
+}
+
 // FindById 获取一条数据
 func FindById(
 	c *znet.Context,
@@ -16,6 +35,7 @@ func FindById(
 	id string,
 	fn func(o *model.CondOptions),
 ) (ztype.Map, error) {
+	store = withRequestContext(c, store)
 	res, err := find(c, store, id, model.Filter{}, fn, defaultMaxPageSize)
 	if err != nil {
 		return nil, err
@@ -31,6 +51,7 @@ func Page(
 	filter model.Filter,
 	fn func(o *model.CondOptions),
 ) (*model.PageData, error) {
+	store = withRequestContext(c, store)
 	res, err := find(c, store, "", filter, fn, defaultMaxPageSize)
 	if err != nil {
 		return nil, err
@@ -46,6 +67,7 @@ func Find(
 	filter model.Filter,
 	fn func(o *model.CondOptions),
 ) (ztype.Maps, error) {
+	store = withRequestContext(c, store)
 	return store.Find(filter, fn)
 }
 
@@ -56,6 +78,7 @@ func Insert(
 	fn func(data ztype.Map) (ztype.Map, error),
 	o ...func(io *model.InsertOptions),
 ) (ztype.Map, error) {
+	store = withRequestContext(c, store)
 	j, err := c.GetJSONs()
 	if err != nil {
 		return nil, zerror.InvalidInput.Text(err.Error())
@@ -91,6 +114,7 @@ func InsertMany(
 	fn func(i int, data ztype.Map) (ztype.Map, error),
 	o ...func(io *model.InsertOptions),
 ) (ztype.Map, error) {
+	store = withRequestContext(c, store)
 	j, err := c.GetJSONs()
 	if err != nil {
 		return nil, zerror.InvalidInput.Text(err.Error())
@@ -132,6 +156,7 @@ func DeleteById(
 	id string,
 	handler func(old ztype.Map) error,
 ) (any, error) {
+	store = withRequestContext(c, store)
 	if len(id) == 0 {
 		return nil, zerror.InvalidInput.Text("id cannot empty")
 	}
@@ -141,6 +166,9 @@ func DeleteById(
 		if errors.Is(err, model.ErrNoRecord) {
 			return nil, zerror.NotFound.Text("id not found")
 		}
+		if errors.Is(err, model.ErrInvalidKey) {
+			return nil, zerror.WrapTag(zerror.InvalidInput)(err)
+		}
 		return nil, err
 	}
 	if handler != nil {
@@ -148,7 +176,7 @@ func DeleteById(
 			return nil, err
 		}
 	}
-	total, err := store.DeleteMany(model.Filter{model.IDKey(): id})
+	total, err := store.DeleteByID(id)
 	if err != nil {
 		return nil, err
 	}
@@ -162,6 +190,7 @@ func Delete(
 	filter model.Filter,
 	handler func(old ztype.Map) error,
 ) (any, error) {
+	store = withRequestContext(c, store)
 	if handler != nil {
 		rows, err := Find(c, store, filter, nil)
 		if err != nil {
@@ -186,6 +215,7 @@ func UpdateById(
 	id string,
 	handler func(old ztype.Map, data ztype.Map) (ztype.Map, error),
 ) (any, error) {
+	store = withRequestContext(c, store)
 	if id == "" {
 		return nil, zerror.InvalidInput.Text("id cannot empty")
 	}
@@ -205,6 +235,9 @@ func UpdateById(
 		if errors.Is(err, model.ErrNoRecord) {
 			return nil, zerror.NotFound.Text("id not found")
 		}
+		if errors.Is(err, model.ErrInvalidKey) {
+			return nil, zerror.WrapTag(zerror.InvalidInput)(err)
+		}
 		return nil, err
 	}
 	if handler != nil {
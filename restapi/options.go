@@ -1,6 +1,8 @@
 package restapi
 
 import (
+	"time"
+
 	"github.com/sohaha/zlsgo/znet"
 	"github.com/zlsgo/app_module/model"
 )
@@ -22,4 +24,8 @@ type Options struct {
 	DisableErrorHandler bool
 	RejectUnknownQuery  bool
 	AllowQueryKeys      map[string]bool
+	// Roles 解析调用方角色，用于字段可见性策略
+	Roles func(c *znet.Context) []string
+	// TimeZone 解析调用方时区，用于解析写入的时间与格式化返回的时间
+	TimeZone func(c *znet.Context) *time.Location
 }
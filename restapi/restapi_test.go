@@ -24,6 +24,7 @@ type testEnv struct {
 	engine   *znet.Engine
 	users    *model.Store
 	profiles *model.Store
+	schemas  *model.Schemas
 	cleanup  func()
 }
 
@@ -114,6 +115,7 @@ func newTestEnv(t *testing.T, opts *Options) *testEnv {
 		engine:   r,
 		users:    userStore,
 		profiles: profileStore,
+		schemas:  schemas,
 		cleanup: func() {
 			_ = db.Close()
 		},
@@ -223,6 +225,46 @@ func TestRestAPIMethodAllowlistNormalize(t *testing.T) {
 	tt.Equal(200, w.Code)
 }
 
+func TestGenerateTS(t *testing.T) {
+	tt := zlsgo.NewTest(t)
+	env := newTestEnv(t, nil)
+	defer env.cleanup()
+
+	src, err := Module{options: Options{Prefix: "/api"}}.GenerateTS(env.schemas)
+	tt.NoError(err)
+	code := string(src)
+	tt.Equal(true, strings.HasPrefix(code, "// Code generated by modelgen. DO NOT EDIT."))
+	tt.Equal(true, strings.Contains(code, "export interface UsersRead {"))
+	tt.Equal(true, strings.Contains(code, "  profile_id?: number | null;"))
+	tt.Equal(true, strings.Contains(code, "  profile: Partial<Pick<ProfilesRead, \"nickname\">> | null;"))
+	tt.Equal(true, strings.Contains(code, "export type UsersWith = \"profile\" | `profile.${string}`;"))
+	tt.Equal(true, strings.Contains(code, "export function createClient(fetcher: Fetcher, prefix = \"/api\")"))
+	tt.Equal(true, strings.Contains(code, "fetcher<{ total: number }>(\"PATCH\", prefix + \"/users\""))
+
+	src, err = GenerateTS(env.schemas, Options{
+		Prefix:         "/v1",
+		AllowMethods:   map[string]bool{"get": true, "put": true},
+		AllowFields:    map[string]bool{"id": true, "name": true},
+		AllowRelations: map[string]bool{"profile.nickname": true},
+	}, "users", "profiles")
+	tt.NoError(err)
+	code = string(src)
+	for _, line := range strings.Split(code, "\n") {
+		if strings.HasPrefix(line, "export type UsersField = ") {
+			tt.Equal(true, strings.Contains(line, `"name"`))
+			tt.Equal(false, strings.Contains(line, `"profile_id"`))
+			tt.Equal(false, strings.Contains(line, `"*"`))
+		}
+	}
+	tt.Equal(true, strings.Contains(code, "export type UsersWith = \"profile.nickname\";"))
+	tt.Equal(true, strings.Contains(code, "fetcher<{ total: number }>(\"PUT\", prefix + \"/users\""))
+	tt.Equal(false, strings.Contains(code, "\"POST\""))
+	tt.Equal(false, strings.Contains(code, "\"DELETE\""))
+
+	_, err = GenerateTS(env.schemas, Options{}, "missing")
+	tt.Equal(true, err != nil)
+}
+
 func TestRestAPIInvalidJSONBody(t *testing.T) {
 	tt := zlsgo.NewTest(t)
 	env := newTestEnv(t, &Options{Prefix: "/api"})